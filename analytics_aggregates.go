@@ -0,0 +1,166 @@
+package gcloudcx
+
+import (
+	"time"
+
+	"github.com/gildas/go-errors"
+)
+
+// AggregateQuery describes an aggregate query for conversations or users
+//
+// See: https://developer.genesys.cloud/api/rest/v2/analytics/#post-api-v2-analytics-conversations-aggregates-query
+//
+// See: https://developer.genesys.cloud/api/rest/v2/analytics/#post-api-v2-analytics-users-aggregates-query
+type AggregateQuery struct {
+	Interval    AnalyticsInterval `json:"interval"`
+	Granularity string            `json:"granularity,omitempty"` // ISO-8601 duration, e.g.: PT30M, P1D
+	TimeZone    string            `json:"timeZone,omitempty"`
+	GroupBy     []string          `json:"groupBy,omitempty"`
+	Filter      *AnalyticsFilter  `json:"filter,omitempty"`
+	Metrics     []string          `json:"metrics"`
+}
+
+// AggregateResult describes the aggregated data of a group
+type AggregateResult struct {
+	Group map[string]string `json:"group"`
+	Data  []*AggregateData  `json:"data"`
+}
+
+// AggregateData describes the metrics of a granularity interval
+type AggregateData struct {
+	Interval AnalyticsInterval  `json:"interval"`
+	Metrics  []*AggregateMetric `json:"metrics"`
+}
+
+// AggregateMetric describes an aggregated metric
+type AggregateMetric struct {
+	Metric    string         `json:"metric"`
+	Qualifier string         `json:"qualifier,omitempty"`
+	Stats     AggregateStats `json:"stats"`
+}
+
+// AggregateStats describes the statistics of an aggregated metric
+//
+// Durations (metrics starting with "t") are in milliseconds
+type AggregateStats struct {
+	Max         float64 `json:"max,omitempty"`
+	Min         float64 `json:"min,omitempty"`
+	Count       int64   `json:"count,omitempty"`
+	Sum         float64 `json:"sum,omitempty"`
+	Current     float64 `json:"current,omitempty"`
+	Ratio       float64 `json:"ratio,omitempty"`
+	Numerator   float64 `json:"numerator,omitempty"`
+	Denominator float64 `json:"denominator,omitempty"`
+	Target      float64 `json:"target,omitempty"`
+}
+
+// NewAggregateQuery creates a new aggregate query in the given interval for the given metrics
+func NewAggregateQuery(interval AnalyticsInterval, metrics ...string) *AggregateQuery {
+	return &AggregateQuery{Interval: interval, Metrics: metrics}
+}
+
+// WithGranularity sets the granularity of the query (ISO-8601 duration, e.g.: PT30M, P1D)
+func (query *AggregateQuery) WithGranularity(granularity string) *AggregateQuery {
+	query.Granularity = granularity
+	return query
+}
+
+// WithTimeZone sets the time zone used to compute the granularity intervals (e.g.: Asia/Tokyo)
+func (query *AggregateQuery) WithTimeZone(timezone string) *AggregateQuery {
+	query.TimeZone = timezone
+	return query
+}
+
+// WithGroupBy adds dimensions to group the results by (e.g.: queueId, userId, mediaType)
+func (query *AggregateQuery) WithGroupBy(dimensions ...string) *AggregateQuery {
+	query.GroupBy = append(query.GroupBy, dimensions...)
+	return query
+}
+
+// WithFilter sets the filter of the query
+func (query *AggregateQuery) WithFilter(filter *AnalyticsFilter) *AggregateQuery {
+	query.Filter = filter
+	return query
+}
+
+// QueryConversationAggregates queries conversation aggregates (handle time, abandons, service level, ...)
+func (client *Client) QueryConversationAggregates(query *AggregateQuery) ([]*AggregateResult, error) {
+	return client.queryAggregates("/analytics/conversations/aggregates/query", query)
+}
+
+// QueryUserAggregates queries user aggregates (presence and routing status durations)
+func (client *Client) QueryUserAggregates(query *AggregateQuery) ([]*AggregateResult, error) {
+	return client.queryAggregates("/analytics/users/aggregates/query", query)
+}
+
+func (client *Client) queryAggregates(path URI, query *AggregateQuery) ([]*AggregateResult, error) {
+	if query == nil {
+		return nil, errors.ArgumentMissing.With("query").WithStack()
+	}
+	if query.Interval.IsZero() {
+		return nil, errors.ArgumentMissing.With("interval").WithStack()
+	}
+	if len(query.Metrics) == 0 {
+		return nil, errors.ArgumentMissing.With("metrics").WithStack()
+	}
+	response := struct {
+		Results []*AggregateResult `json:"results"`
+	}{}
+	if err := client.Post(path, query, &response); err != nil {
+		return nil, err
+	}
+	return response.Results, nil
+}
+
+// Metric gets the stats of the given metric and qualifier
+//
+// If qualifier is empty, the first metric with the given name is returned
+func (data AggregateData) Metric(metric, qualifier string) (*AggregateStats, bool) {
+	for _, item := range data.Metrics {
+		if item.Metric == metric && (len(qualifier) == 0 || item.Qualifier == qualifier) {
+			return &item.Stats, true
+		}
+	}
+	return nil, false
+}
+
+// AverageHandleTime gets the average handle time (tHandle)
+func (data AggregateData) AverageHandleTime() time.Duration {
+	return data.AverageDuration("tHandle")
+}
+
+// AverageDuration gets the average duration of the given duration metric (tHandle, tTalk, tAcw, tAnswered, ...)
+func (data AggregateData) AverageDuration(metric string) time.Duration {
+	stats, found := data.Metric(metric, "")
+	if !found || stats.Count == 0 {
+		return 0
+	}
+	return time.Duration(stats.Sum/float64(stats.Count)) * time.Millisecond
+}
+
+// AbandonRate gets the ratio of abandoned conversations (tAbandon) over offered conversations (nOffered)
+func (data AggregateData) AbandonRate() float64 {
+	offered, found := data.Metric("nOffered", "")
+	if !found || offered.Count == 0 {
+		return 0
+	}
+	abandoned, found := data.Metric("tAbandon", "")
+	if !found {
+		return 0
+	}
+	return float64(abandoned.Count) / float64(offered.Count)
+}
+
+// StatusDurations gets the time spent in each status of the given metric
+//
+// metric is typically tSystemPresence, tOrganizationPresence, or tAgentRoutingStatus.
+// The returned map is keyed by the metric qualifier (e.g.: AVAILABLE, ON_QUEUE, IDLE)
+func (data AggregateData) StatusDurations(metric string) map[string]time.Duration {
+	durations := map[string]time.Duration{}
+	for _, item := range data.Metrics {
+		if item.Metric == metric {
+			durations[item.Qualifier] += time.Duration(item.Stats.Sum) * time.Millisecond
+		}
+	}
+	return durations
+}
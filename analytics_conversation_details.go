@@ -0,0 +1,339 @@
+package gcloudcx
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gildas/go-errors"
+	"github.com/gildas/go-logger"
+	"github.com/google/uuid"
+)
+
+// ConversationDetailsQuery describes a query for conversation details
+//
+// See: https://developer.genesys.cloud/api/rest/v2/analytics/#post-api-v2-analytics-conversations-details-query
+type ConversationDetailsQuery struct {
+	Interval            AnalyticsInterval  `json:"interval"`
+	ConversationFilters []*AnalyticsFilter `json:"conversationFilters,omitempty"`
+	SegmentFilters      []*AnalyticsFilter `json:"segmentFilters,omitempty"`
+	Order               string             `json:"order,omitempty"`   // asc, desc
+	OrderBy             string             `json:"orderBy,omitempty"` // conversationStart, conversationEnd, segmentStart, segmentEnd, ...
+	Paging              *AnalyticsPaging   `json:"paging,omitempty"`
+}
+
+// AnalyticsPaging describes the paging of an Analytics query
+type AnalyticsPaging struct {
+	PageSize   int `json:"pageSize"`
+	PageNumber int `json:"pageNumber"`
+}
+
+// ConversationDetailsJob describes an asynchronous conversation details query
+//
+// See: https://developer.genesys.cloud/api/rest/v2/analytics/#post-api-v2-analytics-conversations-details-jobs
+type ConversationDetailsJob struct {
+	ID             string         `json:"jobId"`
+	State          string         `json:"state"` // QUEUED, PENDING, FULFILLED, FAILED, CANCELLED, EXPIRED
+	ErrorMessage   string         `json:"errorMessage,omitempty"`
+	ExpirationDate time.Time      `json:"expirationDate,omitempty"`
+	SubmissionDate time.Time      `json:"submissionDate,omitempty"`
+	Client         *Client        `json:"-"`
+	Logger         *logger.Logger `json:"-"`
+}
+
+// analyticsConversation describes a conversation as returned by the Analytics API
+type analyticsConversation struct {
+	ID           uuid.UUID   `json:"conversationId"`
+	Start        time.Time   `json:"conversationStart"`
+	End          time.Time   `json:"conversationEnd"`
+	Divisions    []uuid.UUID `json:"divisionIds"`
+	Participants []struct {
+		ID         uuid.UUID         `json:"participantId"`
+		Name       string            `json:"participantName"`
+		Purpose    string            `json:"purpose"`
+		UserID     uuid.UUID         `json:"userId"`
+		Attributes map[string]string `json:"attributes"`
+		Sessions   []struct {
+			ID        uuid.UUID `json:"sessionId"`
+			MediaType string    `json:"mediaType"`
+			Direction string    `json:"direction"`
+			ANI       string    `json:"ani"`
+			DNIS      string    `json:"dnis"`
+			Segments  []struct {
+				Type           string    `json:"segmentType"`
+				Start          time.Time `json:"segmentStart"`
+				End            time.Time `json:"segmentEnd"`
+				DisconnectType string    `json:"disconnectType"`
+				QueueID        string    `json:"queueId"`
+				WrapupCode     string    `json:"wrapUpCode"`
+				WrapupNote     string    `json:"wrapUpNote"`
+			} `json:"segments"`
+		} `json:"sessions"`
+	} `json:"participants"`
+}
+
+// NewConversationDetailsQuery creates a new query for conversation details in the given interval
+func NewConversationDetailsQuery(interval AnalyticsInterval) *ConversationDetailsQuery {
+	return &ConversationDetailsQuery{Interval: interval}
+}
+
+// WithConversationFilters adds filters on the conversations
+func (query *ConversationDetailsQuery) WithConversationFilters(filters ...*AnalyticsFilter) *ConversationDetailsQuery {
+	query.ConversationFilters = append(query.ConversationFilters, filters...)
+	return query
+}
+
+// WithSegmentFilters adds filters on the segments of the conversations
+func (query *ConversationDetailsQuery) WithSegmentFilters(filters ...*AnalyticsFilter) *ConversationDetailsQuery {
+	query.SegmentFilters = append(query.SegmentFilters, filters...)
+	return query
+}
+
+// WithPaging sets the paging of the query
+func (query *ConversationDetailsQuery) WithPaging(pageSize, pageNumber int) *ConversationDetailsQuery {
+	query.Paging = &AnalyticsPaging{PageSize: pageSize, PageNumber: pageNumber}
+	return query
+}
+
+// WithOrder sets the order of the results
+//
+// order is either "asc" or "desc"
+func (query *ConversationDetailsQuery) WithOrder(orderBy, order string) *ConversationDetailsQuery {
+	query.OrderBy = orderBy
+	query.Order = order
+	return query
+}
+
+// QueryConversationDetails queries conversation details
+//
+// Returns the conversations of the requested page and the total number of conversations matching the query
+func (client *Client) QueryConversationDetails(query *ConversationDetailsQuery) ([]*Conversation, int, error) {
+	if query == nil {
+		return nil, 0, errors.ArgumentMissing.With("query").WithStack()
+	}
+	if query.Interval.IsZero() {
+		return nil, 0, errors.ArgumentMissing.With("interval").WithStack()
+	}
+	response := struct {
+		Conversations []*analyticsConversation `json:"conversations"`
+		TotalHits     int                      `json:"totalHits"`
+	}{}
+	if err := client.Post("/analytics/conversations/details/query", query, &response); err != nil {
+		return nil, 0, err
+	}
+	return client.conversationsFromAnalytics(response.Conversations), response.TotalHits, nil
+}
+
+// SubmitConversationDetailsJob submits an asynchronous conversation details query
+//
+// Paging is ignored by asynchronous queries, results are fetched with cursors
+func (client *Client) SubmitConversationDetailsJob(query *ConversationDetailsQuery) (*ConversationDetailsJob, error) {
+	if query == nil {
+		return nil, errors.ArgumentMissing.With("query").WithStack()
+	}
+	if query.Interval.IsZero() {
+		return nil, errors.ArgumentMissing.With("interval").WithStack()
+	}
+	jobQuery := *query
+	jobQuery.Paging = nil
+	job := &ConversationDetailsJob{}
+	if err := client.Post("/analytics/conversations/details/jobs", jobQuery, &job); err != nil {
+		return nil, err
+	}
+	job.Client = client
+	job.Logger = client.Logger.Child("analytics_job", "analytics_job", "job", job.ID)
+	return job, nil
+}
+
+// GetID gets the identifier of this
+func (job ConversationDetailsJob) GetID() string {
+	return job.ID
+}
+
+// String gets a string version
+//   implements the fmt.Stringer interface
+func (job ConversationDetailsJob) String() string {
+	return job.ID
+}
+
+// IsDone tells if the job is not running anymore (fulfilled or not)
+func (job ConversationDetailsJob) IsDone() bool {
+	switch job.State {
+	case "FULFILLED", "FAILED", "CANCELLED", "EXPIRED":
+		return true
+	default:
+		return false
+	}
+}
+
+// Refresh fetches the current state of this job
+func (job *ConversationDetailsJob) Refresh() error {
+	response := ConversationDetailsJob{}
+	if err := job.Client.Get(NewURI("/analytics/conversations/details/jobs/%s", job.ID), &response); err != nil {
+		return err
+	}
+	job.State = response.State
+	job.ErrorMessage = response.ErrorMessage
+	job.ExpirationDate = response.ExpirationDate
+	return nil
+}
+
+// Wait polls this job until it is done or the context is done
+//
+// If the job did not complete successfully, an error is returned
+func (job *ConversationDetailsJob) Wait(ctx context.Context, pollInterval time.Duration) error {
+	if pollInterval <= 0 {
+		pollInterval = 5 * time.Second
+	}
+	log := job.Logger.Scope("wait")
+	for {
+		if err := job.Refresh(); err != nil {
+			return err
+		}
+		log.Debugf("Job state: %s", job.State)
+		if job.IsDone() {
+			if job.State != "FULFILLED" {
+				return errors.RuntimeError.Wrap(errors.Errorf("Job %s %s: %s", job.ID, strings.ToLower(job.State), job.ErrorMessage))
+			}
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return errors.WithStack(ctx.Err())
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// FetchResults fetches a page of results of this job
+//
+// Give an empty cursor to get the first page. The returned cursor is empty when there are no more results
+func (job *ConversationDetailsJob) FetchResults(cursor string, pageSize int) (conversations []*Conversation, next string, err error) {
+	query := url.Values{}
+	if len(cursor) > 0 {
+		query.Add("cursor", cursor)
+	}
+	if pageSize > 0 {
+		query.Add("pageSize", strconv.Itoa(pageSize))
+	}
+	response := struct {
+		Conversations []*analyticsConversation `json:"conversations"`
+		Cursor        string                   `json:"cursor"`
+	}{}
+	if err = job.Client.Get(NewURI("/analytics/conversations/details/jobs/%s/results?%s", job.ID, query.Encode()), &response); err != nil {
+		return nil, "", err
+	}
+	return job.Client.conversationsFromAnalytics(response.Conversations), response.Cursor, nil
+}
+
+// FetchAllResults fetches all the results of this job, following the cursors
+func (job *ConversationDetailsJob) FetchAllResults() ([]*Conversation, error) {
+	conversations := []*Conversation{}
+	cursor := ""
+	for {
+		page, next, err := job.FetchResults(cursor, 0)
+		if err != nil {
+			return nil, err
+		}
+		conversations = append(conversations, page...)
+		if len(next) == 0 {
+			return conversations, nil
+		}
+		cursor = next
+	}
+}
+
+// Delete deletes this job
+func (job *ConversationDetailsJob) Delete() error {
+	return job.Client.Delete(NewURI("/analytics/conversations/details/jobs/%s", job.ID), nil)
+}
+
+// conversationsFromAnalytics converts Analytics conversations into Conversation objects
+//
+// Sessions are converted into the Participant's media (Calls, Chats, etc) carrying their segments
+func (client *Client) conversationsFromAnalytics(items []*analyticsConversation) []*Conversation {
+	conversations := make([]*Conversation, 0, len(items))
+	for _, item := range items {
+		conversation := &Conversation{
+			ID:           item.ID,
+			StartTime:    item.Start,
+			EndTime:      item.End,
+			Participants: make([]*Participant, 0, len(item.Participants)),
+			Client:       client,
+			Logger:       client.Logger.Child("conversation", "conversation", "conversation", item.ID),
+		}
+		for _, divisionID := range item.Divisions {
+			conversation.Divisions = append(conversation.Divisions, struct {
+				Division DomainEntityRef   `json:"division"`
+				Entities []DomainEntityRef `json:"entities"`
+			}{Division: DomainEntityRef{ID: divisionID}})
+		}
+		if item.End.IsZero() {
+			conversation.State = "connected"
+		} else {
+			conversation.State = "disconnected"
+		}
+		for _, itemParticipant := range item.Participants {
+			participant := &Participant{
+				ID:         itemParticipant.ID,
+				Name:       itemParticipant.Name,
+				Purpose:    itemParticipant.Purpose,
+				Attributes: itemParticipant.Attributes,
+			}
+			if itemParticipant.UserID != uuid.Nil {
+				participant.User = &User{ID: itemParticipant.UserID}
+			}
+			for _, session := range itemParticipant.Sessions {
+				segments := make([]Segment, 0, len(session.Segments))
+				for _, itemSegment := range session.Segments {
+					segments = append(segments, Segment{
+						Type:           itemSegment.Type,
+						DisconnectType: itemSegment.DisconnectType,
+						StartTime:      itemSegment.Start,
+						EndTime:        itemSegment.End,
+					})
+					if len(itemSegment.QueueID) > 0 {
+						participant.QueueID = itemSegment.QueueID
+					}
+					if len(itemSegment.WrapupCode) > 0 {
+						participant.Wrapup = &Wrapup{Code: itemSegment.WrapupCode, Notes: itemSegment.WrapupNote}
+					}
+				}
+				if len(participant.Direction) == 0 {
+					participant.Direction = session.Direction
+				}
+				if len(participant.ANI) == 0 {
+					participant.ANI = session.ANI
+				}
+				if len(participant.DNIS) == 0 {
+					participant.DNIS = session.DNIS
+				}
+				if len(segments) > 0 {
+					if participant.StartTime.IsZero() || segments[0].StartTime.Before(participant.StartTime) {
+						participant.StartTime = segments[0].StartTime
+					}
+					if last := segments[len(segments)-1].EndTime; last.After(participant.EndTime) {
+						participant.EndTime = last
+					}
+				}
+				switch session.MediaType {
+				case "voice":
+					participant.Calls = append(participant.Calls, &ConversationCall{ID: session.ID, Direction: session.Direction, Segments: segments})
+				case "chat":
+					participant.Chats = append(participant.Chats, &ConversationChat{ID: session.ID, Direction: session.Direction, Segments: segments})
+				case "email":
+					participant.Emails = append(participant.Emails, &ConversationEmail{ID: session.ID, Direction: session.Direction, Segments: segments})
+				case "callback":
+					participant.Callbacks = append(participant.Callbacks, &ConversationCallback{ID: session.ID, Direction: session.Direction, Segments: segments})
+				case "message":
+					participant.Messages = append(participant.Messages, &ConversationMessage{ID: session.ID, Direction: session.Direction, Segments: segments})
+				}
+			}
+			conversation.Participants = append(conversation.Participants, participant)
+		}
+		conversations = append(conversations, conversation)
+	}
+	return conversations
+}
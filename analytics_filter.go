@@ -0,0 +1,65 @@
+package gcloudcx
+
+// AnalyticsFilter describes a filter of an Analytics query
+//
+// See: https://developer.genesys.cloud/api/rest/v2/analytics/conversation_detail_model
+type AnalyticsFilter struct {
+	Type       string                `json:"type"` // and, or
+	Clauses    []*AnalyticsFilter    `json:"clauses,omitempty"`
+	Predicates []*AnalyticsPredicate `json:"predicates,omitempty"`
+}
+
+// AnalyticsPredicate describes a predicate of an AnalyticsFilter
+type AnalyticsPredicate struct {
+	Type      string                 `json:"type,omitempty"` // dimension, property, metric
+	Dimension string                 `json:"dimension,omitempty"`
+	Property  string                 `json:"property,omitempty"`
+	Metric    string                 `json:"metric,omitempty"`
+	Operator  string                 `json:"operator,omitempty"` // matches, exists, notExists
+	Value     string                 `json:"value,omitempty"`
+	Range     *AnalyticsNumericRange `json:"range,omitempty"`
+}
+
+// AnalyticsNumericRange describes a numeric range used by metric predicates
+type AnalyticsNumericRange struct {
+	GT  *float64 `json:"gt,omitempty"`
+	GTE *float64 `json:"gte,omitempty"`
+	LT  *float64 `json:"lt,omitempty"`
+	LTE *float64 `json:"lte,omitempty"`
+}
+
+// AndFilter creates a filter where all the given predicates must match
+func AndFilter(predicates ...*AnalyticsPredicate) *AnalyticsFilter {
+	return &AnalyticsFilter{Type: "and", Predicates: predicates}
+}
+
+// OrFilter creates a filter where at least one of the given predicates must match
+func OrFilter(predicates ...*AnalyticsPredicate) *AnalyticsFilter {
+	return &AnalyticsFilter{Type: "or", Predicates: predicates}
+}
+
+// WithClauses adds sub filters to this filter
+func (filter *AnalyticsFilter) WithClauses(clauses ...*AnalyticsFilter) *AnalyticsFilter {
+	filter.Clauses = append(filter.Clauses, clauses...)
+	return filter
+}
+
+// DimensionMatches creates a predicate where the dimension must match the given value
+func DimensionMatches(dimension, value string) *AnalyticsPredicate {
+	return &AnalyticsPredicate{Type: "dimension", Dimension: dimension, Operator: "matches", Value: value}
+}
+
+// DimensionExists creates a predicate where the dimension must exist
+func DimensionExists(dimension string) *AnalyticsPredicate {
+	return &AnalyticsPredicate{Type: "dimension", Dimension: dimension, Operator: "exists"}
+}
+
+// DimensionNotExists creates a predicate where the dimension must not exist
+func DimensionNotExists(dimension string) *AnalyticsPredicate {
+	return &AnalyticsPredicate{Type: "dimension", Dimension: dimension, Operator: "notExists"}
+}
+
+// MetricInRange creates a predicate where the metric must be in the given range
+func MetricInRange(metric string, valueRange AnalyticsNumericRange) *AnalyticsPredicate {
+	return &AnalyticsPredicate{Type: "metric", Metric: metric, Range: &valueRange}
+}
@@ -0,0 +1,81 @@
+package gcloudcx
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gildas/go-errors"
+)
+
+// AnalyticsInterval describes the time interval of an Analytics query
+//
+// It is marshaled as an ISO-8601 interval: "2021-06-01T00:00:00.000Z/2021-06-08T00:00:00.000Z"
+type AnalyticsInterval struct {
+	Start time.Time
+	End   time.Time
+}
+
+// analyticsTimeFormat is the ISO-8601 format GCloud expects in Analytics queries
+const analyticsTimeFormat = "2006-01-02T15:04:05.000Z"
+
+// NewAnalyticsInterval creates a new AnalyticsInterval
+func NewAnalyticsInterval(start, end time.Time) AnalyticsInterval {
+	return AnalyticsInterval{Start: start, End: end}
+}
+
+// ParseAnalyticsInterval parses an ISO-8601 interval, e.g.: "2021-06-01T00:00:00Z/2021-06-08T00:00:00Z"
+func ParseAnalyticsInterval(value string) (interval AnalyticsInterval, err error) {
+	parts := strings.Split(value, "/")
+	if len(parts) != 2 {
+		return AnalyticsInterval{}, errors.ArgumentInvalid.With("interval", value).WithStack()
+	}
+	if interval.Start, err = time.Parse(time.RFC3339, parts[0]); err != nil {
+		return AnalyticsInterval{}, errors.ArgumentInvalid.With("interval", value).Wrap(err)
+	}
+	if interval.End, err = time.Parse(time.RFC3339, parts[1]); err != nil {
+		return AnalyticsInterval{}, errors.ArgumentInvalid.With("interval", value).Wrap(err)
+	}
+	return interval, nil
+}
+
+// LastAnalyticsInterval creates an AnalyticsInterval that ends now
+func LastAnalyticsInterval(duration time.Duration) AnalyticsInterval {
+	now := time.Now().UTC()
+	return AnalyticsInterval{Start: now.Add(-duration), End: now}
+}
+
+// IsZero tells if this interval is not set
+func (interval AnalyticsInterval) IsZero() bool {
+	return interval.Start.IsZero() && interval.End.IsZero()
+}
+
+// Duration gets the duration of this interval
+func (interval AnalyticsInterval) Duration() time.Duration {
+	return interval.End.Sub(interval.Start)
+}
+
+// String gets a string version
+//   implements the fmt.Stringer interface
+func (interval AnalyticsInterval) String() string {
+	return fmt.Sprintf("%s/%s", interval.Start.UTC().Format(analyticsTimeFormat), interval.End.UTC().Format(analyticsTimeFormat))
+}
+
+// MarshalJSON marshals this into JSON
+func (interval AnalyticsInterval) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(interval.String())
+	return data, errors.JSONMarshalError.Wrap(err)
+}
+
+// UnmarshalJSON unmarshals JSON into this
+func (interval *AnalyticsInterval) UnmarshalJSON(payload []byte) (err error) {
+	var value string
+	if err = json.Unmarshal(payload, &value); err != nil {
+		return errors.JSONUnmarshalError.Wrap(err)
+	}
+	if *interval, err = ParseAnalyticsInterval(value); err != nil {
+		return errors.JSONUnmarshalError.Wrap(err)
+	}
+	return
+}
@@ -0,0 +1,75 @@
+package gcloudcx
+
+import (
+	"github.com/gildas/go-errors"
+)
+
+// QueueObservationQuery describes a query for the current observations of queues
+//
+// See: https://developer.genesys.cloud/api/rest/v2/analytics/#post-api-v2-analytics-queues-observations-query
+type QueueObservationQuery struct {
+	Filter        *AnalyticsFilter `json:"filter"`
+	Metrics       []string         `json:"metrics"` // oWaiting, oInteracting, oOnQueueUsers, oActiveUsers, oMemberUsers, oUserPresences, oUserRoutingStatuses
+	DetailMetrics []string         `json:"detailMetrics,omitempty"`
+}
+
+// QueueObservationResult describes the observations of a group
+type QueueObservationResult struct {
+	Group map[string]string       `json:"group"`
+	Data  []*QueueObservationData `json:"data"`
+}
+
+// QueueObservationData describes an observation metric
+type QueueObservationData struct {
+	Metric    string         `json:"metric"`
+	Qualifier string         `json:"qualifier,omitempty"`
+	Stats     AggregateStats `json:"stats"`
+}
+
+// NewQueueObservationQuery creates a new query for the given queues and metrics
+func NewQueueObservationQuery(queues []Identifiable, metrics ...string) *QueueObservationQuery {
+	predicates := make([]*AnalyticsPredicate, len(queues))
+	for i, queue := range queues {
+		predicates[i] = DimensionMatches("queueId", queue.GetID().String())
+	}
+	return &QueueObservationQuery{Filter: OrFilter(predicates...), Metrics: metrics}
+}
+
+// WithMediaType restricts the observations to the given media type (voice, chat, email, callback, message)
+func (query *QueueObservationQuery) WithMediaType(mediaType string) *QueueObservationQuery {
+	query.Filter = AndFilter(DimensionMatches("mediaType", mediaType)).WithClauses(query.Filter)
+	return query
+}
+
+// QueryQueueObservations queries the current observations of queues
+func (client *Client) QueryQueueObservations(query *QueueObservationQuery) ([]*QueueObservationResult, error) {
+	if query == nil {
+		return nil, errors.ArgumentMissing.With("query").WithStack()
+	}
+	if query.Filter == nil {
+		return nil, errors.ArgumentMissing.With("filter").WithStack()
+	}
+	if len(query.Metrics) == 0 {
+		return nil, errors.ArgumentMissing.With("metrics").WithStack()
+	}
+	response := struct {
+		Results []*QueueObservationResult `json:"results"`
+	}{}
+	if err := client.Post("/analytics/queues/observations/query", query, &response); err != nil {
+		return nil, err
+	}
+	return response.Results, nil
+}
+
+// Count gets the count of the given metric and qualifier
+//
+// If qualifier is empty, the counts of all qualifiers of the metric are added
+func (result QueueObservationResult) Count(metric, qualifier string) int64 {
+	var count int64
+	for _, data := range result.Data {
+		if data.Metric == metric && (len(qualifier) == 0 || data.Qualifier == qualifier) {
+			count += data.Stats.Count
+		}
+	}
+	return count
+}
@@ -0,0 +1,214 @@
+package gcloudcx_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gildas/go-gcloudcx"
+	"github.com/gildas/go-logger"
+	"github.com/stretchr/testify/suite"
+)
+
+type AnalyticsSuite struct {
+	suite.Suite
+	Name   string
+	Logger *logger.Logger
+	Start  time.Time
+}
+
+func TestAnalyticsSuite(t *testing.T) {
+	suite.Run(t, new(AnalyticsSuite))
+}
+
+func (suite *AnalyticsSuite) TestCanMarshalInterval() {
+	interval := gcloudcx.NewAnalyticsInterval(
+		time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2021, 6, 8, 0, 0, 0, 0, time.UTC),
+	)
+	data, err := json.Marshal(interval)
+	suite.Require().Nilf(err, "Failed to marshal AnalyticsInterval. %s", err)
+	suite.Assert().Equal(`"2021-06-01T00:00:00.000Z/2021-06-08T00:00:00.000Z"`, string(data))
+
+	unmarshaled := gcloudcx.AnalyticsInterval{}
+	err = json.Unmarshal(data, &unmarshaled)
+	suite.Require().Nilf(err, "Failed to unmarshal AnalyticsInterval. %s", err)
+	suite.Assert().True(interval.Start.Equal(unmarshaled.Start))
+	suite.Assert().True(interval.End.Equal(unmarshaled.End))
+}
+
+func (suite *AnalyticsSuite) TestCanMarshalConversationDetailsQuery() {
+	query := gcloudcx.NewConversationDetailsQuery(gcloudcx.NewAnalyticsInterval(
+		time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2021, 6, 8, 0, 0, 0, 0, time.UTC),
+	)).
+		WithConversationFilters(gcloudcx.AndFilter(gcloudcx.DimensionNotExists("conversationEnd"))).
+		WithSegmentFilters(gcloudcx.OrFilter(
+			gcloudcx.DimensionMatches("mediaType", "voice"),
+			gcloudcx.DimensionMatches("mediaType", "callback"),
+		)).
+		WithPaging(25, 2).
+		WithOrder("conversationStart", "desc")
+	data, err := json.Marshal(query)
+	suite.Require().Nilf(err, "Failed to marshal ConversationDetailsQuery. %s", err)
+	expected := `{
+		"interval": "2021-06-01T00:00:00.000Z/2021-06-08T00:00:00.000Z",
+		"conversationFilters": [{"type": "and", "predicates": [{"type": "dimension", "dimension": "conversationEnd", "operator": "notExists"}]}],
+		"segmentFilters": [{"type": "or", "predicates": [
+			{"type": "dimension", "dimension": "mediaType", "operator": "matches", "value": "voice"},
+			{"type": "dimension", "dimension": "mediaType", "operator": "matches", "value": "callback"}
+		]}],
+		"order": "desc",
+		"orderBy": "conversationStart",
+		"paging": {"pageSize": 25, "pageNumber": 2}
+	}`
+	suite.Assert().JSONEq(expected, string(data))
+}
+
+func (suite *AnalyticsSuite) TestCanQueryConversationDetails() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		suite.Assert().Equal(http.MethodPost, r.Method)
+		suite.Assert().Equal("/api/v2/analytics/conversations/details/query", r.URL.Path)
+		body, _ := ioutil.ReadAll(r.Body)
+		suite.Assert().Contains(string(body), `"interval":"2021-06-01T00:00:00.000Z/2021-06-02T00:00:00.000Z"`)
+		payload, _ := LoadFile("analytics-conversation-details.json")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(payload)
+	}))
+	defer server.Close()
+	client := CreateTestClient(server.URL, suite.Logger)
+
+	conversations, total, err := client.QueryConversationDetails(gcloudcx.NewConversationDetailsQuery(gcloudcx.NewAnalyticsInterval(
+		time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2021, 6, 2, 0, 0, 0, 0, time.UTC),
+	)))
+	suite.Require().Nilf(err, "Failed to query conversation details. %s", err)
+	suite.Assert().Equal(1, total)
+	suite.Require().Len(conversations, 1)
+	conversation := conversations[0]
+	suite.Assert().Equal("5a6e6f5c-57ec-4d4b-9d0f-6dfa3a1a4c11", conversation.ID.String())
+	suite.Assert().Equal("disconnected", conversation.State)
+	suite.Require().Len(conversation.Divisions, 1)
+	suite.Require().Len(conversation.Participants, 2)
+
+	customer := conversation.Participants[0]
+	suite.Assert().Equal("customer", customer.Purpose)
+	suite.Assert().Equal("tel:+81312345678", customer.ANI)
+	suite.Require().Len(customer.Calls, 1)
+	suite.Require().Len(customer.Calls[0].Segments, 1)
+	suite.Assert().Equal("interact", customer.Calls[0].Segments[0].Type)
+	suite.Assert().Equal("peer", customer.Calls[0].Segments[0].DisconnectType)
+
+	agent := conversation.Participants[1]
+	suite.Require().NotNil(agent.User)
+	suite.Assert().Equal("3e23b1b3-325f-4fbd-8fe0-e88416850c0e", agent.User.ID.String())
+	suite.Assert().Equal("9d0ba4c5-6e3e-4d2a-8a27-8d6a40f15e5e", agent.QueueID)
+	suite.Require().NotNil(agent.Wrapup)
+	suite.Assert().Equal("7fd9f0a4-8b0b-4a4c-9a55-0a8f6c8e6b05", agent.Wrapup.Code)
+	suite.Assert().Equal(time.Date(2021, 6, 1, 9, 1, 0, 0, time.UTC), agent.StartTime)
+	suite.Assert().Equal(time.Date(2021, 6, 1, 9, 10, 30, 0, time.UTC), agent.EndTime)
+}
+
+func (suite *AnalyticsSuite) TestShouldNotQueryConversationDetailsWithoutInterval() {
+	client := gcloudcx.NewClient(&gcloudcx.ClientOptions{Logger: suite.Logger})
+	_, _, err := client.QueryConversationDetails(&gcloudcx.ConversationDetailsQuery{})
+	suite.Require().NotNil(err, "Should not query without an interval")
+}
+
+func (suite *AnalyticsSuite) TestCanUnmarshalAggregates() {
+	response := struct {
+		Results []*gcloudcx.AggregateResult `json:"results"`
+	}{}
+	err := LoadObject("analytics-conversation-aggregates.json", &response)
+	suite.Require().Nilf(err, "Failed to unmarshal aggregates. %s", err)
+	suite.Require().Len(response.Results, 1)
+	result := response.Results[0]
+	suite.Assert().Equal("voice", result.Group["mediaType"])
+	suite.Require().Len(result.Data, 1)
+	data := result.Data[0]
+	suite.Assert().Equal(time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC), data.Interval.Start)
+	suite.Assert().Equal(5*time.Minute, data.AverageHandleTime())
+	suite.Assert().Equal(15*time.Second, data.AverageDuration("tAnswered"))
+	suite.Assert().InDelta(0.1, data.AbandonRate(), 0.0001)
+}
+
+func (suite *AnalyticsSuite) TestCanEvaluateServiceLevel() {
+	response := struct {
+		Results []*gcloudcx.AggregateResult `json:"results"`
+	}{}
+	err := LoadObject("analytics-conversation-aggregates.json", &response)
+	suite.Require().Nilf(err, "Failed to unmarshal aggregates. %s", err)
+	data := response.Results[0].Data[0]
+
+	serviceLevel := gcloudcx.ServiceLevel{Percentage: 0.8, Duration: 20 * time.Second}
+	result, err := serviceLevel.Evaluate(data)
+	suite.Require().Nilf(err, "Failed to evaluate the service level. %s", err)
+	suite.Assert().InDelta(0.85, result.Achieved, 0.0001)
+	suite.Assert().Equal(int64(36), result.Answered)
+	suite.Assert().True(result.Met)
+	suite.Assert().False(gcloudcx.ServiceLevel{Percentage: 0.9}.IsMet(data))
+}
+
+func (suite *AnalyticsSuite) TestCanEvaluateServiceLevelWithoutServiceLevelMetric() {
+	data := &gcloudcx.AggregateData{Metrics: []*gcloudcx.AggregateMetric{
+		{Metric: "tAnswered", Stats: gcloudcx.AggregateStats{Count: 20}},
+		{Metric: "nOverSla", Stats: gcloudcx.AggregateStats{Count: 5}},
+	}}
+	result, err := gcloudcx.ServiceLevel{Percentage: 0.8}.Evaluate(data)
+	suite.Require().Nilf(err, "Failed to evaluate the service level. %s", err)
+	suite.Assert().InDelta(0.75, result.Achieved, 0.0001)
+	suite.Assert().False(result.Met)
+}
+
+func (suite *AnalyticsSuite) TestCanGetStatusDurations() {
+	data := &gcloudcx.AggregateData{Metrics: []*gcloudcx.AggregateMetric{
+		{Metric: "tAgentRoutingStatus", Qualifier: "IDLE", Stats: gcloudcx.AggregateStats{Sum: 60000}},
+		{Metric: "tAgentRoutingStatus", Qualifier: "INTERACTING", Stats: gcloudcx.AggregateStats{Sum: 120000}},
+		{Metric: "tSystemPresence", Qualifier: "AVAILABLE", Stats: gcloudcx.AggregateStats{Sum: 180000}},
+	}}
+	durations := data.StatusDurations("tAgentRoutingStatus")
+	suite.Assert().Len(durations, 2)
+	suite.Assert().Equal(1*time.Minute, durations["IDLE"])
+	suite.Assert().Equal(2*time.Minute, durations["INTERACTING"])
+}
+
+// Suite Tools
+
+func (suite *AnalyticsSuite) SetupSuite() {
+	suite.Name = strings.TrimSuffix(reflect.TypeOf(*suite).Name(), "Suite")
+	suite.Logger = logger.Create("test",
+		&logger.FileStream{
+			Path:        fmt.Sprintf("./log/test-%s.log", strings.ToLower(suite.Name)),
+			Unbuffered:  true,
+			FilterLevel: logger.TRACE,
+		},
+	).Child("test", "test")
+	suite.Logger.Infof("Suite Start: %s %s", suite.Name, strings.Repeat("=", 80-14-len(suite.Name)))
+}
+
+func (suite *AnalyticsSuite) TearDownSuite() {
+	if suite.T().Failed() {
+		suite.Logger.Warnf("At least one test failed, we are not cleaning")
+		suite.T().Log("At least one test failed, we are not cleaning")
+	} else {
+		suite.Logger.Infof("All tests succeeded, we are cleaning")
+	}
+	suite.Logger.Infof("Suite End: %s %s", suite.Name, strings.Repeat("=", 80-12-len(suite.Name)))
+	suite.Logger.Close()
+}
+
+func (suite *AnalyticsSuite) BeforeTest(suiteName, testName string) {
+	suite.Logger.Infof("Test Start: %s %s", testName, strings.Repeat("-", 80-13-len(testName)))
+	suite.Start = time.Now()
+}
+
+func (suite *AnalyticsSuite) AfterTest(suiteName, testName string) {
+	duration := time.Since(suite.Start)
+	suite.Logger.Record("duration", duration.String()).Infof("Test End: %s %s", testName, strings.Repeat("-", 80-11-len(testName)))
+}
@@ -0,0 +1,164 @@
+package gcloudcx
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gildas/go-errors"
+	"github.com/google/uuid"
+)
+
+// ChatMemberRoster is a concurrency-safe list of the members of a chat
+//
+// It is kept up to date with the ConversationGuestChatMemberTopic events of the chat
+type ChatMemberRoster struct {
+	members map[uuid.UUID]*ChatMember
+	changed chan struct{}
+	mutex   sync.RWMutex
+}
+
+// NewChatMemberRoster creates a new ChatMemberRoster with the given members
+func NewChatMemberRoster(members ...*ChatMember) *ChatMemberRoster {
+	roster := &ChatMemberRoster{
+		members: map[uuid.UUID]*ChatMember{},
+		changed: make(chan struct{}),
+	}
+	for _, member := range members {
+		roster.Set(member)
+	}
+	return roster
+}
+
+// Get gets a copy of the member with the given identifier
+func (roster *ChatMemberRoster) Get(identifiable Identifiable) (*ChatMember, bool) {
+	roster.mutex.RLock()
+	defer roster.mutex.RUnlock()
+	member, found := roster.members[identifiable.GetID()]
+	if !found {
+		return nil, false
+	}
+	copied := *member
+	return &copied, true
+}
+
+// Set adds or replaces a member
+func (roster *ChatMemberRoster) Set(member *ChatMember) {
+	if member == nil || member.ID == uuid.Nil {
+		return
+	}
+	roster.mutex.Lock()
+	defer roster.mutex.Unlock()
+	copied := *member
+	roster.members[member.ID] = &copied
+	roster.notify()
+}
+
+// Remove removes a member
+func (roster *ChatMemberRoster) Remove(identifiable Identifiable) {
+	roster.mutex.Lock()
+	defer roster.mutex.Unlock()
+	delete(roster.members, identifiable.GetID())
+	roster.notify()
+}
+
+// Update merges the member of a member topic in this roster and returns the merged member
+//
+// Only the fields that are set in the topic's member are changed
+func (roster *ChatMemberRoster) Update(topic *ConversationGuestChatMemberTopic) (*ChatMember, error) {
+	if topic == nil || topic.Member == nil || topic.Member.ID == uuid.Nil {
+		return nil, errors.ArgumentMissing.With("member").WithStack()
+	}
+	roster.mutex.Lock()
+	defer roster.mutex.Unlock()
+	member, found := roster.members[topic.Member.ID]
+	if !found {
+		member = &ChatMember{ID: topic.Member.ID}
+		roster.members[member.ID] = member
+	}
+	update := topic.Member
+	if len(update.DisplayName) > 0 {
+		member.DisplayName = update.DisplayName
+	}
+	if update.AvatarURL != nil {
+		member.AvatarURL = update.AvatarURL
+	}
+	if len(update.Role) > 0 {
+		member.Role = update.Role
+	}
+	if len(update.State) > 0 {
+		member.State = update.State
+	}
+	if !update.JoinedAt.IsZero() {
+		member.JoinedAt = update.JoinedAt
+	} else if member.JoinedAt.IsZero() && member.State == "CONNECTED" {
+		member.JoinedAt = topic.TimeStamp
+	}
+	if !update.LeftAt.IsZero() {
+		member.LeftAt = update.LeftAt
+	} else if member.LeftAt.IsZero() && member.State == "DISCONNECTED" {
+		member.LeftAt = topic.TimeStamp
+	}
+	if update.Custom != nil {
+		member.Custom = update.Custom
+	}
+	roster.notify()
+	copied := *member
+	return &copied, nil
+}
+
+// Members gets a copy of all the members
+func (roster *ChatMemberRoster) Members() []*ChatMember {
+	roster.mutex.RLock()
+	defer roster.mutex.RUnlock()
+	members := make([]*ChatMember, 0, len(roster.members))
+	for _, member := range roster.members {
+		copied := *member
+		members = append(members, &copied)
+	}
+	return members
+}
+
+// Agents gets a copy of the agents that are currently connected
+func (roster *ChatMemberRoster) Agents() []*ChatMember {
+	roster.mutex.RLock()
+	defer roster.mutex.RUnlock()
+	return roster.agents()
+}
+
+// WaitForAgent waits until an agent is connected or the context is done
+//
+// If an agent is already connected, it is returned immediately
+func (roster *ChatMemberRoster) WaitForAgent(ctx context.Context) (*ChatMember, error) {
+	for {
+		roster.mutex.RLock()
+		agents := roster.agents()
+		changed := roster.changed
+		roster.mutex.RUnlock()
+		if len(agents) > 0 {
+			return agents[0], nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, errors.WithStack(ctx.Err())
+		case <-changed:
+		}
+	}
+}
+
+// agents gets the connected agents, the caller must hold the mutex
+func (roster *ChatMemberRoster) agents() []*ChatMember {
+	agents := []*ChatMember{}
+	for _, member := range roster.members {
+		if member.Role == "AGENT" && member.State == "CONNECTED" {
+			copied := *member
+			agents = append(agents, &copied)
+		}
+	}
+	return agents
+}
+
+// notify wakes up the waiters, the caller must hold the mutex
+func (roster *ChatMemberRoster) notify() {
+	close(roster.changed)
+	roster.changed = make(chan struct{})
+}
@@ -0,0 +1,136 @@
+package gcloudcx_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gildas/go-errors"
+	"github.com/gildas/go-gcloudcx"
+	"github.com/gildas/go-logger"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+type ChatMemberRosterSuite struct {
+	suite.Suite
+	Name   string
+	Logger *logger.Logger
+	Start  time.Time
+}
+
+func TestChatMemberRosterSuite(t *testing.T) {
+	suite.Run(t, new(ChatMemberRosterSuite))
+}
+
+func (suite *ChatMemberRosterSuite) TestCanUnmarshalMemberTopic() {
+	topic := gcloudcx.ConversationGuestChatMemberTopic{}
+	err := json.Unmarshal([]byte(`{
+		"topicName": "v2.conversations.chats.2c4c4f6b-3e8f-4d4e-9c8a-0f6a4c3e6b71.members",
+		"eventBody": {
+			"member": {"id": "5d9a0d5c-6f42-4a0d-8c34-2d3a8b5a7d10", "role": "AGENT", "state": "CONNECTED"},
+			"timestamp": "2021-06-30T09:00:00.000Z"
+		},
+		"metadata": {"type": "member-change"}
+	}`), &topic)
+	suite.Require().Nilf(err, "Failed to unmarshal topic. %s", err)
+	suite.Assert().Equal("2c4c4f6b-3e8f-4d4e-9c8a-0f6a4c3e6b71", topic.Conversation.ID.String())
+	suite.Require().NotNil(topic.Member)
+	suite.Assert().Equal("AGENT", topic.Member.Role)
+}
+
+func (suite *ChatMemberRosterSuite) TestCanUpdateMembers() {
+	guest := &gcloudcx.ChatMember{ID: uuid.New(), DisplayName: "Bob", Role: "CUSTOMER", State: "CONNECTED"}
+	roster := gcloudcx.NewChatMemberRoster(guest)
+	agentID := uuid.New()
+
+	member, err := roster.Update(suite.memberTopic(agentID, "AGENT", "ALERTING"))
+	suite.Require().Nilf(err, "Failed to update roster. %s", err)
+	suite.Assert().Equal("ALERTING", member.State)
+	suite.Assert().Empty(roster.Agents(), "Alerting agents are not connected")
+
+	_, _ = roster.Update(suite.memberTopic(agentID, "", "CONNECTED"))
+	agents := roster.Agents()
+	suite.Require().Len(agents, 1)
+	suite.Assert().Equal(agentID, agents[0].ID)
+	suite.Assert().Equal("AGENT", agents[0].Role, "The role should be kept")
+	suite.Assert().False(agents[0].JoinedAt.IsZero())
+
+	_, _ = roster.Update(suite.memberTopic(agentID, "", "DISCONNECTED"))
+	suite.Assert().Empty(roster.Agents())
+	member, found := roster.Get(gcloudcx.ChatMember{ID: agentID})
+	suite.Require().True(found)
+	suite.Assert().False(member.LeftAt.IsZero())
+	suite.Assert().Len(roster.Members(), 2)
+}
+
+func (suite *ChatMemberRosterSuite) TestCanWaitForAgent() {
+	roster := gcloudcx.NewChatMemberRoster()
+	agentID := uuid.New()
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_, _ = roster.Update(suite.memberTopic(uuid.New(), "CUSTOMER", "CONNECTED"))
+		_, _ = roster.Update(suite.memberTopic(agentID, "AGENT", "CONNECTED"))
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	agent, err := roster.WaitForAgent(ctx)
+	suite.Require().Nilf(err, "Failed to wait for agent. %s", err)
+	suite.Assert().Equal(agentID, agent.ID)
+}
+
+func (suite *ChatMemberRosterSuite) TestShouldTimeoutWaitingForAgent() {
+	roster := gcloudcx.NewChatMemberRoster()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := roster.WaitForAgent(ctx)
+	suite.Require().NotNil(err, "Should have timed out")
+	suite.Assert().True(errors.Is(err, context.DeadlineExceeded), "Error should be a DeadlineExceeded")
+}
+
+func (suite *ChatMemberRosterSuite) memberTopic(id uuid.UUID, role, state string) *gcloudcx.ConversationGuestChatMemberTopic {
+	return &gcloudcx.ConversationGuestChatMemberTopic{
+		Type:      "member-change",
+		Member:    &gcloudcx.ChatMember{ID: id, Role: role, State: state},
+		TimeStamp: time.Now().UTC(),
+	}
+}
+
+// Suite Tools
+
+func (suite *ChatMemberRosterSuite) SetupSuite() {
+	suite.Name = strings.TrimSuffix(reflect.TypeOf(*suite).Name(), "Suite")
+	suite.Logger = logger.Create("test",
+		&logger.FileStream{
+			Path:        fmt.Sprintf("./log/test-%s.log", strings.ToLower(suite.Name)),
+			Unbuffered:  true,
+			FilterLevel: logger.TRACE,
+		},
+	).Child("test", "test")
+	suite.Logger.Infof("Suite Start: %s %s", suite.Name, strings.Repeat("=", 80-14-len(suite.Name)))
+}
+
+func (suite *ChatMemberRosterSuite) TearDownSuite() {
+	if suite.T().Failed() {
+		suite.Logger.Warnf("At least one test failed, we are not cleaning")
+		suite.T().Log("At least one test failed, we are not cleaning")
+	} else {
+		suite.Logger.Infof("All tests succeeded, we are cleaning")
+	}
+	suite.Logger.Infof("Suite End: %s %s", suite.Name, strings.Repeat("=", 80-12-len(suite.Name)))
+	suite.Logger.Close()
+}
+
+func (suite *ChatMemberRosterSuite) BeforeTest(suiteName, testName string) {
+	suite.Logger.Infof("Test Start: %s %s", testName, strings.Repeat("-", 80-13-len(testName)))
+	suite.Start = time.Now()
+}
+
+func (suite *ChatMemberRosterSuite) AfterTest(suiteName, testName string) {
+	duration := time.Since(suite.Start)
+	suite.Logger.Record("duration", duration.String()).Infof("Test End: %s %s", testName, strings.Repeat("-", 80-11-len(testName)))
+}
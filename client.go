@@ -22,6 +22,7 @@ type Client struct {
 	Grant          Authorizer     `json:"-"`
 	RequestTimeout time.Duration  `json:"requestTimout"`
 	Logger         *logger.Logger `json:"-"`
+	wrapupCodes    wrapupCodeCache
 }
 
 // ClientOptions contains the options to create a new Client
@@ -1,9 +1,12 @@
 package gcloudcx_test
 
 import (
+	"bytes"
 	"encoding/json"
 	"io/ioutil"
+	"net/http"
 	"path/filepath"
+	"sync"
 )
 
 func LoadObject(filename string, object interface{}) (err error) {
@@ -23,3 +26,56 @@ func LoadFile(filename string) (payload []byte, err error) {
 	}
 	return payload, nil
 }
+
+// RequestRecorder records the requests received by a test server before handing them to its Handler
+//
+// The requests are recorded as "METHOD /path?query" and their bodies are kept in the same order.
+// The Handler can still read the request body.
+type RequestRecorder struct {
+	Handler  http.HandlerFunc
+	requests []string
+	bodies   []string
+	mutex    sync.Mutex
+}
+
+// NewRequestRecorder creates a new RequestRecorder that hands the requests to the given handler
+func NewRequestRecorder(handler http.HandlerFunc) *RequestRecorder {
+	return &RequestRecorder{Handler: handler}
+}
+
+// ServeHTTP records the request and serves it with the Handler
+func (recorder *RequestRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	request := r.Method + " " + r.URL.Path
+	if len(r.URL.RawQuery) > 0 {
+		request += "?" + r.URL.RawQuery
+	}
+	body, _ := ioutil.ReadAll(r.Body)
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	recorder.mutex.Lock()
+	recorder.requests = append(recorder.requests, request)
+	recorder.bodies = append(recorder.bodies, string(body))
+	recorder.mutex.Unlock()
+	recorder.Handler(w, r)
+}
+
+// Requests gets a copy of the recorded requests
+func (recorder *RequestRecorder) Requests() []string {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	return append([]string{}, recorder.requests...)
+}
+
+// Bodies gets a copy of the recorded request bodies
+func (recorder *RequestRecorder) Bodies() []string {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	return append([]string{}, recorder.bodies...)
+}
+
+// Reset forgets the recorded requests
+func (recorder *RequestRecorder) Reset() {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	recorder.requests = []string{}
+	recorder.bodies = []string{}
+}
@@ -1,11 +1,22 @@
 package gcloudcx
 
 import (
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/gildas/go-errors"
 	"github.com/google/uuid"
 )
 
+// Messenger types of agentless messages
+const (
+	MessengerTypeSMS      = "sms"
+	MessengerTypeWhatsApp = "whatsapp"
+	MessengerTypeFacebook = "facebook"
+	MessengerTypeOpen     = "open"
+)
+
 // AgentlessMessage sends an agentless outbound text message to a Messenger address
 //
 // See https://developer.genesys.cloud/api/rest/v2/conversations/#post-api-v2-conversations-messages-agentless
@@ -42,3 +53,211 @@ type AgentlessMessageResult struct {
 	Timestamp     time.Time             `json:"timestamp"`
 	SelfURI       string                `json:"selfUri"`
 }
+
+// AgentlessBulkResult describes the result of one message of a bulk send
+type AgentlessBulkResult struct {
+	Message *AgentlessMessage
+	Result  *AgentlessMessageResult
+	Error   error
+}
+
+// CannedResponse describes a Response of the Response Management (e.g.: a WhatsApp template)
+//
+// See https://developer.genesys.cloud/api/rest/v2/responsemanagement/
+type CannedResponse struct {
+	ID                uuid.UUID                    `json:"id"`
+	Name              string                       `json:"name"`
+	Type              string                       `json:"responseType,omitempty"` // MessagingTemplate, CampaignSmsTemplate, CampaignEmailTemplate, Footer
+	Texts             []CannedResponseText         `json:"texts,omitempty"`
+	Substitutions     []CannedResponseSubstitution `json:"substitutions,omitempty"`
+	MessagingTemplate *struct {
+		WhatsApp *struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+			Language  string `json:"language"`
+		} `json:"whatsApp,omitempty"`
+	} `json:"messagingTemplate,omitempty"`
+	SelfURI URI `json:"selfUri,omitempty"`
+}
+
+// CannedResponseText describes a text of a CannedResponse
+type CannedResponseText struct {
+	Content     string `json:"content"`
+	ContentType string `json:"contentType"` // text/plain, text/html
+}
+
+// CannedResponseSubstitution describes a substitution (parameter) of a CannedResponse
+type CannedResponseSubstitution struct {
+	ID           string `json:"id"`
+	Description  string `json:"description,omitempty"`
+	DefaultValue string `json:"defaultValue,omitempty"`
+}
+
+// SendAgentlessMessage sends an agentless outbound message
+//
+// See https://developer.genesys.cloud/api/rest/v2/conversations/#post-api-v2-conversations-messages-agentless
+func (client *Client) SendAgentlessMessage(message *AgentlessMessage) (*AgentlessMessageResult, error) {
+	if message == nil {
+		return nil, errors.ArgumentMissing.With("message").WithStack()
+	}
+	if len(message.From) == 0 {
+		return nil, errors.ArgumentMissing.With("fromAddress").WithStack()
+	}
+	if len(message.To) == 0 {
+		return nil, errors.ArgumentMissing.With("toAddress").WithStack()
+	}
+	messengerType := strings.ToLower(message.MessengerType)
+	switch messengerType {
+	case MessengerTypeSMS, MessengerTypeWhatsApp, MessengerTypeFacebook, MessengerTypeOpen:
+	default:
+		return nil, errors.ArgumentInvalid.With("toAddressMessengerType", message.MessengerType).WithStack()
+	}
+	if len(message.Text) == 0 && message.Template == nil {
+		return nil, errors.ArgumentMissing.With("textBody").WithStack()
+	}
+	payload := *message
+	payload.MessengerType = messengerType
+	result := &AgentlessMessageResult{}
+	if err := client.Post("/conversations/messages/agentless", payload, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SendAgentlessTemplate sends an agentless outbound message built from a template response
+//
+// The values are validated against the response substitutions (see NewMessagingTemplate)
+func (client *Client) SendAgentlessTemplate(from, to, messengerType string, response *CannedResponse, values map[string]string) (*AgentlessMessageResult, error) {
+	template, err := NewMessagingTemplate(response, values)
+	if err != nil {
+		return nil, err
+	}
+	return client.SendAgentlessMessage(&AgentlessMessage{
+		From:          from,
+		To:            to,
+		MessengerType: messengerType,
+		Template:      template,
+	})
+}
+
+// SendAgentlessMessages sends several agentless outbound messages
+//
+// The messages are sent one after the other, each message gets its own result or error
+func (client *Client) SendAgentlessMessages(messages []*AgentlessMessage) []AgentlessBulkResult {
+	results := make([]AgentlessBulkResult, len(messages))
+	for i, message := range messages {
+		results[i].Message = message
+		results[i].Result, results[i].Error = client.SendAgentlessMessage(message)
+	}
+	return results
+}
+
+// FetchCannedResponse fetches a Response of the Response Management
+func (client *Client) FetchCannedResponse(id uuid.UUID) (*CannedResponse, error) {
+	if id == uuid.Nil {
+		return nil, errors.ArgumentMissing.With("id").WithStack()
+	}
+	response := &CannedResponse{}
+	if err := client.Get(NewURI("/responsemanagement/responses/%s", id), &response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// NewMessagingTemplate creates a MessagingTemplate from a response and the values of its substitutions
+//
+// Substitutions without a value use their default value, if any.
+// An error is returned if a substitution has no value or if a value does not match any substitution.
+func NewMessagingTemplate(response *CannedResponse, values map[string]string) (*MessagingTemplate, error) {
+	if response == nil || response.ID == uuid.Nil {
+		return nil, errors.ArgumentMissing.With("response").WithStack()
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !response.hasSubstitution(key) {
+			return nil, errors.ArgumentInvalid.With("substitution", key).WithStack()
+		}
+	}
+	parameters := make([]TemplateParameter, 0, len(response.Substitutions))
+	for _, substitution := range response.Substitutions {
+		value, found := values[substitution.ID]
+		if !found {
+			if len(substitution.DefaultValue) == 0 {
+				return nil, errors.ArgumentMissing.With(substitution.ID).WithStack()
+			}
+			value = substitution.DefaultValue
+		}
+		parameters = append(parameters, TemplateParameter{ID: substitution.ID, Value: value})
+	}
+	if err := response.Validate(parameters); err != nil {
+		return nil, err
+	}
+	return &MessagingTemplate{ResponseID: response.ID.String(), Parameters: parameters}, nil
+}
+
+// Validate validates the given parameters against the substitutions of this response
+func (response CannedResponse) Validate(parameters []TemplateParameter) error {
+	given := map[string]bool{}
+	for _, parameter := range parameters {
+		if !response.hasSubstitution(parameter.ID) {
+			return errors.ArgumentInvalid.With("parameter", parameter.ID).WithStack()
+		}
+		given[parameter.ID] = true
+	}
+	for _, substitution := range response.Substitutions {
+		if !given[substitution.ID] && len(substitution.DefaultValue) == 0 {
+			return errors.ArgumentMissing.With(substitution.ID).WithStack()
+		}
+	}
+	return nil
+}
+
+// Render renders the plain text of this response with the given parameters
+//
+// Substitutions are written as {{id}} in the response texts
+func (response CannedResponse) Render(parameters []TemplateParameter) string {
+	for _, text := range response.Texts {
+		if text.ContentType == "text/plain" || len(response.Texts) == 1 {
+			content := text.Content
+			for _, substitution := range response.Substitutions {
+				value := substitution.DefaultValue
+				for _, parameter := range parameters {
+					if parameter.ID == substitution.ID {
+						value = parameter.Value
+					}
+				}
+				content = strings.ReplaceAll(content, "{{"+substitution.ID+"}}", value)
+			}
+			return content
+		}
+	}
+	return ""
+}
+
+// GetID gets the identifier of this
+//   implements Identifiable
+func (response CannedResponse) GetID() uuid.UUID {
+	return response.ID
+}
+
+// String gets a string version
+//   implements the fmt.Stringer interface
+func (response CannedResponse) String() string {
+	if len(response.Name) > 0 {
+		return response.Name
+	}
+	return response.ID.String()
+}
+
+func (response CannedResponse) hasSubstitution(id string) bool {
+	for _, substitution := range response.Substitutions {
+		if substitution.ID == id {
+			return true
+		}
+	}
+	return false
+}
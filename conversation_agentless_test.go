@@ -0,0 +1,132 @@
+package gcloudcx_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gildas/go-errors"
+	"github.com/gildas/go-gcloudcx"
+	"github.com/gildas/go-logger"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+type AgentlessSuite struct {
+	suite.Suite
+	Name   string
+	Logger *logger.Logger
+	Start  time.Time
+
+	Response *gcloudcx.CannedResponse
+}
+
+func TestAgentlessSuite(t *testing.T) {
+	suite.Run(t, new(AgentlessSuite))
+}
+
+func (suite *AgentlessSuite) TestCanCreateMessagingTemplate() {
+	template, err := gcloudcx.NewMessagingTemplate(suite.Response, map[string]string{"name": "Bob"})
+	suite.Require().Nilf(err, "Failed to create template. %s", err)
+	suite.Assert().Equal(suite.Response.ID.String(), template.ResponseID)
+	suite.Require().Len(template.Parameters, 2)
+	suite.Assert().Equal(gcloudcx.TemplateParameter{ID: "name", Value: "Bob"}, template.Parameters[0])
+	suite.Assert().Equal(gcloudcx.TemplateParameter{ID: "company", Value: "ACME"}, template.Parameters[1])
+	suite.Assert().Equal("Hello Bob, welcome to ACME!", suite.Response.Render(template.Parameters))
+}
+
+func (suite *AgentlessSuite) TestShouldNotCreateMessagingTemplateWithMissingParameter() {
+	_, err := gcloudcx.NewMessagingTemplate(suite.Response, map[string]string{"company": "Wayne"})
+	suite.Require().NotNil(err, "Template should not have been created")
+	suite.Assert().True(errors.Is(err, errors.ArgumentMissing), "Error should be an ArgumentMissing")
+}
+
+func (suite *AgentlessSuite) TestShouldNotCreateMessagingTemplateWithUnknownSubstitution() {
+	_, err := gcloudcx.NewMessagingTemplate(suite.Response, map[string]string{"name": "Bob", "nmae": "Bobby", "age": "42"})
+	suite.Require().NotNil(err, "Template should not have been created")
+	suite.Assert().True(errors.Is(err, errors.ArgumentInvalid), "Error should be an ArgumentInvalid")
+	suite.Assert().Contains(err.Error(), "age", "The first unknown substitution should be reported")
+}
+
+func (suite *AgentlessSuite) TestShouldNotValidateUnknownParameter() {
+	err := suite.Response.Validate([]gcloudcx.TemplateParameter{{ID: "name", Value: "Bob"}, {ID: "age", Value: "42"}})
+	suite.Require().NotNil(err, "Parameters should not be valid")
+	suite.Assert().True(errors.Is(err, errors.ArgumentInvalid), "Error should be an ArgumentInvalid")
+}
+
+func (suite *AgentlessSuite) TestCanSendBulkMessages() {
+	received := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		message := gcloudcx.AgentlessMessage{}
+		_ = json.NewDecoder(r.Body).Decode(&message)
+		received = append(received, message.MessengerType+":"+message.To)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(fmt.Sprintf(`{"id": "%s", "conversationId": "%s", "toAddress": "%s", "messengerType": "%s"}`, uuid.New(), uuid.New(), message.To, message.MessengerType)))
+	}))
+	defer server.Close()
+	client := CreateTestClient(server.URL, suite.Logger)
+
+	results := client.SendAgentlessMessages([]*gcloudcx.AgentlessMessage{
+		{From: "+13175550000", To: "+13175551111", MessengerType: "SMS", Text: "Hello"},
+		{From: "+13175550000", To: "+13175552222", MessengerType: "pigeon", Text: "Hello"},
+		{From: "+13175550000", To: "+13175553333", MessengerType: gcloudcx.MessengerTypeWhatsApp, Text: "Hello"},
+	})
+	suite.Require().Len(results, 3)
+	suite.Assert().Nil(results[0].Error)
+	suite.Require().NotNil(results[0].Result)
+	suite.Assert().Equal("+13175551111", results[0].Result.To)
+	suite.Require().NotNil(results[1].Error)
+	suite.Assert().True(errors.Is(results[1].Error, errors.ArgumentInvalid), "Error should be an ArgumentInvalid")
+	suite.Assert().Nil(results[2].Error)
+	suite.Assert().Equal([]string{"sms:+13175551111", "whatsapp:+13175553333"}, received)
+}
+
+// Suite Tools
+
+func (suite *AgentlessSuite) SetupSuite() {
+	suite.Name = strings.TrimSuffix(reflect.TypeOf(*suite).Name(), "Suite")
+	suite.Logger = logger.Create("test",
+		&logger.FileStream{
+			Path:        fmt.Sprintf("./log/test-%s.log", strings.ToLower(suite.Name)),
+			Unbuffered:  true,
+			FilterLevel: logger.TRACE,
+		},
+	).Child("test", "test")
+	suite.Logger.Infof("Suite Start: %s %s", suite.Name, strings.Repeat("=", 80-14-len(suite.Name)))
+	suite.Response = &gcloudcx.CannedResponse{
+		ID:    uuid.MustParse("5ae5a5d9-8a4e-4c5e-a3a5-c1b7b5f3e1d2"),
+		Name:  "Welcome",
+		Type:  "MessagingTemplate",
+		Texts: []gcloudcx.CannedResponseText{{Content: "Hello {{name}}, welcome to {{company}}!", ContentType: "text/plain"}},
+		Substitutions: []gcloudcx.CannedResponseSubstitution{
+			{ID: "name"},
+			{ID: "company", DefaultValue: "ACME"},
+		},
+	}
+}
+
+func (suite *AgentlessSuite) TearDownSuite() {
+	if suite.T().Failed() {
+		suite.Logger.Warnf("At least one test failed, we are not cleaning")
+		suite.T().Log("At least one test failed, we are not cleaning")
+	} else {
+		suite.Logger.Infof("All tests succeeded, we are cleaning")
+	}
+	suite.Logger.Infof("Suite End: %s %s", suite.Name, strings.Repeat("=", 80-12-len(suite.Name)))
+	suite.Logger.Close()
+}
+
+func (suite *AgentlessSuite) BeforeTest(suiteName, testName string) {
+	suite.Logger.Infof("Test Start: %s %s", testName, strings.Repeat("-", 80-13-len(testName)))
+	suite.Start = time.Now()
+}
+
+func (suite *AgentlessSuite) AfterTest(suiteName, testName string) {
+	duration := time.Since(suite.Start)
+	suite.Logger.Record("duration", duration.String()).Infof("Test End: %s %s", testName, strings.Repeat("-", 80-11-len(testName)))
+}
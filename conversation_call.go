@@ -3,6 +3,8 @@ package gcloudcx
 import (
 	"time"
 
+	"github.com/gildas/go-errors"
+	"github.com/gildas/go-logger"
 	"github.com/google/uuid"
 )
 
@@ -28,7 +30,7 @@ type ConversationCall struct {
 	Held              bool                `json:"held"`
 	Confined          bool                `json:"confined"`
 	Recording         bool                `json:"recording"`
-	RecordingState    string              `json:"recodingState"` // none,active,paused
+	RecordingState    string              `json:"recordingState"` // none,active,paused
 	RecordingID       string              `json:"recordingId"`
 	Segments          []Segment           `json:"segments"`
 	DocumentID        string              `json:"documentId"`
@@ -45,4 +47,361 @@ type ConversationCall struct {
 	DisconnectReasons []*DisconnectReason `json:"disconnectReasons"`
 	FaxStatus         FaxStatus           `json:"faxStatus"`
 	ErrorInfo         ErrorBody           `json:"errorInfo"`
+	Participants      []*Participant      `json:"participants,omitempty"`
+	Client            *Client             `json:"-"`
+	Logger            *logger.Logger      `json:"-"`
+}
+
+// CreateCallRequest describes the request to place an outbound call
+//
+// See: https://developer.genesys.cloud/api/rest/v2/conversations/#post-api-v2-conversations-calls
+type CreateCallRequest struct {
+	PhoneNumber      string   `json:"phoneNumber,omitempty"`
+	CallerID         string   `json:"callerId,omitempty"`
+	CallerIDName     string   `json:"callerIdName,omitempty"`
+	CallFromQueueID  string   `json:"callFromQueueId,omitempty"`
+	CallQueueID      string   `json:"callQueueId,omitempty"`
+	CallUserID       string   `json:"callUserId,omitempty"`
+	Priority         int      `json:"priority,omitempty"`
+	LanguageID       string   `json:"languageId,omitempty"`
+	RoutingSkillsIDs []string `json:"routingSkillsIds,omitempty"`
+	ConversationIDs  []string `json:"conversationIds,omitempty"`
+	UUIData          string   `json:"uuiData,omitempty"`
+}
+
+// Validate validates this CreateCallRequest
+//
+// Exactly one target must be given: PhoneNumber, CallUserID, CallQueueID, or ConversationIDs.
+// CallUserID, CallQueueID, and CallFromQueueID must be identifiers.
+func (call CreateCallRequest) Validate() error {
+	targets := []string{}
+	if len(call.PhoneNumber) > 0 {
+		targets = append(targets, "phoneNumber")
+	}
+	if len(call.CallUserID) > 0 {
+		targets = append(targets, "callUserId")
+	}
+	if len(call.CallQueueID) > 0 {
+		targets = append(targets, "callQueueId")
+	}
+	if len(call.ConversationIDs) > 0 {
+		targets = append(targets, "conversationIds")
+	}
+	if len(targets) == 0 {
+		return errors.ArgumentMissing.With("phoneNumber, callUserId, callQueueId, or conversationIds").WithStack()
+	}
+	if len(targets) > 1 {
+		return errors.ArgumentInvalid.With(targets[1], "cannot be used with "+targets[0]).WithStack()
+	}
+	for _, field := range []struct{ Name, Value string }{
+		{"callUserId", call.CallUserID},
+		{"callQueueId", call.CallQueueID},
+		{"callFromQueueId", call.CallFromQueueID},
+	} {
+		if len(field.Value) > 0 {
+			if _, err := uuid.Parse(field.Value); err != nil {
+				return errors.ArgumentInvalid.With(field.Name, field.Value).WithStack()
+			}
+		}
+	}
+	return nil
+}
+
+// ConsultTransferDestination describes the destination of a Consult Transfer
+//
+// Only one of Address, UserID, or QueueID should be given
+type ConsultTransferDestination struct {
+	Address string `json:"address,omitempty"`
+	Name    string `json:"name,omitempty"`
+	UserID  string `json:"userId,omitempty"`
+	QueueID string `json:"queueId,omitempty"`
+}
+
+const (
+	// ConsultSpeakToDestination means the agent speaks to the consult destination only
+	ConsultSpeakToDestination = "DESTINATION"
+	// ConsultSpeakToObject means the agent speaks to the original participant (the object of the transfer) only
+	ConsultSpeakToObject = "OBJECT"
+	// ConsultSpeakToBoth means all participants are in conference
+	ConsultSpeakToBoth = "BOTH"
+)
+
+// Initialize initializes this from the given Client
+//   implements Initializable
+func (conversation *ConversationCall) Initialize(parameters ...interface{}) error {
+	client, logger, id, err := parseParameters(conversation, parameters...)
+	if err != nil {
+		return err
+	}
+	if id != uuid.Nil {
+		if err := client.Get(NewURI("/conversations/calls/%s", id), &conversation); err != nil {
+			return err
+		}
+	}
+	conversation.Client = client
+	conversation.Logger = logger.Child("conversation", "conversation", "media", "call", "conversation", conversation.ID)
+	return nil
+}
+
+// PlaceCall places an outbound call and returns the newly created ConversationCall
+//
+// See: https://developer.genesys.cloud/api/rest/v2/conversations/#post-api-v2-conversations-calls
+func (client *Client) PlaceCall(call *CreateCallRequest) (*ConversationCall, error) {
+	if call == nil {
+		return nil, errors.ArgumentMissing.With("call").WithStack()
+	}
+	if err := call.Validate(); err != nil {
+		return nil, err
+	}
+	response := struct {
+		ID      uuid.UUID `json:"id"`
+		SelfURI string    `json:"selfUri"`
+	}{}
+	if err := client.Post("/conversations/calls", call, &response); err != nil {
+		return nil, err
+	}
+	conversation := &ConversationCall{ID: response.ID}
+	if err := conversation.Initialize(client); err != nil {
+		return nil, err
+	}
+	return conversation, nil
+}
+
+// GetID gets the identifier of this
+//   implements Identifiable
+func (conversation ConversationCall) GetID() uuid.UUID {
+	return conversation.ID
+}
+
+// String gets a string version
+//   implements the fmt.Stringer interface
+func (conversation ConversationCall) String() string {
+	return conversation.ID.String()
+}
+
+// Answer answers the call on behalf of the given participant
+func (conversation ConversationCall) Answer(identifiable Identifiable) error {
+	return conversation.UpdateState(identifiable, "connected")
+}
+
+// Disconnect disconnect an Identifiable from this
+//   implements Disconnecter
+func (conversation ConversationCall) Disconnect(identifiable Identifiable) error {
+	return conversation.UpdateState(identifiable, "disconnected")
+}
+
+// UpdateState update the state of an identifiable in this
+//   implements StateUpdater
+func (conversation ConversationCall) UpdateState(identifiable Identifiable, state string) error {
+	return conversation.Client.Patch(
+		NewURI("/conversations/calls/%s/participants/%s", conversation.ID, identifiable.GetID()),
+		MediaParticipantRequest{State: state},
+		nil,
+	)
+}
+
+// Hold puts the given participant on hold
+func (conversation ConversationCall) Hold(identifiable Identifiable) error {
+	return conversation.updateParticipant(identifiable, struct {
+		Held bool `json:"held"`
+	}{Held: true})
+}
+
+// Unhold takes the given participant off hold
+func (conversation ConversationCall) Unhold(identifiable Identifiable) error {
+	return conversation.updateParticipant(identifiable, struct {
+		Held bool `json:"held"`
+	}{Held: false})
+}
+
+// Mute mutes the given participant
+func (conversation ConversationCall) Mute(identifiable Identifiable) error {
+	return conversation.updateParticipant(identifiable, struct {
+		Muted bool `json:"muted"`
+	}{Muted: true})
+}
+
+// Unmute unmutes the given participant
+func (conversation ConversationCall) Unmute(identifiable Identifiable) error {
+	return conversation.updateParticipant(identifiable, struct {
+		Muted bool `json:"muted"`
+	}{Muted: false})
+}
+
+// PauseRecording pauses the recording of this call
+func (conversation ConversationCall) PauseRecording() error {
+	return conversation.setRecordingState("paused")
+}
+
+// ResumeRecording resumes the recording of this call
+func (conversation ConversationCall) ResumeRecording() error {
+	return conversation.setRecordingState("active")
+}
+
+// Transfer transfers (blind) a participant of this Conversation to the given User or Queue
+//   implements Transferrer
+//
+// If the target is neither a User nor a Queue, its identifier is considered as a Queue's
+func (conversation ConversationCall) Transfer(identifiable Identifiable, target Identifiable) error {
+	payload := struct {
+		UserID  string `json:"userId,omitempty"`
+		QueueID string `json:"queueId,omitempty"`
+	}{}
+	switch target.(type) {
+	case User, *User:
+		payload.UserID = target.GetID().String()
+	default:
+		payload.QueueID = target.GetID().String()
+	}
+	return conversation.Client.Post(
+		NewURI("/conversations/calls/%s/participants/%s/replace", conversation.ID, identifiable.GetID()),
+		payload,
+		nil,
+	)
+}
+
+// TransferToAddress transfers (blind) a participant of this Conversation to the given address (phone number, SIP URI)
+func (conversation ConversationCall) TransferToAddress(identifiable Identifiable, address string) error {
+	if len(address) == 0 {
+		return errors.ArgumentMissing.With("address").WithStack()
+	}
+	return conversation.Client.Post(
+		NewURI("/conversations/calls/%s/participants/%s/replace", conversation.ID, identifiable.GetID()),
+		struct {
+			Address string `json:"address"`
+		}{Address: address},
+		nil,
+	)
+}
+
+// ConsultTransfer starts a consult transfer of the given participant to the destination
+//
+// speakTo should be one of ConsultSpeakToDestination, ConsultSpeakToObject, ConsultSpeakToBoth.
+// If empty, ConsultSpeakToDestination is used.
+//
+// The identifier of the new consult participant is returned.
+// The consult can then be updated with UpdateConsult, cancelled with CancelConsult, or completed with CompleteConsult.
+func (conversation ConversationCall) ConsultTransfer(identifiable Identifiable, destination ConsultTransferDestination, speakTo string) (uuid.UUID, error) {
+	if len(speakTo) == 0 {
+		speakTo = ConsultSpeakToDestination
+	}
+	response := struct {
+		DestinationParticipantID uuid.UUID `json:"destinationParticipantId"`
+	}{}
+	err := conversation.Client.Post(
+		NewURI("/conversations/calls/%s/participants/%s/consult", conversation.ID, identifiable.GetID()),
+		struct {
+			SpeakTo     string                     `json:"speakTo"`
+			Destination ConsultTransferDestination `json:"destination"`
+		}{
+			SpeakTo:     speakTo,
+			Destination: destination,
+		},
+		&response,
+	)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return response.DestinationParticipantID, nil
+}
+
+// UpdateConsult changes who the agent speaks to during a consult transfer
+func (conversation ConversationCall) UpdateConsult(identifiable Identifiable, speakTo string) error {
+	return conversation.Client.Patch(
+		NewURI("/conversations/calls/%s/participants/%s/consult", conversation.ID, identifiable.GetID()),
+		struct {
+			SpeakTo string `json:"speakTo"`
+		}{SpeakTo: speakTo},
+		nil,
+	)
+}
+
+// CancelConsult cancels the consult transfer of the given participant
+//
+// The consult participant is disconnected and the original participant is reconnected with the agent
+func (conversation ConversationCall) CancelConsult(identifiable Identifiable) error {
+	return conversation.Client.Delete(
+		NewURI("/conversations/calls/%s/participants/%s/consult", conversation.ID, identifiable.GetID()),
+		nil,
+	)
+}
+
+// CompleteConsult completes a consult transfer by disconnecting the agent participant
+//
+// The original participant and the consult participant stay connected together
+func (conversation ConversationCall) CompleteConsult(agent Identifiable) error {
+	return conversation.Disconnect(agent)
+}
+
+// Conference adds the given addresses (phone numbers, SIP URIs) as new participants of this call
+func (conversation ConversationCall) Conference(addresses ...string) error {
+	if len(addresses) == 0 {
+		return errors.ArgumentMissing.With("addresses").WithStack()
+	}
+	type participant struct {
+		Address string `json:"address"`
+	}
+	participants := make([]participant, len(addresses))
+	for i, address := range addresses {
+		participants[i].Address = address
+	}
+	return conversation.Client.Post(
+		NewURI("/conversations/calls/%s/participants", conversation.ID),
+		struct {
+			Participants []participant `json:"participants"`
+		}{Participants: participants},
+		nil,
+	)
+}
+
+// SendDigits sends DTMF digits to the given participant
+//
+// digits can contain 0-9, *, #, and the pause characters ',' and 'w'
+func (conversation ConversationCall) SendDigits(identifiable Identifiable, digits string) error {
+	if len(digits) == 0 {
+		return errors.ArgumentMissing.With("digits").WithStack()
+	}
+	return conversation.Client.Post(
+		NewURI("/conversations/%s/participants/%s/digits", conversation.ID, identifiable.GetID()),
+		struct {
+			Digits string `json:"digits"`
+		}{Digits: digits},
+		nil,
+	)
+}
+
+// Wrapup wraps up a Participant of this Conversation
+//   The wrapup code is validated against the codes assigned to the queue of the participant and can be given by name
+func (conversation ConversationCall) Wrapup(identifiable Identifiable, wrapup *Wrapup) error {
+	wrapup, err := resolveWrapup(conversation.Client, conversation.Participants, identifiable, wrapup)
+	if err != nil {
+		return err
+	}
+	return conversation.Client.Patch(
+		NewURI("/conversations/calls/%s/participants/%s", conversation.ID, identifiable.GetID()),
+		MediaParticipantRequest{Wrapup: wrapup},
+		nil,
+	)
+}
+
+// updateParticipant sends a partial update of a participant
+//
+// MediaParticipantRequest omits false values, so we cannot use it to unset a flag
+func (conversation ConversationCall) updateParticipant(identifiable Identifiable, payload interface{}) error {
+	return conversation.Client.Patch(
+		NewURI("/conversations/calls/%s/participants/%s", conversation.ID, identifiable.GetID()),
+		payload,
+		nil,
+	)
+}
+
+// setRecordingState sets the recording state of this call (active, paused)
+func (conversation ConversationCall) setRecordingState(state string) error {
+	return conversation.Client.Patch(
+		NewURI("/conversations/calls/%s", conversation.ID),
+		struct {
+			RecordingState string `json:"recordingState"`
+		}{RecordingState: state},
+		nil,
+	)
 }
@@ -0,0 +1,237 @@
+package gcloudcx_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gildas/go-errors"
+	"github.com/gildas/go-gcloudcx"
+	"github.com/gildas/go-logger"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+type ConversationCallSuite struct {
+	suite.Suite
+	Name   string
+	Logger *logger.Logger
+	Start  time.Time
+
+	ConversationID uuid.UUID
+	ParticipantID  uuid.UUID
+	Conversation   *gcloudcx.ConversationCall
+	Participant    gcloudcx.Identifiable
+	Recorder       *RequestRecorder
+	Server         *httptest.Server
+	Client         *gcloudcx.Client
+}
+
+func TestConversationCallSuite(t *testing.T) {
+	suite.Run(t, new(ConversationCallSuite))
+}
+
+func (suite *ConversationCallSuite) TestCanPlaceCall() {
+	call, err := suite.Client.PlaceCall(&gcloudcx.CreateCallRequest{PhoneNumber: "+13175550100", CallerID: "+13175550199"})
+	suite.Require().Nilf(err, "Failed to place call. %s", err)
+	suite.Assert().Equal(suite.ConversationID, call.ID)
+	suite.Assert().Equal("connected", call.State)
+	suite.Assert().Equal([]string{
+		"POST /api/v2/conversations/calls",
+		"GET /api/v2/conversations/calls/" + suite.ConversationID.String(),
+	}, suite.Recorder.Requests())
+	suite.Assert().JSONEq(`{"phoneNumber":"+13175550100","callerId":"+13175550199"}`, suite.Recorder.Bodies()[0])
+}
+
+func (suite *ConversationCallSuite) TestShouldNotPlaceCallWithoutTarget() {
+	_, err := suite.Client.PlaceCall(&gcloudcx.CreateCallRequest{CallerID: "+13175550199"})
+	suite.Require().NotNil(err, "Call should not have been placed")
+	suite.Assert().True(errors.Is(err, errors.ArgumentMissing), "Error should be an ArgumentMissing")
+	suite.Assert().Empty(suite.Recorder.Requests())
+}
+
+func (suite *ConversationCallSuite) TestShouldNotPlaceCallWithSeveralTargets() {
+	_, err := suite.Client.PlaceCall(&gcloudcx.CreateCallRequest{PhoneNumber: "+13175550100", CallUserID: uuid.New().String()})
+	suite.Require().NotNil(err, "Call should not have been placed")
+	suite.Assert().True(errors.Is(err, errors.ArgumentInvalid), "Error should be an ArgumentInvalid")
+	suite.Assert().Contains(err.Error(), "callUserId")
+	suite.Assert().Empty(suite.Recorder.Requests())
+}
+
+func (suite *ConversationCallSuite) TestShouldNotPlaceCallWithInvalidQueue() {
+	_, err := suite.Client.PlaceCall(&gcloudcx.CreateCallRequest{CallQueueID: "Sales"})
+	suite.Require().NotNil(err, "Call should not have been placed")
+	suite.Assert().True(errors.Is(err, errors.ArgumentInvalid), "Error should be an ArgumentInvalid")
+	suite.Assert().Contains(err.Error(), "callQueueId")
+	suite.Assert().Empty(suite.Recorder.Requests())
+}
+
+func (suite *ConversationCallSuite) TestCanUpdateParticipant() {
+	participantPath := fmt.Sprintf("PATCH /api/v2/conversations/calls/%s/participants/%s", suite.ConversationID, suite.ParticipantID)
+	operations := []struct {
+		Name    string
+		Execute func() error
+		Body    string
+	}{
+		{"Answer", func() error { return suite.Conversation.Answer(suite.Participant) }, `{"state":"connected"}`},
+		{"Hold", func() error { return suite.Conversation.Hold(suite.Participant) }, `{"held":true}`},
+		{"Unhold", func() error { return suite.Conversation.Unhold(suite.Participant) }, `{"held":false}`},
+		{"Mute", func() error { return suite.Conversation.Mute(suite.Participant) }, `{"muted":true}`},
+		{"Unmute", func() error { return suite.Conversation.Unmute(suite.Participant) }, `{"muted":false}`},
+		{"Disconnect", func() error { return suite.Conversation.Disconnect(suite.Participant) }, `{"state":"disconnected"}`},
+		{"CompleteConsult", func() error { return suite.Conversation.CompleteConsult(suite.Participant) }, `{"state":"disconnected"}`},
+	}
+	for _, operation := range operations {
+		suite.Recorder.Reset()
+		err := operation.Execute()
+		suite.Require().Nilf(err, "Failed to %s. %s", operation.Name, err)
+		suite.Assert().Equal([]string{participantPath}, suite.Recorder.Requests(), operation.Name)
+		suite.Assert().JSONEq(operation.Body, suite.Recorder.Bodies()[0], operation.Name)
+	}
+}
+
+func (suite *ConversationCallSuite) TestCanControlRecording() {
+	err := suite.Conversation.PauseRecording()
+	suite.Require().Nilf(err, "Failed to pause recording. %s", err)
+	err = suite.Conversation.ResumeRecording()
+	suite.Require().Nilf(err, "Failed to resume recording. %s", err)
+	conversationPath := "PATCH /api/v2/conversations/calls/" + suite.ConversationID.String()
+	suite.Assert().Equal([]string{conversationPath, conversationPath}, suite.Recorder.Requests())
+	suite.Assert().Equal([]string{`{"recordingState":"paused"}`, `{"recordingState":"active"}`}, suite.Recorder.Bodies())
+}
+
+func (suite *ConversationCallSuite) TestCanUnmarshalRecordingState() {
+	conversation := gcloudcx.ConversationCall{}
+	err := json.Unmarshal([]byte(`{"id": "`+suite.ConversationID.String()+`", "recordingState": "paused"}`), &conversation)
+	suite.Require().Nilf(err, "Failed to unmarshal call. %s", err)
+	suite.Assert().Equal("paused", conversation.RecordingState)
+}
+
+func (suite *ConversationCallSuite) TestCanTransfer() {
+	userID, queueID := uuid.New(), uuid.New()
+	err := suite.Conversation.Transfer(suite.Participant, gcloudcx.User{ID: userID})
+	suite.Require().Nilf(err, "Failed to transfer to user. %s", err)
+	err = suite.Conversation.Transfer(suite.Participant, &gcloudcx.Queue{ID: queueID})
+	suite.Require().Nilf(err, "Failed to transfer to queue. %s", err)
+	err = suite.Conversation.TransferToAddress(suite.Participant, "+13175550100")
+	suite.Require().Nilf(err, "Failed to transfer to address. %s", err)
+	replacePath := fmt.Sprintf("POST /api/v2/conversations/calls/%s/participants/%s/replace", suite.ConversationID, suite.ParticipantID)
+	suite.Assert().Equal([]string{replacePath, replacePath, replacePath}, suite.Recorder.Requests())
+	suite.Assert().Equal([]string{
+		fmt.Sprintf(`{"userId":"%s"}`, userID),
+		fmt.Sprintf(`{"queueId":"%s"}`, queueID),
+		`{"address":"+13175550100"}`,
+	}, suite.Recorder.Bodies())
+
+	err = suite.Conversation.TransferToAddress(suite.Participant, "")
+	suite.Assert().True(errors.Is(err, errors.ArgumentMissing), "Error should be an ArgumentMissing")
+}
+
+func (suite *ConversationCallSuite) TestCanConsultTransfer() {
+	queueID := uuid.New().String()
+	consultID, err := suite.Conversation.ConsultTransfer(suite.Participant, gcloudcx.ConsultTransferDestination{QueueID: queueID}, "")
+	suite.Require().Nilf(err, "Failed to start consult. %s", err)
+	suite.Assert().Equal(uuid.MustParse("3c3c0d8a-0b5c-4c6e-8f27-5f4c6a3e9a07"), consultID)
+	err = suite.Conversation.UpdateConsult(suite.Participant, gcloudcx.ConsultSpeakToBoth)
+	suite.Require().Nilf(err, "Failed to update consult. %s", err)
+	err = suite.Conversation.CancelConsult(suite.Participant)
+	suite.Require().Nilf(err, "Failed to cancel consult. %s", err)
+	consultPath := fmt.Sprintf("/api/v2/conversations/calls/%s/participants/%s/consult", suite.ConversationID, suite.ParticipantID)
+	suite.Assert().Equal([]string{"POST " + consultPath, "PATCH " + consultPath, "DELETE " + consultPath}, suite.Recorder.Requests())
+	bodies := suite.Recorder.Bodies()
+	suite.Assert().JSONEq(fmt.Sprintf(`{"speakTo":"DESTINATION","destination":{"queueId":"%s"}}`, queueID), bodies[0])
+	suite.Assert().JSONEq(`{"speakTo":"BOTH"}`, bodies[1])
+}
+
+func (suite *ConversationCallSuite) TestCanConference() {
+	err := suite.Conversation.Conference("+13175550100", "sip:bob@example.com")
+	suite.Require().Nilf(err, "Failed to conference. %s", err)
+	suite.Assert().Equal([]string{fmt.Sprintf("POST /api/v2/conversations/calls/%s/participants", suite.ConversationID)}, suite.Recorder.Requests())
+	suite.Assert().JSONEq(`{"participants":[{"address":"+13175550100"},{"address":"sip:bob@example.com"}]}`, suite.Recorder.Bodies()[0])
+
+	err = suite.Conversation.Conference()
+	suite.Assert().True(errors.Is(err, errors.ArgumentMissing), "Error should be an ArgumentMissing")
+}
+
+func (suite *ConversationCallSuite) TestCanSendDigits() {
+	err := suite.Conversation.SendDigits(suite.Participant, "1234#")
+	suite.Require().Nilf(err, "Failed to send digits. %s", err)
+	suite.Assert().Equal([]string{fmt.Sprintf("POST /api/v2/conversations/%s/participants/%s/digits", suite.ConversationID, suite.ParticipantID)}, suite.Recorder.Requests())
+	suite.Assert().JSONEq(`{"digits":"1234#"}`, suite.Recorder.Bodies()[0])
+
+	err = suite.Conversation.SendDigits(suite.Participant, "")
+	suite.Assert().True(errors.Is(err, errors.ArgumentMissing), "Error should be an ArgumentMissing")
+}
+
+func (suite *ConversationCallSuite) TestCanWrapup() {
+	err := suite.Conversation.Wrapup(suite.Participant, &gcloudcx.Wrapup{Code: "Sale", Notes: "Called back"})
+	suite.Require().Nilf(err, "Failed to wrap up. %s", err)
+	suite.Assert().Equal([]string{fmt.Sprintf("PATCH /api/v2/conversations/calls/%s/participants/%s", suite.ConversationID, suite.ParticipantID)}, suite.Recorder.Requests())
+	suite.Assert().Contains(suite.Recorder.Bodies()[0], `"wrapup":{`)
+	suite.Assert().Contains(suite.Recorder.Bodies()[0], `"code":"Sale"`)
+}
+
+// Suite Tools
+
+func (suite *ConversationCallSuite) SetupSuite() {
+	suite.Name = strings.TrimSuffix(reflect.TypeOf(*suite).Name(), "Suite")
+	suite.Logger = logger.Create("test",
+		&logger.FileStream{
+			Path:        fmt.Sprintf("./log/test-%s.log", strings.ToLower(suite.Name)),
+			Unbuffered:  true,
+			FilterLevel: logger.TRACE,
+		},
+	).Child("test", "test")
+	suite.Logger.Infof("Suite Start: %s %s", suite.Name, strings.Repeat("=", 80-14-len(suite.Name)))
+	suite.ConversationID = uuid.MustParse("1a1a0d8a-0b5c-4c6e-8f27-5f4c6a3e9a05")
+	suite.ParticipantID = uuid.MustParse("2b2b0d8a-0b5c-4c6e-8f27-5f4c6a3e9a06")
+	suite.Participant = gcloudcx.Participant{ID: suite.ParticipantID}
+	suite.Recorder = NewRequestRecorder(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		conversationPath := "/api/v2/conversations/calls/" + suite.ConversationID.String()
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v2/conversations/calls":
+			_, _ = w.Write([]byte(fmt.Sprintf(`{"id": "%s"}`, suite.ConversationID)))
+		case r.Method == http.MethodGet && r.URL.Path == conversationPath:
+			_, _ = w.Write([]byte(fmt.Sprintf(`{"id": "%s", "state": "connected", "direction": "outbound"}`, suite.ConversationID)))
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/consult"):
+			_, _ = w.Write([]byte(`{"destinationParticipantId": "3c3c0d8a-0b5c-4c6e-8f27-5f4c6a3e9a07"}`))
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusAccepted)
+			_, _ = w.Write([]byte(`{}`))
+		}
+	})
+	suite.Server = httptest.NewServer(suite.Recorder)
+	suite.Client = CreateTestClient(suite.Server.URL, suite.Logger)
+	suite.Conversation = &gcloudcx.ConversationCall{ID: suite.ConversationID, Client: suite.Client}
+}
+
+func (suite *ConversationCallSuite) TearDownSuite() {
+	suite.Server.Close()
+	if suite.T().Failed() {
+		suite.Logger.Warnf("At least one test failed, we are not cleaning")
+		suite.T().Log("At least one test failed, we are not cleaning")
+	} else {
+		suite.Logger.Infof("All tests succeeded, we are cleaning")
+	}
+	suite.Logger.Infof("Suite End: %s %s", suite.Name, strings.Repeat("=", 80-12-len(suite.Name)))
+	suite.Logger.Close()
+}
+
+func (suite *ConversationCallSuite) BeforeTest(suiteName, testName string) {
+	suite.Logger.Infof("Test Start: %s %s", testName, strings.Repeat("-", 80-13-len(testName)))
+	suite.Start = time.Now()
+	suite.Recorder.Reset()
+}
+
+func (suite *ConversationCallSuite) AfterTest(suiteName, testName string) {
+	duration := time.Since(suite.Start)
+	suite.Logger.Record("duration", duration.String()).Infof("Test End: %s %s", testName, strings.Repeat("-", 80-11-len(testName)))
+}
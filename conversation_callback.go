@@ -1,8 +1,11 @@
 package gcloudcx
 
 import (
+	"encoding/json"
 	"time"
 
+	"github.com/gildas/go-errors"
+	"github.com/gildas/go-logger"
 	"github.com/google/uuid"
 )
 
@@ -30,4 +33,207 @@ type ConversationCallback struct {
 	CallbackUserName          string         `json:"callbackUserName"`
 	ScriptID                  string         `json:"scriptId"`
 	AutomatedCallbackConfigID string         `json:"automatedCallbackConfigId"`
+	Participants              []*Participant `json:"participants,omitempty"`
+	Client                    *Client        `json:"-"`
+	Logger                    *logger.Logger `json:"-"`
+}
+
+// CreateCallbackRequest describes the request to create a Callback
+//
+// If ScheduledTime is zero, the callback is placed in the queue immediately.
+// ScheduledTime can be given in any time zone, it is sent to GCloud in UTC.
+//
+// See: https://developer.genesys.cloud/api/rest/v2/conversations/#post-api-v2-conversations-callbacks
+type CreateCallbackRequest struct {
+	QueueID                 uuid.UUID         `json:"queueId"`
+	ScriptID                string            `json:"scriptId,omitempty"`
+	CallbackUserName        string            `json:"callbackUserName,omitempty"`
+	CallbackNumbers         []string          `json:"callbackNumbers"`
+	ScheduledTime           time.Time         `json:"-"`
+	CountryCode             string            `json:"countryCode,omitempty"`
+	ValidateCallbackNumbers bool              `json:"validateCallbackNumbers,omitempty"`
+	Data                    map[string]string `json:"data,omitempty"`
+}
+
+// CreateCallback creates a new Callback in a Queue
+func (client *Client) CreateCallback(callback *CreateCallbackRequest) (*ConversationCallback, error) {
+	if callback == nil {
+		return nil, errors.ArgumentMissing.With("callback").WithStack()
+	}
+	if callback.QueueID == uuid.Nil {
+		return nil, errors.ArgumentMissing.With("queueId").WithStack()
+	}
+	if len(callback.CallbackNumbers) == 0 {
+		return nil, errors.ArgumentMissing.With("callbackNumbers").WithStack()
+	}
+	response := struct {
+		Conversation AddressableEntityRef `json:"conversation"`
+	}{}
+	if err := client.Post("/conversations/callbacks", callback, &response); err != nil {
+		return nil, err
+	}
+	conversation := &ConversationCallback{ID: response.Conversation.ID}
+	if err := conversation.Initialize(client); err != nil {
+		return nil, err
+	}
+	return conversation, nil
+}
+
+// callbackPageSize is the page size used when querying the callbacks of a Queue (100 is the maximum for analytics details)
+const callbackPageSize = 100
+
+// FetchCallbacks fetches the pending callbacks (not yet ended) of this Queue
+//
+// All the pages of the analytics query are read.
+//
+// interval is the period (ISO-8601) where the callbacks were created, e.g.: "2021-06-01T00:00:00Z/2021-06-08T00:00:00Z"
+func (queue Queue) FetchCallbacks(interval string) ([]*ConversationCallback, error) {
+	if len(interval) == 0 {
+		return nil, errors.ArgumentMissing.With("interval").WithStack()
+	}
+	analyticsInterval, err := ParseAnalyticsInterval(interval)
+	if err != nil {
+		return nil, err
+	}
+	query := NewConversationDetailsQuery(analyticsInterval).
+		WithConversationFilters(AndFilter(DimensionNotExists("conversationEnd"))).
+		WithSegmentFilters(AndFilter(
+			DimensionMatches("mediaType", "callback"),
+			DimensionMatches("queueId", queue.ID.String()),
+		))
+	conversations := []*Conversation{}
+	for page := 1; ; page++ {
+		results, total, err := queue.Client.QueryConversationDetails(query.WithPaging(callbackPageSize, page))
+		if err != nil {
+			return nil, err
+		}
+		conversations = append(conversations, results...)
+		if len(results) == 0 || len(conversations) >= total {
+			break
+		}
+	}
+	callbacks := make([]*ConversationCallback, 0, len(conversations))
+	for _, conversation := range conversations {
+		callback := &ConversationCallback{ID: conversation.ID}
+		if err := callback.Initialize(queue.Client); err != nil {
+			return nil, err
+		}
+		callbacks = append(callbacks, callback)
+	}
+	return callbacks, nil
+}
+
+// Initialize initializes this from the given Client
+//   implements Initializable
+func (conversation *ConversationCallback) Initialize(parameters ...interface{}) error {
+	client, logger, id, err := parseParameters(conversation, parameters...)
+	if err != nil {
+		return err
+	}
+	if id != uuid.Nil {
+		if err := client.Get(NewURI("/conversations/callbacks/%s", id), &conversation); err != nil {
+			return err
+		}
+	}
+	conversation.Client = client
+	conversation.Logger = logger.Child("conversation", "conversation", "media", "callback", "conversation", conversation.ID)
+	return nil
+}
+
+// GetID gets the identifier of this
+//   implements Identifiable
+func (conversation ConversationCallback) GetID() uuid.UUID {
+	return conversation.ID
+}
+
+// String gets a string version
+//   implements the fmt.Stringer interface
+func (conversation ConversationCallback) String() string {
+	return conversation.ID.String()
+}
+
+// Reschedule reschedules this Callback at the given time
+//
+// The time can be given in any time zone, it is sent to GCloud in UTC
+func (conversation *ConversationCallback) Reschedule(scheduledTime time.Time) error {
+	if scheduledTime.IsZero() {
+		return errors.ArgumentMissing.With("scheduledTime").WithStack()
+	}
+	if err := conversation.Client.Patch(
+		"/conversations/callbacks",
+		struct {
+			ConversationID string `json:"conversationId"`
+			ScheduledTime  string `json:"callbackScheduledTime"`
+		}{
+			ConversationID: conversation.ID.String(),
+			ScheduledTime:  scheduledTime.UTC().Format(callbackTimeFormat),
+		},
+		nil,
+	); err != nil {
+		return err
+	}
+	conversation.ScheduledTime = scheduledTime.UTC()
+	return nil
+}
+
+// Cancel cancels this Callback by disconnecting its callback participants
+func (conversation *ConversationCallback) Cancel() error {
+	if len(conversation.Participants) == 0 {
+		if err := conversation.Initialize(conversation.Client); err != nil {
+			return err
+		}
+	}
+	canceled := false
+	for _, participant := range conversation.Participants {
+		if len(participant.Callbacks) == 0 {
+			continue
+		}
+		if participant.State == "disconnected" || participant.State == "terminated" {
+			continue
+		}
+		if err := conversation.Disconnect(participant); err != nil {
+			return err
+		}
+		canceled = true
+	}
+	if !canceled {
+		return errors.NotFound.With("participant", "callback").WithStack()
+	}
+	return nil
+}
+
+// Disconnect disconnect an Identifiable from this
+//   implements Disconnecter
+func (conversation ConversationCallback) Disconnect(identifiable Identifiable) error {
+	return conversation.UpdateState(identifiable, "disconnected")
+}
+
+// UpdateState update the state of an identifiable in this
+//   implements StateUpdater
+func (conversation ConversationCallback) UpdateState(identifiable Identifiable, state string) error {
+	return conversation.Client.Patch(
+		NewURI("/conversations/callbacks/%s/participants/%s", conversation.ID, identifiable.GetID()),
+		MediaParticipantRequest{State: state},
+		nil,
+	)
+}
+
+// callbackTimeFormat is the ISO-8601 format GCloud expects for callback times
+const callbackTimeFormat = "2006-01-02T15:04:05.000Z"
+
+// MarshalJSON marshals this into JSON
+func (callback CreateCallbackRequest) MarshalJSON() ([]byte, error) {
+	type surrogate CreateCallbackRequest
+	scheduledTime := ""
+	if !callback.ScheduledTime.IsZero() {
+		scheduledTime = callback.ScheduledTime.UTC().Format(callbackTimeFormat)
+	}
+	data, err := json.Marshal(struct {
+		surrogate
+		ScheduledTime string `json:"callbackScheduledTime,omitempty"`
+	}{
+		surrogate:     surrogate(callback),
+		ScheduledTime: scheduledTime,
+	})
+	return data, errors.JSONMarshalError.Wrap(err)
 }
@@ -0,0 +1,165 @@
+package gcloudcx_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gildas/go-errors"
+	"github.com/gildas/go-gcloudcx"
+	"github.com/gildas/go-logger"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+type CallbackSuite struct {
+	suite.Suite
+	Name   string
+	Logger *logger.Logger
+	Start  time.Time
+}
+
+func TestCallbackSuite(t *testing.T) {
+	suite.Run(t, new(CallbackSuite))
+}
+
+func (suite *CallbackSuite) TestCanMarshalCreateCallbackRequest() {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	suite.Require().Nilf(err, "Failed to load time zone. %s", err)
+	callback := gcloudcx.CreateCallbackRequest{
+		QueueID:          uuid.MustParse("9d0ba4c5-6e3e-4d2a-8a27-8d6a40f15e5e"),
+		CallbackUserName: "Bob Minion",
+		CallbackNumbers:  []string{"+81312345678"},
+		ScheduledTime:    time.Date(2021, 6, 30, 18, 30, 0, 0, tokyo),
+		Data:             map[string]string{"reason": "billing"},
+	}
+	data, err := json.Marshal(callback)
+	suite.Require().Nilf(err, "Failed to marshal CreateCallbackRequest. %s", err)
+	expected := `{
+		"queueId": "9d0ba4c5-6e3e-4d2a-8a27-8d6a40f15e5e",
+		"callbackUserName": "Bob Minion",
+		"callbackNumbers": ["+81312345678"],
+		"callbackScheduledTime": "2021-06-30T09:30:00.000Z",
+		"data": {"reason": "billing"}
+	}`
+	suite.Assert().JSONEq(expected, string(data))
+}
+
+func (suite *CallbackSuite) TestCanMarshalCreateCallbackRequestWithoutScheduledTime() {
+	callback := gcloudcx.CreateCallbackRequest{
+		QueueID:         uuid.MustParse("9d0ba4c5-6e3e-4d2a-8a27-8d6a40f15e5e"),
+		CallbackNumbers: []string{"+81312345678"},
+	}
+	data, err := json.Marshal(callback)
+	suite.Require().Nilf(err, "Failed to marshal CreateCallbackRequest. %s", err)
+	suite.Assert().NotContains(string(data), "callbackScheduledTime")
+}
+
+func (suite *CallbackSuite) TestShouldNotCreateCallbackWithoutNumbers() {
+	client := gcloudcx.NewClient(&gcloudcx.ClientOptions{Logger: suite.Logger})
+	_, err := client.CreateCallback(&gcloudcx.CreateCallbackRequest{QueueID: uuid.New()})
+	suite.Require().NotNil(err, "Should not create a callback without numbers")
+}
+
+func (suite *CallbackSuite) TestCanFetchQueueCallbacksOnSeveralPages() {
+	queueID := uuid.MustParse("9d0ba4c5-6e3e-4d2a-8a27-8d6a40f15e5e")
+	pages := map[int][]string{}
+	total := 0
+	for page := 1; page <= 2; page++ {
+		count := 100
+		if page == 2 {
+			count = 20
+		}
+		for i := 0; i < count; i++ {
+			pages[page] = append(pages[page], uuid.New().String())
+		}
+		total += count
+	}
+	recorder := NewRequestRecorder(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v2/analytics/conversations/details/query":
+			query := struct {
+				Paging struct {
+					PageSize   int `json:"pageSize"`
+					PageNumber int `json:"pageNumber"`
+				} `json:"paging"`
+			}{}
+			_ = json.NewDecoder(r.Body).Decode(&query)
+			conversations := []string{}
+			for _, id := range pages[query.Paging.PageNumber] {
+				conversations = append(conversations, fmt.Sprintf(`{"conversationId": "%s"}`, id))
+			}
+			_, _ = w.Write([]byte(fmt.Sprintf(`{"conversations": [%s], "totalHits": %d}`, strings.Join(conversations, ","), total)))
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/api/v2/conversations/callbacks/"):
+			_, _ = w.Write([]byte(fmt.Sprintf(`{"id": "%s"}`, strings.TrimPrefix(r.URL.Path, "/api/v2/conversations/callbacks/"))))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"status": 404, "code": "not.found", "message": "not found"}`))
+		}
+	})
+	server := httptest.NewServer(recorder)
+	defer server.Close()
+
+	queue := gcloudcx.Queue{ID: queueID, Client: CreateTestClient(server.URL, suite.Logger)}
+	callbacks, err := queue.FetchCallbacks("2021-06-01T00:00:00Z/2021-06-08T00:00:00Z")
+	suite.Require().Nilf(err, "Failed to fetch callbacks. %s", err)
+	suite.Require().Len(callbacks, 120)
+	suite.Assert().Equal(pages[2][19], callbacks[119].ID.String())
+	queries := 0
+	for i, request := range recorder.Requests() {
+		if request == "POST /api/v2/analytics/conversations/details/query" {
+			queries++
+			suite.Assert().Contains(recorder.Bodies()[i], fmt.Sprintf(`"pageNumber":%d`, queries))
+		}
+	}
+	suite.Assert().Equal(2, queries, "Both pages should have been queried, and only them")
+}
+
+func (suite *CallbackSuite) TestShouldNotFetchQueueCallbacksWithInvalidInterval() {
+	queue := gcloudcx.Queue{ID: uuid.New(), Client: gcloudcx.NewClient(&gcloudcx.ClientOptions{Logger: suite.Logger})}
+	_, err := queue.FetchCallbacks("")
+	suite.Assert().True(errors.Is(err, errors.ArgumentMissing), "Error should be an ArgumentMissing")
+	_, err = queue.FetchCallbacks("last week")
+	suite.Assert().True(errors.Is(err, errors.ArgumentInvalid), "Error should be an ArgumentInvalid")
+}
+
+// Suite Tools
+
+func (suite *CallbackSuite) SetupSuite() {
+	suite.Name = strings.TrimSuffix(reflect.TypeOf(*suite).Name(), "Suite")
+	suite.Logger = logger.Create("test",
+		&logger.FileStream{
+			Path:        fmt.Sprintf("./log/test-%s.log", strings.ToLower(suite.Name)),
+			Unbuffered:  true,
+			FilterLevel: logger.TRACE,
+		},
+	).Child("test", "test")
+	suite.Logger.Infof("Suite Start: %s %s", suite.Name, strings.Repeat("=", 80-14-len(suite.Name)))
+}
+
+func (suite *CallbackSuite) TearDownSuite() {
+	if suite.T().Failed() {
+		suite.Logger.Warnf("At least one test failed, we are not cleaning")
+		suite.T().Log("At least one test failed, we are not cleaning")
+	} else {
+		suite.Logger.Infof("All tests succeeded, we are cleaning")
+	}
+	suite.Logger.Infof("Suite End: %s %s", suite.Name, strings.Repeat("=", 80-12-len(suite.Name)))
+	suite.Logger.Close()
+}
+
+func (suite *CallbackSuite) BeforeTest(suiteName, testName string) {
+	suite.Logger.Infof("Test Start: %s %s", testName, strings.Repeat("-", 80-13-len(testName)))
+	suite.Start = time.Now()
+}
+
+func (suite *CallbackSuite) AfterTest(suiteName, testName string) {
+	duration := time.Since(suite.Start)
+	suite.Logger.Record("duration", duration.String()).Infof("Test End: %s %s", testName, strings.Repeat("-", 80-11-len(testName)))
+}
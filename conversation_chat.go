@@ -143,7 +143,12 @@ func (conversation ConversationChat) SetTyping(member Identifiable) error {
 }
 
 // Wrapup wraps up a Participant of this Conversation
+//   The wrapup code is validated against the codes assigned to the queue of the participant and can be given by name
 func (conversation ConversationChat) Wrapup(identifiable Identifiable, wrapup *Wrapup) error {
+	wrapup, err := resolveWrapup(conversation.Client, conversation.Participants, identifiable, wrapup)
+	if err != nil {
+		return err
+	}
 	return conversation.Client.Patch(
 		NewURI("/conversations/chats/%s/participants/%s", conversation.ID, identifiable.GetID()),
 		MediaParticipantRequest{Wrapup: wrapup},
@@ -1,34 +1,43 @@
 package gcloudcx
 
 import (
+	"io"
+	"net/http"
+	"strings"
 	"time"
 
+	"github.com/gildas/go-errors"
+	"github.com/gildas/go-logger"
+	"github.com/gildas/go-request"
 	"github.com/google/uuid"
 )
 
 // ConversationEmail describes an Email (like belonging to Participant)
 type ConversationEmail struct {
-	ID                uuid.UUID     `json:"id"`
-	State             string        `json:"state"`     // alerting,dialing,contacting,offering,connected,disconnected,terminated,converting,uploading,transmitting,scheduled,none
-	Direction         string        `json:"direction"` // inbound,outbound
-	Held              bool          `json:"held"`
-	ConnectedTime     time.Time     `json:"connectedTime"`
-	DisconnectedTime  time.Time     `json:"disconnectedTime"`
-	StartAlertingTime time.Time     `json:"startAlertingTime"`
-	StartHoldTime     time.Time     `json:"startHoldTime"`
-	Segments          []Segment     `json:"segments"`
-	Provider          string        `json:"provider"`
-	ScriptID          string        `json:"scriptId"`
-	PeerID            string        `json:"peerId"`
-	RecordingID       string        `json:"recordingId"`
-	AutoGenerated     bool          `json:"autoGenerated"`
-	Subject           string        `json:"subject"`
-	MessagesSent      int           `json:"messagesSent"`
-	MessageID         string        `json:"messageId"`
-	Spam              bool          `json:"spam"`
-	DraftAttachments  []*Attachment `json:"draftAttachments"`
-	DisconnectType    string        `json:"disconnectType"` // endpoint,client,system,transfer,timeout,transfer.conference,transfer.consult,transfer.forward,transfer.noanswer,transfer.notavailable,transport.failure,error,peer,other,spam,uncallable
-	ErrorInfo         ErrorBody     `json:"errorInfo"`
+	ID                uuid.UUID      `json:"id"`
+	State             string         `json:"state"`     // alerting,dialing,contacting,offering,connected,disconnected,terminated,converting,uploading,transmitting,scheduled,none
+	Direction         string         `json:"direction"` // inbound,outbound
+	Held              bool           `json:"held"`
+	ConnectedTime     time.Time      `json:"connectedTime"`
+	DisconnectedTime  time.Time      `json:"disconnectedTime"`
+	StartAlertingTime time.Time      `json:"startAlertingTime"`
+	StartHoldTime     time.Time      `json:"startHoldTime"`
+	Segments          []Segment      `json:"segments"`
+	Provider          string         `json:"provider"`
+	ScriptID          string         `json:"scriptId"`
+	PeerID            string         `json:"peerId"`
+	RecordingID       string         `json:"recordingId"`
+	AutoGenerated     bool           `json:"autoGenerated"`
+	Subject           string         `json:"subject"`
+	MessagesSent      int            `json:"messagesSent"`
+	MessageID         string         `json:"messageId"`
+	Spam              bool           `json:"spam"`
+	DraftAttachments  []*Attachment  `json:"draftAttachments"`
+	DisconnectType    string         `json:"disconnectType"` // endpoint,client,system,transfer,timeout,transfer.conference,transfer.consult,transfer.forward,transfer.noanswer,transfer.notavailable,transport.failure,error,peer,other,spam,uncallable
+	ErrorInfo         ErrorBody      `json:"errorInfo"`
+	Participants      []*Participant `json:"participants,omitempty"`
+	Client            *Client        `json:"-"`
+	Logger            *logger.Logger `json:"-"`
 }
 
 // Attachment describes an Email Attachment
@@ -40,3 +49,378 @@ type Attachment struct {
 	ContentLength int64  `json:"contentLength"`
 	InlineImage   bool   `json:"inlineImage"`
 }
+
+// EmailAddress describes an Email address
+type EmailAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+// EmailMessage describes a message in an Email Conversation thread
+//
+// See: https://developer.genesys.cloud/api/rest/v2/conversations/#get-api-v2-conversations-emails--conversationId--messages--messageId-
+type EmailMessage struct {
+	ID              string          `json:"id,omitempty"`
+	Name            string          `json:"name,omitempty"`
+	To              []*EmailAddress `json:"to"`
+	CC              []*EmailAddress `json:"cc,omitempty"`
+	BCC             []*EmailAddress `json:"bcc,omitempty"`
+	From            *EmailAddress   `json:"from"`
+	ReplyTo         *EmailAddress   `json:"replyTo,omitempty"`
+	Subject         string          `json:"subject,omitempty"`
+	Attachments     []*Attachment   `json:"attachments,omitempty"`
+	TextBody        string          `json:"textBody,omitempty"`
+	HTMLBody        string          `json:"htmlBody,omitempty"`
+	Time            time.Time       `json:"time,omitempty"`
+	HistoryIncluded bool            `json:"historyIncluded,omitempty"`
+	SelfURI         string          `json:"selfUri,omitempty"`
+}
+
+// CreateEmailRequest describes the request to create an outbound Email Conversation
+//
+// See: https://developer.genesys.cloud/api/rest/v2/conversations/#post-api-v2-conversations-emails
+type CreateEmailRequest struct {
+	QueueID     string            `json:"queueId,omitempty"`
+	FlowID      string            `json:"flowId,omitempty"`
+	Provider    string            `json:"provider,omitempty"`
+	ToAddress   string            `json:"toAddress"`
+	ToName      string            `json:"toName,omitempty"`
+	FromAddress string            `json:"fromAddress,omitempty"`
+	FromName    string            `json:"fromName,omitempty"`
+	Subject     string            `json:"subject,omitempty"`
+	TextBody    string            `json:"textBody,omitempty"`
+	HTMLBody    string            `json:"htmlBody,omitempty"`
+	Attributes  map[string]string `json:"attributes,omitempty"`
+	Direction   string            `json:"direction"` // INBOUND, OUTBOUND
+}
+
+// Initialize initializes this from the given Client
+//   implements Initializable
+func (conversation *ConversationEmail) Initialize(parameters ...interface{}) error {
+	client, logger, id, err := parseParameters(conversation, parameters...)
+	if err != nil {
+		return err
+	}
+	if id != uuid.Nil {
+		if err := client.Get(NewURI("/conversations/emails/%s", id), &conversation); err != nil {
+			return err
+		}
+	}
+	conversation.Client = client
+	conversation.Logger = logger.Child("conversation", "conversation", "media", "email", "conversation", conversation.ID)
+	return nil
+}
+
+// CreateEmail creates an outbound Email Conversation
+//
+// If the request Direction is not given, OUTBOUND is used
+func (client *Client) CreateEmail(email *CreateEmailRequest) (*ConversationEmail, error) {
+	if email == nil {
+		return nil, errors.ArgumentMissing.With("email").WithStack()
+	}
+	if len(email.ToAddress) == 0 {
+		return nil, errors.ArgumentMissing.With("toAddress").WithStack()
+	}
+	if len(email.QueueID) == 0 && len(email.FlowID) == 0 {
+		return nil, errors.ArgumentMissing.With("queueId").WithStack()
+	}
+	payload := *email
+	if len(payload.Direction) == 0 {
+		payload.Direction = "OUTBOUND"
+	}
+	conversation := &ConversationEmail{}
+	if err := client.Post("/conversations/emails", payload, &conversation); err != nil {
+		return nil, err
+	}
+	if err := conversation.Initialize(client); err != nil {
+		return nil, err
+	}
+	return conversation, nil
+}
+
+// GetID gets the identifier of this
+//   implements Identifiable
+func (conversation ConversationEmail) GetID() uuid.UUID {
+	return conversation.ID
+}
+
+// String gets a string version
+//   implements the fmt.Stringer interface
+func (conversation ConversationEmail) String() string {
+	if len(conversation.Subject) > 0 {
+		return conversation.Subject
+	}
+	return conversation.ID.String()
+}
+
+// Disconnect disconnect an Identifiable from this
+//   implements Disconnecter
+func (conversation ConversationEmail) Disconnect(identifiable Identifiable) error {
+	return conversation.UpdateState(identifiable, "disconnected")
+}
+
+// UpdateState update the state of an identifiable in this
+//   implements StateUpdater
+func (conversation ConversationEmail) UpdateState(identifiable Identifiable, state string) error {
+	return conversation.Client.Patch(
+		NewURI("/conversations/emails/%s/participants/%s", conversation.ID, identifiable.GetID()),
+		MediaParticipantRequest{State: state},
+		nil,
+	)
+}
+
+// Transfer transfers a participant of this Conversation to the given Queue
+//   implements Transferrer
+func (conversation ConversationEmail) Transfer(identifiable Identifiable, queue Identifiable) error {
+	return conversation.Client.Post(
+		NewURI("/conversations/emails/%s/participants/%s/replace", conversation.ID, identifiable.GetID()),
+		struct {
+			ID string `json:"queueId"`
+		}{ID: queue.GetID().String()},
+		nil,
+	)
+}
+
+// Wrapup wraps up a Participant of this Conversation
+//   The wrapup code is validated against the codes assigned to the queue of the participant and can be given by name
+func (conversation ConversationEmail) Wrapup(identifiable Identifiable, wrapup *Wrapup) error {
+	wrapup, err := resolveWrapup(conversation.Client, conversation.Participants, identifiable, wrapup)
+	if err != nil {
+		return err
+	}
+	return conversation.Client.Patch(
+		NewURI("/conversations/emails/%s/participants/%s", conversation.ID, identifiable.GetID()),
+		MediaParticipantRequest{Wrapup: wrapup},
+		nil,
+	)
+}
+
+// FetchMessages fetches the message thread of this Email Conversation
+//
+// The messages are previews as returned by GENESYS Cloud (bodies may be missing or truncated),
+// use FetchMessage to get a message in full (with bodies and attachments)
+func (conversation ConversationEmail) FetchMessages() ([]*EmailMessage, error) {
+	response := struct {
+		Entities []*EmailMessage `json:"entities"`
+	}{}
+	if err := conversation.Client.Get(NewURI("/conversations/emails/%s/messages", conversation.ID), &response); err != nil {
+		return nil, err
+	}
+	return response.Entities, nil
+}
+
+// FetchMessage fetches a message of this Email Conversation
+func (conversation ConversationEmail) FetchMessage(messageID string) (*EmailMessage, error) {
+	message := &EmailMessage{}
+	if err := conversation.Client.Get(NewURI("/conversations/emails/%s/messages/%s", conversation.ID, messageID), &message); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+// FetchDraft fetches the current draft of this Email Conversation
+func (conversation ConversationEmail) FetchDraft() (*EmailMessage, error) {
+	draft := &EmailMessage{}
+	if err := conversation.Client.Get(NewURI("/conversations/emails/%s/messages/draft", conversation.ID), &draft); err != nil {
+		return nil, err
+	}
+	return draft, nil
+}
+
+// UpdateDraft replaces the draft of this Email Conversation
+//
+// The draft's DraftAttachments are updated from the response
+func (conversation *ConversationEmail) UpdateDraft(draft *EmailMessage) (*EmailMessage, error) {
+	if draft == nil {
+		return nil, errors.ArgumentMissing.With("draft").WithStack()
+	}
+	updated := &EmailMessage{}
+	if err := conversation.Client.Put(NewURI("/conversations/emails/%s/messages/draft", conversation.ID), draft, &updated); err != nil {
+		return nil, err
+	}
+	conversation.DraftAttachments = updated.Attachments
+	return updated, nil
+}
+
+// SendMessage sends the given message in this Email Conversation
+//
+// Draft attachments are sent along with the message
+func (conversation *ConversationEmail) SendMessage(message *EmailMessage) (*EmailMessage, error) {
+	if message == nil {
+		return nil, errors.ArgumentMissing.With("message").WithStack()
+	}
+	if len(message.To) == 0 {
+		return nil, errors.ArgumentMissing.With("to").WithStack()
+	}
+	sent := &EmailMessage{}
+	if err := conversation.Client.Post(NewURI("/conversations/emails/%s/messages", conversation.ID), message, &sent); err != nil {
+		return nil, err
+	}
+	conversation.DraftAttachments = []*Attachment{}
+	return sent, nil
+}
+
+// Reply replies to the given message with the draft's attachments
+//
+// The reply is sent to the sender of the message (or its ReplyTo address)
+func (conversation *ConversationEmail) Reply(message *EmailMessage, textBody, htmlBody string) (*EmailMessage, error) {
+	if message == nil {
+		return nil, errors.ArgumentMissing.With("message").WithStack()
+	}
+	to := message.From
+	if message.ReplyTo != nil {
+		to = message.ReplyTo
+	}
+	if to == nil {
+		return nil, errors.ArgumentMissing.With("from").WithStack()
+	}
+	return conversation.SendMessage(&EmailMessage{
+		To:          []*EmailAddress{to},
+		From:        firstEmailAddress(message.To),
+		Subject:     prefixSubject("Re: ", message.Subject),
+		TextBody:    textBody,
+		HTMLBody:    htmlBody,
+		Attachments: conversation.DraftAttachments,
+	})
+}
+
+// Forward forwards the given message and its attachments to the given recipients
+//
+// The attachments of the original message are copied into the draft first
+func (conversation *ConversationEmail) Forward(message *EmailMessage, to []*EmailAddress, textBody, htmlBody string) (*EmailMessage, error) {
+	if message == nil {
+		return nil, errors.ArgumentMissing.With("message").WithStack()
+	}
+	if len(to) == 0 {
+		return nil, errors.ArgumentMissing.With("to").WithStack()
+	}
+	if len(message.Attachments) > 0 {
+		if err := conversation.CopyAttachments(message.ID, message.Attachments...); err != nil {
+			return nil, err
+		}
+	}
+	return conversation.SendMessage(&EmailMessage{
+		To:          to,
+		From:        firstEmailAddress(message.To),
+		Subject:     prefixSubject("Fwd: ", message.Subject),
+		TextBody:    textBody,
+		HTMLBody:    htmlBody,
+		Attachments: conversation.DraftAttachments,
+	})
+}
+
+// CopyAttachments copies attachments of a message of this conversation into the draft
+func (conversation *ConversationEmail) CopyAttachments(messageID string, attachments ...*Attachment) error {
+	draft := &EmailMessage{}
+	if err := conversation.Client.Post(
+		NewURI("/conversations/emails/%s/messages/draft/attachments/copy", conversation.ID),
+		struct {
+			SourceMessageID string        `json:"sourceMessageId"`
+			Attachments     []*Attachment `json:"attachments"`
+		}{
+			SourceMessageID: messageID,
+			Attachments:     attachments,
+		},
+		&draft,
+	); err != nil {
+		return err
+	}
+	conversation.DraftAttachments = draft.Attachments
+	return nil
+}
+
+// UploadAttachment uploads an attachment into the draft of this Email Conversation
+func (conversation *ConversationEmail) UploadAttachment(filename, contentType string, reader io.Reader) (*Attachment, error) {
+	if reader == nil {
+		return nil, errors.ArgumentMissing.With("reader").WithStack()
+	}
+	content, err := request.ContentFromReader(reader, contentType)
+	if err != nil {
+		return nil, err
+	}
+	attachment := &Attachment{}
+	if err := conversation.Client.SendRequest(
+		NewURI("/conversations/emails/%s/messages/draft/attachments", conversation.ID),
+		&request.Options{
+			Method:     http.MethodPost,
+			Payload:    map[string]string{">file": filename},
+			Attachment: content.Reader(),
+		},
+		&attachment,
+	); err != nil {
+		return nil, err
+	}
+	conversation.DraftAttachments = append(conversation.DraftAttachments, attachment)
+	return attachment, nil
+}
+
+// DeleteAttachment deletes an attachment from the draft of this Email Conversation
+func (conversation *ConversationEmail) DeleteAttachment(attachment *Attachment) error {
+	if attachment == nil {
+		return errors.ArgumentMissing.With("attachment").WithStack()
+	}
+	if err := conversation.Client.Delete(
+		NewURI("/conversations/emails/%s/messages/draft/attachments/%s", conversation.ID, attachment.AttachmentID),
+		nil,
+	); err != nil {
+		return err
+	}
+	for i, draftAttachment := range conversation.DraftAttachments {
+		if draftAttachment.AttachmentID == attachment.AttachmentID {
+			conversation.DraftAttachments = append(conversation.DraftAttachments[:i], conversation.DraftAttachments[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// MarkAsSpam marks this Email Conversation as spam
+func (conversation *ConversationEmail) MarkAsSpam() error {
+	if err := conversation.Client.Patch(
+		NewURI("/conversations/emails/%s", conversation.ID),
+		struct {
+			Spam bool `json:"spam"`
+		}{Spam: true},
+		nil,
+	); err != nil {
+		return err
+	}
+	conversation.Spam = true
+	return nil
+}
+
+// Download downloads the content of this Attachment
+//
+// The whole content is read in memory, see Client.Download
+func (attachment Attachment) Download(client *Client) (*request.ContentReader, error) {
+	if client == nil {
+		return nil, errors.ArgumentMissing.With("Client").WithStack()
+	}
+	if len(attachment.ContentURI) == 0 {
+		return nil, errors.ArgumentMissing.With("contentUri").WithStack()
+	}
+	return client.Download(URI(attachment.ContentURI))
+}
+
+// String gets a string version
+//   implements the fmt.Stringer interface
+func (attachment Attachment) String() string {
+	if len(attachment.Name) > 0 {
+		return attachment.Name
+	}
+	return attachment.AttachmentID
+}
+
+func firstEmailAddress(addresses []*EmailAddress) *EmailAddress {
+	if len(addresses) > 0 {
+		return addresses[0]
+	}
+	return nil
+}
+
+func prefixSubject(prefix, subject string) string {
+	if strings.HasPrefix(strings.ToLower(subject), strings.ToLower(prefix)) {
+		return subject
+	}
+	return prefix + subject
+}
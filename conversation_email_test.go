@@ -0,0 +1,263 @@
+package gcloudcx_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gildas/go-errors"
+	"github.com/gildas/go-gcloudcx"
+	"github.com/gildas/go-logger"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+type ConversationEmailSuite struct {
+	suite.Suite
+	Name   string
+	Logger *logger.Logger
+	Start  time.Time
+
+	ConversationID uuid.UUID
+	QueueID        uuid.UUID
+	Recorder       *RequestRecorder
+	Server         *httptest.Server
+	Client         *gcloudcx.Client
+}
+
+func TestConversationEmailSuite(t *testing.T) {
+	suite.Run(t, new(ConversationEmailSuite))
+}
+
+func (suite *ConversationEmailSuite) TestCanCreateEmail() {
+	request := &gcloudcx.CreateEmailRequest{
+		QueueID:   suite.QueueID.String(),
+		ToAddress: "john.doe@acme.com",
+		Subject:   "Your order",
+		TextBody:  "Your order has shipped",
+	}
+	email, err := suite.Client.CreateEmail(request)
+	suite.Require().Nilf(err, "Failed to create email. %s", err)
+	suite.Assert().Empty(request.Direction, "The request should not be modified")
+	suite.Assert().Equal(suite.ConversationID, email.ID)
+	suite.Assert().Equal("Your order", email.String())
+	suite.Assert().Equal([]string{
+		"POST /api/v2/conversations/emails",
+		"GET /api/v2/conversations/emails/" + suite.ConversationID.String(),
+	}, suite.Recorder.Requests())
+	suite.Assert().JSONEq(fmt.Sprintf(`{"queueId":"%s","toAddress":"john.doe@acme.com","subject":"Your order","textBody":"Your order has shipped","direction":"OUTBOUND"}`, suite.QueueID), suite.Recorder.Bodies()[0])
+}
+
+func (suite *ConversationEmailSuite) TestShouldNotCreateEmailWithoutQueueOrFlow() {
+	_, err := suite.Client.CreateEmail(&gcloudcx.CreateEmailRequest{ToAddress: "john.doe@acme.com"})
+	suite.Require().NotNil(err, "Email should not have been created")
+	suite.Assert().True(errors.Is(err, errors.ArgumentMissing), "Error should be an ArgumentMissing")
+	suite.Assert().Empty(suite.Recorder.Requests())
+}
+
+func (suite *ConversationEmailSuite) TestCanFetchMessages() {
+	conversation := suite.NewConversation()
+	messages, err := conversation.FetchMessages()
+	suite.Require().Nilf(err, "Failed to fetch messages. %s", err)
+	suite.Require().Len(messages, 2)
+	suite.Assert().Equal("msg-1", messages[0].ID)
+	suite.Assert().Equal([]string{"GET /api/v2/conversations/emails/" + suite.ConversationID.String() + "/messages"}, suite.Recorder.Requests(), "The messages should be fetched in one request")
+
+	message, err := conversation.FetchMessage("msg-1")
+	suite.Require().Nilf(err, "Failed to fetch message. %s", err)
+	suite.Assert().Equal("Hello", message.TextBody)
+	suite.Assert().Equal("GET /api/v2/conversations/emails/"+suite.ConversationID.String()+"/messages/msg-1", suite.Recorder.Requests()[1])
+}
+
+func (suite *ConversationEmailSuite) TestCanManageDraft() {
+	conversation := suite.NewConversation()
+	draft, err := conversation.FetchDraft()
+	suite.Require().Nilf(err, "Failed to fetch draft. %s", err)
+	suite.Assert().Equal("draft", draft.ID)
+	draft.TextBody = "Almost done"
+	updated, err := conversation.UpdateDraft(draft)
+	suite.Require().Nilf(err, "Failed to update draft. %s", err)
+	suite.Require().Len(conversation.DraftAttachments, 1)
+	suite.Assert().Equal("att-1", conversation.DraftAttachments[0].AttachmentID)
+	suite.Assert().Equal(updated.Attachments, conversation.DraftAttachments)
+	draftPath := "/api/v2/conversations/emails/" + suite.ConversationID.String() + "/messages/draft"
+	suite.Assert().Equal([]string{"GET " + draftPath, "PUT " + draftPath}, suite.Recorder.Requests())
+	suite.Assert().Contains(suite.Recorder.Bodies()[1], `"textBody":"Almost done"`)
+}
+
+func (suite *ConversationEmailSuite) TestCanReply() {
+	conversation := suite.NewConversation()
+	conversation.DraftAttachments = []*gcloudcx.Attachment{{AttachmentID: "att-1", Name: "invoice.pdf"}}
+	message := &gcloudcx.EmailMessage{
+		ID:      "msg-1",
+		To:      []*gcloudcx.EmailAddress{{Email: "support@acme.com"}},
+		From:    &gcloudcx.EmailAddress{Email: "john.doe@acme.com"},
+		ReplyTo: &gcloudcx.EmailAddress{Email: "john@home.com"},
+		Subject: "RE: Your order",
+	}
+	_, err := conversation.Reply(message, "Thanks", "")
+	suite.Require().Nilf(err, "Failed to reply. %s", err)
+	suite.Assert().Equal([]string{"POST /api/v2/conversations/emails/" + suite.ConversationID.String() + "/messages"}, suite.Recorder.Requests())
+	body := suite.Recorder.Bodies()[0]
+	suite.Assert().Contains(body, `"to":[{"email":"john@home.com"}]`, "The reply should go to the ReplyTo address")
+	suite.Assert().Contains(body, `"from":{"email":"support@acme.com"}`)
+	suite.Assert().Contains(body, `"subject":"RE: Your order"`, "The subject should not be prefixed twice")
+	suite.Assert().Contains(body, `"attachmentId":"att-1"`, "The draft attachments should be sent")
+	suite.Assert().Empty(conversation.DraftAttachments, "The draft attachments should be cleared once sent")
+}
+
+func (suite *ConversationEmailSuite) TestCanForward() {
+	conversation := suite.NewConversation()
+	message := &gcloudcx.EmailMessage{
+		ID:          "msg-1",
+		To:          []*gcloudcx.EmailAddress{{Email: "support@acme.com"}},
+		From:        &gcloudcx.EmailAddress{Email: "john.doe@acme.com"},
+		Subject:     "Your order",
+		Attachments: []*gcloudcx.Attachment{{AttachmentID: "att-9", Name: "receipt.pdf"}},
+	}
+	_, err := conversation.Forward(message, []*gcloudcx.EmailAddress{{Email: "billing@acme.com"}}, "FYI", "")
+	suite.Require().Nilf(err, "Failed to forward. %s", err)
+	suite.Assert().Equal([]string{
+		"POST /api/v2/conversations/emails/" + suite.ConversationID.String() + "/messages/draft/attachments/copy",
+		"POST /api/v2/conversations/emails/" + suite.ConversationID.String() + "/messages",
+	}, suite.Recorder.Requests())
+	bodies := suite.Recorder.Bodies()
+	suite.Assert().Contains(bodies[0], `"sourceMessageId":"msg-1"`)
+	suite.Assert().Contains(bodies[0], `"attachmentId":"att-9"`)
+	suite.Assert().Contains(bodies[1], `"subject":"Fwd: Your order"`)
+	suite.Assert().Contains(bodies[1], `"attachmentId":"att-9"`, "The copied attachments should be forwarded")
+
+	_, err = conversation.Forward(message, nil, "FYI", "")
+	suite.Assert().True(errors.Is(err, errors.ArgumentMissing), "Error should be an ArgumentMissing")
+}
+
+func (suite *ConversationEmailSuite) TestCanManageAttachments() {
+	conversation := suite.NewConversation()
+	attachment, err := conversation.UploadAttachment("notes.txt", "text/plain", strings.NewReader("Some notes"))
+	suite.Require().Nilf(err, "Failed to upload attachment. %s", err)
+	suite.Assert().Equal("att-2", attachment.AttachmentID)
+	suite.Require().Len(conversation.DraftAttachments, 1)
+	suite.Assert().Contains(suite.Recorder.Bodies()[0], "Some notes", "The attachment content should be uploaded")
+
+	err = conversation.DeleteAttachment(attachment)
+	suite.Require().Nilf(err, "Failed to delete attachment. %s", err)
+	suite.Assert().Empty(conversation.DraftAttachments)
+	suite.Assert().Equal([]string{
+		"POST /api/v2/conversations/emails/" + suite.ConversationID.String() + "/messages/draft/attachments",
+		"DELETE /api/v2/conversations/emails/" + suite.ConversationID.String() + "/messages/draft/attachments/att-2",
+	}, suite.Recorder.Requests())
+}
+
+func (suite *ConversationEmailSuite) TestCanDownloadAttachment() {
+	attachment := gcloudcx.Attachment{AttachmentID: "att-1", ContentURI: suite.Server.URL + "/downloads/att-1"}
+	content, err := attachment.Download(suite.Client)
+	suite.Require().Nilf(err, "Failed to download attachment. %s", err)
+	suite.Assert().Equal("application/pdf", content.Type)
+	data, err := ioutil.ReadAll(content)
+	suite.Require().Nil(err)
+	suite.Assert().Equal("%PDF-1.4", string(data))
+	suite.Assert().Equal([]string{"GET /downloads/att-1"}, suite.Recorder.Requests())
+
+	_, err = gcloudcx.Attachment{AttachmentID: "att-1"}.Download(suite.Client)
+	suite.Assert().True(errors.Is(err, errors.ArgumentMissing), "Error should be an ArgumentMissing")
+}
+
+func (suite *ConversationEmailSuite) TestCanMarkAsSpam() {
+	conversation := suite.NewConversation()
+	err := conversation.MarkAsSpam()
+	suite.Require().Nilf(err, "Failed to mark as spam. %s", err)
+	suite.Assert().True(conversation.Spam)
+	suite.Assert().Equal([]string{"PATCH /api/v2/conversations/emails/" + suite.ConversationID.String()}, suite.Recorder.Requests())
+	suite.Assert().Equal(`{"spam":true}`, suite.Recorder.Bodies()[0])
+}
+
+func (suite *ConversationEmailSuite) TestCanTransferAndDisconnect() {
+	conversation := suite.NewConversation()
+	participant := gcloudcx.Participant{ID: uuid.New()}
+	err := conversation.Transfer(participant, gcloudcx.Queue{ID: suite.QueueID})
+	suite.Require().Nilf(err, "Failed to transfer. %s", err)
+	err = conversation.Disconnect(participant)
+	suite.Require().Nilf(err, "Failed to disconnect. %s", err)
+	participantPath := "/api/v2/conversations/emails/" + suite.ConversationID.String() + "/participants/" + participant.ID.String()
+	suite.Assert().Equal([]string{"POST " + participantPath + "/replace", "PATCH " + participantPath}, suite.Recorder.Requests())
+	suite.Assert().Equal([]string{fmt.Sprintf(`{"queueId":"%s"}`, suite.QueueID), `{"state":"disconnected"}`}, suite.Recorder.Bodies())
+}
+
+// Suite Tools
+
+func (suite *ConversationEmailSuite) NewConversation() *gcloudcx.ConversationEmail {
+	return &gcloudcx.ConversationEmail{ID: suite.ConversationID, Client: suite.Client}
+}
+
+func (suite *ConversationEmailSuite) SetupSuite() {
+	suite.Name = strings.TrimSuffix(reflect.TypeOf(*suite).Name(), "Suite")
+	suite.Logger = logger.Create("test",
+		&logger.FileStream{
+			Path:        fmt.Sprintf("./log/test-%s.log", strings.ToLower(suite.Name)),
+			Unbuffered:  true,
+			FilterLevel: logger.TRACE,
+		},
+	).Child("test", "test")
+	suite.Logger.Infof("Suite Start: %s %s", suite.Name, strings.Repeat("=", 80-14-len(suite.Name)))
+	suite.ConversationID = uuid.MustParse("4d4d0d8a-0b5c-4c6e-8f27-5f4c6a3e9a08")
+	suite.QueueID = uuid.MustParse("5e5e0d8a-0b5c-4c6e-8f27-5f4c6a3e9a09")
+	suite.Recorder = NewRequestRecorder(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		conversationPath := "/api/v2/conversations/emails/" + suite.ConversationID.String()
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v2/conversations/emails":
+			_, _ = w.Write([]byte(fmt.Sprintf(`{"id": "%s"}`, suite.ConversationID)))
+		case r.Method == http.MethodGet && r.URL.Path == conversationPath:
+			_, _ = w.Write([]byte(fmt.Sprintf(`{"id": "%s", "state": "connected", "subject": "Your order"}`, suite.ConversationID)))
+		case r.Method == http.MethodGet && r.URL.Path == conversationPath+"/messages":
+			_, _ = w.Write([]byte(`{"entities": [{"id": "msg-1", "subject": "Your order"}, {"id": "msg-2", "subject": "RE: Your order"}]}`))
+		case r.Method == http.MethodGet && r.URL.Path == conversationPath+"/messages/msg-1":
+			_, _ = w.Write([]byte(`{"id": "msg-1", "subject": "Your order", "textBody": "Hello", "from": {"email": "john.doe@acme.com"}, "to": [{"email": "support@acme.com"}]}`))
+		case r.URL.Path == conversationPath+"/messages/draft":
+			_, _ = w.Write([]byte(`{"id": "draft", "attachments": [{"attachmentId": "att-1", "name": "invoice.pdf"}]}`))
+		case r.Method == http.MethodPost && r.URL.Path == conversationPath+"/messages/draft/attachments/copy":
+			_, _ = w.Write([]byte(`{"id": "draft", "attachments": [{"attachmentId": "att-9", "name": "receipt.pdf"}]}`))
+		case r.Method == http.MethodPost && r.URL.Path == conversationPath+"/messages/draft/attachments":
+			_, _ = w.Write([]byte(`{"attachmentId": "att-2", "name": "notes.txt", "contentType": "text/plain"}`))
+		case r.Method == http.MethodPost && r.URL.Path == conversationPath+"/messages":
+			_, _ = w.Write([]byte(`{"id": "msg-3"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/downloads/att-1":
+			w.Header().Set("Content-Type", "application/pdf")
+			_, _ = w.Write([]byte("%PDF-1.4"))
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			_, _ = w.Write([]byte(`{}`))
+		}
+	})
+	suite.Server = httptest.NewServer(suite.Recorder)
+	suite.Client = CreateTestClient(suite.Server.URL, suite.Logger)
+}
+
+func (suite *ConversationEmailSuite) TearDownSuite() {
+	suite.Server.Close()
+	if suite.T().Failed() {
+		suite.Logger.Warnf("At least one test failed, we are not cleaning")
+		suite.T().Log("At least one test failed, we are not cleaning")
+	} else {
+		suite.Logger.Infof("All tests succeeded, we are cleaning")
+	}
+	suite.Logger.Infof("Suite End: %s %s", suite.Name, strings.Repeat("=", 80-12-len(suite.Name)))
+	suite.Logger.Close()
+}
+
+func (suite *ConversationEmailSuite) BeforeTest(suiteName, testName string) {
+	suite.Logger.Infof("Test Start: %s %s", testName, strings.Repeat("-", 80-13-len(testName)))
+	suite.Start = time.Now()
+	suite.Recorder.Reset()
+}
+
+func (suite *ConversationEmailSuite) AfterTest(suiteName, testName string) {
+	duration := time.Since(suite.Start)
+	suite.Logger.Record("duration", duration.String()).Infof("Test End: %s %s", testName, strings.Repeat("-", 80-11-len(testName)))
+}
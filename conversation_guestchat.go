@@ -1,9 +1,12 @@
 package gcloudcx
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gildas/go-core"
@@ -20,14 +23,33 @@ type ConversationGuestChat struct {
 	SelfURI       string                    `json:"selfUri,omitempty"`
 	Target        *RoutingTarget            `json:"-"`
 	Guest         *ChatMember               `json:"member,omitempty"`
-	Members       map[uuid.UUID]*ChatMember `json:"-"`
+	Members       map[uuid.UUID]*ChatMember `json:"-"` // Deprecated: use Roster, this map is not safe for concurrent use and is not updated by the member topics
+	Roster        *ChatMemberRoster         `json:"-"`
 	JWT           string                    `json:"jwt,omitempty"`
 	EventStream   string                    `json:"eventStreamUri,omitempty"`
 	Socket        *websocket.Conn           `json:"-"`
 	TopicReceived chan NotificationTopic    `json:"-"`
 	LogHeartbeat  bool                      `json:"logHeartbeat"`
+	MaxRedials    int                       `json:"-"` // Number of times the websocket is redialed after it dropped, Default: 5
+	RedialDelay   time.Duration             `json:"-"` // Delay before the first redial, doubled after each failure, Default: 1s
+	HistoryWindow time.Duration             `json:"-"` // How far back missed messages are fetched from the history after a redial, Default: 10 minutes
 	Client        *Client                   `json:"-"`
 	Logger        *logger.Logger            `json:"-"`
+	delivered     map[uuid.UUID]time.Time
+	prunedAt      time.Time
+	closed        bool
+	mutex         *sync.Mutex
+}
+
+// GuestChatMessage describes a message of the history of a Guest Chat
+type GuestChatMessage struct {
+	ID        uuid.UUID   `json:"id"`
+	Name      string      `json:"name,omitempty"`
+	Sender    *ChatMember `json:"sender,omitempty"`
+	Body      string      `json:"body,omitempty"`
+	BodyType  string      `json:"bodyType,omitempty"` // standard, notice, member-join, member-leave, media-request
+	Timestamp time.Time   `json:"timestamp"`
+	SelfURI   string      `json:"selfUri,omitempty"`
 }
 
 // Initialize initializes this from the given Client
@@ -84,10 +106,15 @@ func (conversation *ConversationGuestChat) Initialize(parameters ...interface{})
 	conversation.Guest.Role = guest.Role
 	conversation.Guest.State = guest.State
 	conversation.Guest.Custom = guest.Custom
-	conversation.Members = map[uuid.UUID]*ChatMember{}
-	conversation.Members[conversation.Guest.ID] = conversation.Guest
+	conversation.Members = map[uuid.UUID]*ChatMember{conversation.Guest.ID: conversation.Guest}
+	conversation.Roster = NewChatMemberRoster(conversation.Guest)
 	conversation.TopicReceived = make(chan NotificationTopic)
 	conversation.LogHeartbeat = core.GetEnvAsBool("PURECLOUD_LOG_HEARTBEAT", false)
+	conversation.MaxRedials = 5
+	conversation.RedialDelay = 1 * time.Second
+	conversation.HistoryWindow = 10 * time.Minute
+	conversation.delivered = map[uuid.UUID]time.Time{}
+	conversation.mutex = &sync.Mutex{}
 	return
 }
 
@@ -106,6 +133,7 @@ func (conversation ConversationGuestChat) String() string {
 // Connect connects a Guest Chat to its websocket and starts its message loop
 //   If the websocket was already connected, nothing happens
 //   If the environment variable PURECLOUD_LOG_HEARTBEAT is set to true, the Heartbeat topic will be logged
+//   If the websocket drops, it is redialed with the same JWT and the messages missed in the meantime are fetched from the history
 func (conversation *ConversationGuestChat) Connect() (err error) {
 	if conversation.Socket != nil {
 		return
@@ -124,9 +152,13 @@ func (conversation *ConversationGuestChat) Connect() (err error) {
 func (conversation *ConversationGuestChat) Close() (err error) {
 	log := conversation.Logger.Scope("close")
 
-	if conversation.Socket != nil {
+	conversation.lock()
+	conversation.closed = true
+	socket := conversation.Socket
+	conversation.unlock()
+	if socket != nil {
 		log.Debugf("Disconnecting websocket")
-		if err = conversation.Socket.Close(); err != nil {
+		if err = socket.Close(); err != nil {
 			log.Errorf("Failed while close websocket", err)
 			return errors.WithMessage(err, "Failed while closing websocket")
 		}
@@ -143,6 +175,36 @@ func (conversation *ConversationGuestChat) Close() (err error) {
 	return
 }
 
+// FetchMessages fetches the message history of this Guest Chat, oldest first
+func (conversation *ConversationGuestChat) FetchMessages() ([]*GuestChatMessage, error) {
+	messages := []*GuestChatMessage{}
+	query := url.Values{}
+	query.Set("sortOrder", "ascending")
+	query.Set("maxResults", "100")
+	for {
+		response := struct {
+			Entities []*GuestChatMessage `json:"entities"`
+			Next     string              `json:"next"`
+		}{}
+		err := conversation.Client.SendRequest(
+			NewURI("/webchat/guest/conversations/%s/messages?%s", conversation.ID, query.Encode()),
+			&request.Options{
+				Authorization: "bearer " + conversation.JWT,
+			},
+			&response,
+		)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, response.Entities...)
+		if len(response.Next) == 0 || len(response.Entities) == 0 {
+			break
+		}
+		query.Set("after", response.Entities[len(response.Entities)-1].ID.String())
+	}
+	return messages, nil
+}
+
 func (conversation *ConversationGuestChat) messageLoop() {
 	log := conversation.Logger.Scope("receive")
 
@@ -152,11 +214,16 @@ func (conversation *ConversationGuestChat) messageLoop() {
 		var body []byte
 
 		if _, body, err = conversation.Socket.ReadMessage(); err != nil {
-			if strings.Contains(err.Error(), "use of closed network connection") {
+			if conversation.isClosed() || strings.Contains(err.Error(), "use of closed network connection") {
 				log.Infof("Websocket was closed, stopping receive handler")
 				return
 			}
 			log.Errorf("Failed to read incoming message", err)
+			if err = conversation.redial(); err != nil {
+				log.Errorf("Failed to redial the websocket, stopping receive handler", err)
+				return
+			}
+			conversation.deliverMissedMessages()
 			continue
 		}
 
@@ -167,24 +234,161 @@ func (conversation *ConversationGuestChat) messageLoop() {
 			log.Warnf("%s, Body size: %d, Content: %s", err.Error(), len(body), string(body))
 			continue
 		}
-		switch topic.(type) {
+		switch message := topic.(type) {
 		case *MetadataTopic:
 			if conversation.LogHeartbeat {
 				log.Tracef("Request %d bytes: %s", len(body), string(body))
 			}
+		case *ConversationGuestChatMemberTopic:
+			log.Tracef("Request %d bytes: %s", len(body), string(body))
+			conversation.updateMember(message)
+		case *ConversationGuestChatMessageTopic:
+			log.Tracef("Request %d bytes: %s", len(body), string(body))
+			if !conversation.markDelivered(message.ID, message.TimeStamp) {
+				log.Debugf("Message %s was already delivered, ignoring", message.ID)
+				continue
+			}
 		default:
 			log.Tracef("Request %d bytes: %s", len(body), string(body))
 		}
-		// Make a fake channel object so Notification Topics can be sent through
-		topic.Send(&NotificationChannel{
-			ID:            conversation.ID,
-			LogHeartbeat:  conversation.LogHeartbeat,
-			Logger:        conversation.Logger,
-			Client:        conversation.Client,
-			Socket:        conversation.Socket,
-			TopicReceived: conversation.TopicReceived,
+		conversation.send(topic)
+	}
+}
+
+// send sends a topic to TopicReceived
+func (conversation *ConversationGuestChat) send(topic NotificationTopic) {
+	// Make a fake channel object so Notification Topics can be sent through
+	topic.Send(&NotificationChannel{
+		ID:            conversation.ID,
+		LogHeartbeat:  conversation.LogHeartbeat,
+		Logger:        conversation.Logger,
+		Client:        conversation.Client,
+		Socket:        conversation.Socket,
+		TopicReceived: conversation.TopicReceived,
+	})
+}
+
+// redial dials the websocket again with the current JWT
+func (conversation *ConversationGuestChat) redial() error {
+	log := conversation.Logger.Scope("redial")
+	delay := conversation.RedialDelay
+	var err error
+
+	for attempt := 1; attempt <= conversation.MaxRedials; attempt++ {
+		time.Sleep(delay)
+		if conversation.isClosed() {
+			return errors.NotConnected.With("Conversation").WithStack()
+		}
+		var socket *websocket.Conn
+		if socket, _, err = websocket.DefaultDialer.Dial(conversation.EventStream, nil); err == nil {
+			conversation.lock()
+			if conversation.closed {
+				conversation.unlock()
+				_ = socket.Close()
+				return errors.NotConnected.With("Conversation").WithStack()
+			}
+			_ = conversation.Socket.Close()
+			conversation.Socket = socket
+			conversation.unlock()
+			log.Infof("Redialed websocket after %d attempt(s)", attempt)
+			return nil
+		}
+		log.Warnf("Failed to redial websocket (attempt %d/%d): %s", attempt, conversation.MaxRedials, err)
+		delay *= 2
+	}
+	return errors.NotConnected.Wrap(err)
+}
+
+// deliverMissedMessages fetches the message history and sends the messages that were not delivered yet
+//
+// Only the messages of the HistoryWindow are considered, older messages are not remembered as delivered
+func (conversation *ConversationGuestChat) deliverMissedMessages() {
+	log := conversation.Logger.Scope("history")
+	messages, err := conversation.FetchMessages()
+	if err != nil {
+		log.Errorf("Failed to fetch the message history", err)
+		return
+	}
+	missed := 0
+	since := time.Now().UTC().Add(-conversation.historyWindow())
+	for _, message := range messages {
+		if message.Timestamp.Before(since) {
+			continue
+		}
+		if !conversation.markDelivered(message.ID, message.Timestamp) {
+			continue
+		}
+		missed++
+		conversation.send(&ConversationGuestChatMessageTopic{
+			ID:           message.ID,
+			Name:         ConversationGuestChatMessageTopic{}.TopicFor(conversation),
+			Conversation: &ConversationGuestChat{ID: conversation.ID},
+			Sender:       message.Sender,
+			Type:         "message",
+			Body:         message.Body,
+			BodyType:     message.BodyType,
+			TimeStamp:    message.Timestamp,
 		})
 	}
+	log.Infof("Delivered %d missed message(s)", missed)
+}
+
+// markDelivered marks a message as delivered, returns false if it was already delivered
+//
+// The messages older than the HistoryWindow are forgotten
+func (conversation *ConversationGuestChat) markDelivered(id uuid.UUID, timestamp time.Time) bool {
+	if id == uuid.Nil {
+		return true
+	}
+	now := time.Now().UTC()
+	if timestamp.IsZero() {
+		timestamp = now
+	}
+	conversation.lock()
+	defer conversation.unlock()
+	if conversation.delivered == nil {
+		conversation.delivered = map[uuid.UUID]time.Time{}
+	}
+	if window := conversation.historyWindow(); now.Sub(conversation.prunedAt) > window {
+		for deliveredID, deliveredAt := range conversation.delivered {
+			if now.Sub(deliveredAt) > window {
+				delete(conversation.delivered, deliveredID)
+			}
+		}
+		conversation.prunedAt = now
+	}
+	if _, found := conversation.delivered[id]; found {
+		return false
+	}
+	conversation.delivered[id] = timestamp
+	return true
+}
+
+func (conversation *ConversationGuestChat) historyWindow() time.Duration {
+	if conversation.HistoryWindow <= 0 {
+		return 10 * time.Minute
+	}
+	return conversation.HistoryWindow
+}
+
+// lock locks this conversation, if it was initialized
+func (conversation *ConversationGuestChat) lock() {
+	if conversation.mutex != nil {
+		conversation.mutex.Lock()
+	}
+}
+
+// unlock unlocks this conversation, if it was initialized
+func (conversation *ConversationGuestChat) unlock() {
+	if conversation.mutex != nil {
+		conversation.mutex.Unlock()
+	}
+}
+
+func (conversation *ConversationGuestChat) isClosed() bool {
+	conversation.lock()
+	defer conversation.unlock()
+	return conversation.closed
 }
 
 func (conversation *ConversationGuestChat) notificationTopicFromJSON(payload []byte) (NotificationTopic, error) {
@@ -221,9 +425,31 @@ func (conversation *ConversationGuestChat) notificationTopicFromJSON(payload []b
 
 // GetMember fetches the given member of this Conversation (caches the member)
 func (conversation *ConversationGuestChat) GetMember(identifiable Identifiable) (*ChatMember, error) {
-	if member, ok := conversation.Members[identifiable.GetID()]; ok {
+	if member, ok := conversation.Roster.Get(identifiable); ok {
 		return member, nil
 	}
+	member, err := conversation.fetchMember(identifiable)
+	if err != nil {
+		return nil, err
+	}
+	conversation.Roster.Set(member)
+	if conversation.Members != nil {
+		conversation.Members[member.ID] = member
+	}
+	return member, nil
+}
+
+// WaitForAgent waits until an agent joins this Conversation
+//
+// If an agent is already connected, it is returned immediately
+func (conversation *ConversationGuestChat) WaitForAgent(timeout time.Duration) (*ChatMember, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return conversation.Roster.WaitForAgent(ctx)
+}
+
+// fetchMember fetches the given member from GCloud
+func (conversation *ConversationGuestChat) fetchMember(identifiable Identifiable) (*ChatMember, error) {
 	member := &ChatMember{}
 	err := conversation.Client.SendRequest(
 		NewURI("/webchat/guest/conversations/%s/members/%s", conversation.ID, identifiable.GetID()),
@@ -236,10 +462,55 @@ func (conversation *ConversationGuestChat) GetMember(identifiable Identifiable)
 		return nil, err
 	}
 	conversation.Logger.Scope("getmember").Debugf("Response: %+v", member)
-	conversation.Members[member.ID] = member
 	return member, nil
 }
 
+// updateMember updates the roster with a member topic
+//
+// If the topic does not tell the role of a new member, the member is fetched from GCloud in the background
+// so the message loop is not blocked, the roster is completed when the member is fetched
+func (conversation *ConversationGuestChat) updateMember(topic *ConversationGuestChatMemberTopic) {
+	log := conversation.Logger.Scope("member")
+	member, err := conversation.Roster.Update(topic)
+	if err != nil {
+		log.Warnf("Failed to update member: %s", err)
+		return
+	}
+	if len(member.Role) == 0 {
+		go conversation.completeMember(member)
+		return
+	}
+	log.Debugf("Member %s (%s) is %s", member, member.Role, member.State)
+}
+
+// completeMember fetches a member from GCloud and merges it in the roster
+//
+// The State comes from the member topics, so it is not overwritten by the fetched member
+func (conversation *ConversationGuestChat) completeMember(member *ChatMember) {
+	log := conversation.Logger.Scope("member")
+	fetched, err := conversation.fetchMember(member)
+	if err != nil {
+		log.Errorf("Failed to fetch member %s", member.ID, err)
+		return
+	}
+	completed, err := conversation.Roster.Update(&ConversationGuestChatMemberTopic{
+		Member: &ChatMember{
+			ID:          member.ID,
+			DisplayName: fetched.DisplayName,
+			AvatarURL:   fetched.AvatarURL,
+			Role:        fetched.Role,
+			JoinedAt:    fetched.JoinedAt,
+			LeftAt:      fetched.LeftAt,
+			Custom:      fetched.Custom,
+		},
+	})
+	if err != nil {
+		log.Warnf("Failed to update member: %s", err)
+		return
+	}
+	log.Debugf("Member %s (%s) is %s", completed, completed.Role, completed.State)
+}
+
 // SendTyping sends a typing indicator to Gcloud as the chat guest
 func (conversation *ConversationGuestChat) SendTyping() (err error) {
 	response := &struct {
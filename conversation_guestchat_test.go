@@ -0,0 +1,277 @@
+package gcloudcx_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gildas/go-gcloudcx"
+	"github.com/gildas/go-logger"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/suite"
+)
+
+type GuestChatSuite struct {
+	suite.Suite
+	Name   string
+	Logger *logger.Logger
+	Start  time.Time
+
+	ConversationID uuid.UUID
+	GuestID        uuid.UUID
+	AgentID        uuid.UUID
+	Server         *httptest.Server
+	Stream         *guestChatStream
+}
+
+// guestChatStream plays the websocket of the Guest Chat and its message history
+type guestChatStream struct {
+	Connections int
+	History     []gcloudcx.GuestChatMessage
+	OnConnect   func(connection int, socket *websocket.Conn) // the socket is closed when OnConnect returns
+	MemberDelay time.Duration                                // delays the responses of the member endpoint
+	mutex       sync.Mutex
+}
+
+func TestGuestChatSuite(t *testing.T) {
+	suite.Run(t, new(GuestChatSuite))
+}
+
+func (suite *GuestChatSuite) TestCanReplayMissedMessagesAfterRedial() {
+	old, first, missed, last := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	now := time.Now().UTC()
+	suite.Stream.History = []gcloudcx.GuestChatMessage{
+		suite.historyMessage(old, "Too old to be replayed", now.Add(-time.Hour)),
+		suite.historyMessage(first, "First", now.Add(-3*time.Second)),
+		suite.historyMessage(missed, "Missed", now.Add(-2*time.Second)),
+		suite.historyMessage(last, "Last", now.Add(-1*time.Second)),
+	}
+	suite.Stream.OnConnect = func(connection int, socket *websocket.Conn) {
+		if connection == 1 {
+			_ = socket.WriteMessage(websocket.TextMessage, suite.messageTopic(first, "First", now.Add(-3*time.Second)))
+			return // drops the websocket
+		}
+		_ = socket.WriteMessage(websocket.TextMessage, suite.messageTopic(last, "Last", now.Add(-1*time.Second)))
+		time.Sleep(time.Second)
+	}
+	conversation := suite.connect(10 * time.Millisecond)
+	defer conversation.Close()
+
+	received := []string{}
+	for len(received) < 3 {
+		select {
+		case topic := <-conversation.TopicReceived:
+			if message, ok := topic.(*gcloudcx.ConversationGuestChatMessageTopic); ok {
+				received = append(received, message.Body)
+			}
+		case <-time.After(3 * time.Second):
+			suite.Require().Failf("Timeout", "Received only %v", received)
+		}
+	}
+	suite.Assert().Equal([]string{"First", "Missed", "Last"}, received, "Each message should be delivered once, in order")
+	select {
+	case topic := <-conversation.TopicReceived:
+		suite.Assert().Failf("Unexpected topic", "Received %s", topic)
+	case <-time.After(200 * time.Millisecond):
+	}
+	suite.Assert().Equal(2, suite.Stream.connections(), "The websocket should have been redialed once")
+}
+
+func (suite *GuestChatSuite) TestShouldStopRedialingWhenClosed() {
+	suite.Stream.OnConnect = func(connection int, socket *websocket.Conn) {}
+	conversation := suite.connect(200 * time.Millisecond)
+	time.Sleep(50 * time.Millisecond) // the websocket drops right away, the conversation is waiting to redial
+	suite.Require().Nil(conversation.Close())
+	time.Sleep(400 * time.Millisecond)
+	suite.Assert().Equal(1, suite.Stream.connections(), "The websocket should not be redialed after Close")
+}
+
+func (suite *GuestChatSuite) TestShouldNotBlockTopicsWhileFetchingMembers() {
+	suite.Stream.MemberDelay = 500 * time.Millisecond
+	suite.Stream.OnConnect = func(connection int, socket *websocket.Conn) {
+		_ = socket.WriteMessage(websocket.TextMessage, suite.memberTopic(suite.AgentID, "CONNECTED"))
+		_ = socket.WriteMessage(websocket.TextMessage, suite.messageTopic(uuid.New(), "Hello", time.Now().UTC()))
+		time.Sleep(2 * time.Second)
+	}
+	conversation := suite.connect(time.Second)
+	defer conversation.Close()
+
+	start := time.Now()
+	for received := 0; received < 2; received++ {
+		select {
+		case <-conversation.TopicReceived:
+		case <-time.After(400 * time.Millisecond):
+			suite.Require().Fail("Timeout", "The topics should be delivered while the member is being fetched")
+		}
+	}
+	suite.Assert().Less(time.Since(start), suite.Stream.MemberDelay, "The topics should not wait for the member to be fetched")
+
+	agent, err := conversation.WaitForAgent(2 * time.Second)
+	suite.Require().Nilf(err, "Failed to wait for the agent. %s", err)
+	suite.Assert().Equal(suite.AgentID, agent.ID)
+	suite.Assert().Equal("AGENT", agent.Role)
+	suite.Assert().Equal("CONNECTED", agent.State)
+	suite.Assert().Equal("Agent", agent.DisplayName)
+}
+
+func (suite *GuestChatSuite) TestCanStillUseMembersMap() {
+	suite.Stream.OnConnect = func(connection int, socket *websocket.Conn) { time.Sleep(time.Second) }
+	conversation := suite.connect(time.Second)
+	defer conversation.Close()
+
+	suite.Require().Contains(conversation.Members, suite.GuestID)
+	member, err := conversation.GetMember(gcloudcx.ChatMember{ID: suite.AgentID})
+	suite.Require().Nilf(err, "Failed to get member. %s", err)
+	suite.Assert().Equal("AGENT", member.Role)
+	suite.Assert().Contains(conversation.Members, suite.AgentID)
+	cached, found := conversation.Roster.Get(member)
+	suite.Require().True(found, "The member should be in the roster")
+	suite.Assert().Equal("Agent", cached.DisplayName)
+}
+
+func (suite *GuestChatSuite) connect(redialDelay time.Duration) *gcloudcx.ConversationGuestChat {
+	client := CreateTestClient(suite.Server.URL, suite.Logger)
+	client.Organization = &gcloudcx.Organization{ID: uuid.New()}
+	client.DeploymentID = uuid.New()
+	conversation := &gcloudcx.ConversationGuestChat{}
+	err := conversation.Initialize(client, &gcloudcx.ChatMember{DisplayName: "Guest"}, &gcloudcx.RoutingTarget{Type: "QUEUE", Address: "Support"})
+	suite.Require().Nilf(err, "Failed to initialize the guest chat. %s", err)
+	conversation.RedialDelay = redialDelay
+	err = conversation.Connect()
+	suite.Require().Nilf(err, "Failed to connect the guest chat. %s", err)
+	return conversation
+}
+
+func (suite *GuestChatSuite) historyMessage(id uuid.UUID, body string, timestamp time.Time) gcloudcx.GuestChatMessage {
+	return gcloudcx.GuestChatMessage{
+		ID:        id,
+		Sender:    &gcloudcx.ChatMember{ID: suite.AgentID},
+		Body:      body,
+		BodyType:  "standard",
+		Timestamp: timestamp,
+	}
+}
+
+func (suite *GuestChatSuite) messageTopic(id uuid.UUID, body string, timestamp time.Time) []byte {
+	payload, _ := json.Marshal(map[string]interface{}{
+		"topicName": fmt.Sprintf("v2.conversations.chats.%s.messages", suite.ConversationID),
+		"eventBody": map[string]interface{}{
+			"id":        id,
+			"sender":    map[string]interface{}{"id": suite.AgentID},
+			"body":      body,
+			"bodyType":  "standard",
+			"timestamp": timestamp,
+		},
+		"metadata": map[string]interface{}{"type": "message"},
+	})
+	return payload
+}
+
+func (suite *GuestChatSuite) memberTopic(id uuid.UUID, state string) []byte {
+	payload, _ := json.Marshal(map[string]interface{}{
+		"topicName": fmt.Sprintf("v2.conversations.chats.%s.members", suite.ConversationID),
+		"eventBody": map[string]interface{}{
+			"member": map[string]interface{}{"id": id, "state": state},
+		},
+		"metadata": map[string]interface{}{"type": "member-change"},
+	})
+	return payload
+}
+
+func (stream *guestChatStream) connections() int {
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+	return stream.Connections
+}
+
+func (suite *GuestChatSuite) handler(w http.ResponseWriter, r *http.Request) {
+	conversationPath := "/api/v2/webchat/guest/conversations/" + suite.ConversationID.String()
+	switch {
+	case r.URL.Path == "/stream":
+		socket, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer socket.Close()
+		suite.Stream.mutex.Lock()
+		suite.Stream.Connections++
+		connection := suite.Stream.Connections
+		onConnect := suite.Stream.OnConnect
+		suite.Stream.mutex.Unlock()
+		if onConnect != nil {
+			onConnect(connection, socket)
+		}
+	case r.Method == http.MethodPost && r.URL.Path == "/api/v2/webchat/guest/conversations":
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(fmt.Sprintf(`{"id": "%s", "jwt": "J.W.T", "eventStreamUri": "%s", "member": {"id": "%s"}}`,
+			suite.ConversationID,
+			"ws"+strings.TrimPrefix(suite.Server.URL, "http")+"/stream",
+			suite.GuestID,
+		)))
+	case r.Method == http.MethodGet && r.URL.Path == conversationPath+"/messages":
+		w.Header().Set("Content-Type", "application/json")
+		suite.Stream.mutex.Lock()
+		payload, _ := json.Marshal(map[string]interface{}{"entities": suite.Stream.History})
+		suite.Stream.mutex.Unlock()
+		_, _ = w.Write(payload)
+	case r.Method == http.MethodGet && r.URL.Path == conversationPath+"/members/"+suite.AgentID.String():
+		suite.Stream.mutex.Lock()
+		delay := suite.Stream.MemberDelay
+		suite.Stream.mutex.Unlock()
+		time.Sleep(delay)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(fmt.Sprintf(`{"id": "%s", "displayName": "Agent", "role": "AGENT", "state": "DISCONNECTED"}`, suite.AgentID)))
+	case r.Method == http.MethodDelete:
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// Suite Tools
+
+func (suite *GuestChatSuite) SetupSuite() {
+	suite.Name = strings.TrimSuffix(reflect.TypeOf(*suite).Name(), "Suite")
+	suite.Logger = logger.Create("test",
+		&logger.FileStream{
+			Path:        fmt.Sprintf("./log/test-%s.log", strings.ToLower(suite.Name)),
+			Unbuffered:  true,
+			FilterLevel: logger.TRACE,
+		},
+	).Child("test", "test")
+	suite.Logger.Infof("Suite Start: %s %s", suite.Name, strings.Repeat("=", 80-14-len(suite.Name)))
+	suite.ConversationID = uuid.MustParse("9e9e0d8a-0b5c-4c6e-8f27-5f4c6a3e9a01")
+	suite.GuestID = uuid.MustParse("9e9e0d8a-0b5c-4c6e-8f27-5f4c6a3e9a02")
+	suite.AgentID = uuid.MustParse("9e9e0d8a-0b5c-4c6e-8f27-5f4c6a3e9a03")
+	suite.Server = httptest.NewServer(http.HandlerFunc(suite.handler))
+}
+
+func (suite *GuestChatSuite) TearDownSuite() {
+	suite.Server.Close()
+	if suite.T().Failed() {
+		suite.Logger.Warnf("At least one test failed, we are not cleaning")
+		suite.T().Log("At least one test failed, we are not cleaning")
+	} else {
+		suite.Logger.Infof("All tests succeeded, we are cleaning")
+	}
+	suite.Logger.Infof("Suite End: %s %s", suite.Name, strings.Repeat("=", 80-12-len(suite.Name)))
+	suite.Logger.Close()
+}
+
+func (suite *GuestChatSuite) BeforeTest(suiteName, testName string) {
+	suite.Logger.Infof("Test Start: %s %s", testName, strings.Repeat("-", 80-13-len(testName)))
+	suite.Start = time.Now()
+	suite.Stream = &guestChatStream{}
+}
+
+func (suite *GuestChatSuite) AfterTest(suiteName, testName string) {
+	duration := time.Since(suite.Start)
+	suite.Logger.Record("duration", duration.String()).Infof("Test End: %s %s", testName, strings.Repeat("-", 80-11-len(testName)))
+}
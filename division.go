@@ -23,3 +23,34 @@ func (division Division) String() string {
 	}
 	return division.ID.String()
 }
+
+// FetchDivisions fetches all the Authorization Divisions of the organization
+//   see https://developer.genesys.cloud/api/rest/v2/authorization/#get-api-v2-authorization-divisions
+func (client *Client) FetchDivisions() ([]*Division, error) {
+	divisions := []*Division{}
+	page := 1
+	for {
+		response := struct {
+			Entities  []*Division `json:"entities"`
+			PageCount int         `json:"pageCount"`
+		}{}
+		if err := client.Get(NewURI("/authorization/divisions?pageSize=100&pageNumber=%d", page), &response); err != nil {
+			return nil, err
+		}
+		divisions = append(divisions, response.Entities...)
+		if page >= response.PageCount {
+			break
+		}
+		page++
+	}
+	return divisions, nil
+}
+
+// FetchHomeDivision fetches the Home Division of the organization
+func (client *Client) FetchHomeDivision() (*Division, error) {
+	division := &Division{}
+	if err := client.Get("/authorization/divisions/home", &division); err != nil {
+		return nil, err
+	}
+	return division, nil
+}
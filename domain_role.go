@@ -1,8 +1,73 @@
 package gcloudcx
 
+import (
+	"strings"
+
+	"github.com/gildas/go-errors"
+)
+
 // DomainRole describes a Role in a Domain
 type DomainRole struct {
-	// TODO: Find out what should be here!
-	ID      string `json:"id"`
-	SelfURI string `json:"selfUri,omitempty"`
+	ID          string `json:"id"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	SelfURI     string `json:"selfUri,omitempty"`
+}
+
+// FetchAuthorizationRoles fetches all the Authorization Roles of the organization
+//   see https://developer.genesys.cloud/api/rest/v2/authorization/#get-api-v2-authorization-roles
+func (client *Client) FetchAuthorizationRoles() ([]*DomainRole, error) {
+	roles := []*DomainRole{}
+	page := 1
+	for {
+		response := struct {
+			Entities  []*DomainRole `json:"entities"`
+			PageCount int           `json:"pageCount"`
+		}{}
+		if err := client.Get(NewURI("/authorization/roles?pageSize=100&pageNumber=%d", page), &response); err != nil {
+			return nil, err
+		}
+		roles = append(roles, response.Entities...)
+		if page >= response.PageCount {
+			break
+		}
+		page++
+	}
+	return roles, nil
+}
+
+// FindAuthorizationRoleByName finds an Authorization Role by its name (case insensitive)
+func (client *Client) FindAuthorizationRoleByName(name string) (*DomainRole, error) {
+	roles, err := client.FetchAuthorizationRoles()
+	if err != nil {
+		return nil, err
+	}
+	for _, role := range roles {
+		if strings.EqualFold(role.Name, name) {
+			return role, nil
+		}
+	}
+	return nil, errors.NotFound.With("role", name).WithStack()
+}
+
+// GrantRole grants the given Role to this User in the given Division
+//   see https://developer.genesys.cloud/api/rest/v2/authorization/#post-api-v2-authorization-subjects--subjectId--divisions--divisionId--roles--roleId-
+func (user *User) GrantRole(role *DomainRole, division Identifiable) error {
+	if role == nil || len(role.ID) == 0 {
+		return errors.ArgumentMissing.With("role").WithStack()
+	}
+	return user.Client.Post(
+		NewURI("/authorization/subjects/%s/divisions/%s/roles/%s?subjectType=PC_USER", user.ID, division.GetID(), role.ID),
+		nil,
+		nil,
+	)
+}
+
+// String gets a string version
+//   implements the fmt.Stringer interface
+func (role DomainRole) String() string {
+	if len(role.Name) > 0 {
+		return role.Name
+	}
+	return role.ID
 }
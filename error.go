@@ -3,6 +3,7 @@ package gcloudcx
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 )
 
 var (
@@ -69,6 +70,7 @@ type APIError struct {
 	CorrelationID     string            `json:"correlationId,omitempty"`
 	Details           []APIErrorDetails `json:"details,omitempty"`
 	Errors            []APIError        `json:"errors,omitempty"`
+	RetryAfter        time.Duration     `json:"-"` // from the Retry-After header of rate-limited responses
 }
 
 // APIErrorDetails contains the details of an APIError
@@ -122,7 +122,9 @@ func main() {
 	router := mux.NewRouter().StrictSlash(true)
 	router.Use(Log.HttpHandler())
 	router.Use(config.HttpHandler())
-	router.Methods("POST").Path("/hook").HandlerFunc(mainRouteHandler)
+	webhook := integration.NewWebhook()
+	webhook.OnMessage = messageHandler
+	router.Methods("POST").Path("/hook").Handler(webhook)
 
 	// Routes for the internal Chat Server (used by the chat web client)
 	ChatRoutes(router)
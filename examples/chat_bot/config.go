@@ -9,6 +9,7 @@ import (
 	"github.com/gildas/go-errors"
 	"github.com/gildas/go-logger"
 	"github.com/gildas/go-gcloudcx"
+	"github.com/google/uuid"
 )
 
 // AppConfig describes An Application Configuration
@@ -25,6 +26,9 @@ type AppConfig struct {
 	// AgentQueue is the Queue used when the customer wants to talk to an agent
 	AgentQueue *gcloudcx.Queue
 
+	// WrapupCode is the Wrap-up Code used when the bot wraps up a chat
+	WrapupCode *gcloudcx.WrapupCode
+
 	// User is the currently Logged in User
 	User *gcloudcx.User
 
@@ -73,7 +77,7 @@ func (config *AppConfig) Initialize(client *gcloudcx.Client) (err error) {
 	if config.AgentQueue == nil {
 		return errors.ArgumentMissing.With("Queue").WithStack()
 	}
-	if len(config.AgentQueue.ID) == 0 {
+	if config.AgentQueue.ID == uuid.Nil {
 		queueName := config.AgentQueue.Name
 		config.AgentQueue, err = client.FindQueueByName(queueName)
 		if err != nil {
@@ -83,13 +87,21 @@ func (config *AppConfig) Initialize(client *gcloudcx.Client) (err error) {
 	if config.BotQueue == nil {
 		return errors.New("Bot Queue is nil")
 	}
-	if len(config.BotQueue.ID) == 0 {
+	if config.BotQueue.ID == uuid.Nil {
 		queueName := config.BotQueue.Name
 		config.BotQueue, err = client.FindQueueByName(queueName)
 		if err != nil {
 			return errors.Wrapf(err, "Failed to retrieve the Bot Queue %s", queueName)
 		}
 	}
+	if config.WrapupCode != nil && config.WrapupCode.ID == uuid.Nil {
+		codeName := config.WrapupCode.Name
+		config.WrapupCode, err = config.BotQueue.FindWrapupCode(codeName)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to retrieve the Wrap-up Code %s in the Bot Queue %s", codeName, config.BotQueue)
+		}
+		log.Infof("Wrap-up Code: %s", config.WrapupCode)
+	}
 
 	config.User, err = client.GetMyUser()
 	if err != nil {
@@ -49,6 +49,7 @@ func main() {
 		botURL         = flag.String("boturl", core.GetEnvAsString("PURECLOUD_BOTURL", ""), "The Bot URL to query for interpretation")
 		botQueueName   = flag.String("botqueue", core.GetEnvAsString("PURECLOUD_BOTQUEUE", ""), "The queue to send customers to initially")
 		queueName      = flag.String("queue", core.GetEnvAsString("PURECLOUD_QUEUE", ""), "(legacy) the queue to send to")
+		wrapupCode     = flag.String("wrapupcode", core.GetEnvAsString("PURECLOUD_WRAPUPCODE", "Default Wrap-up Code"), "The wrap-up code (name or id) to use when the bot wraps up a chat")
 		webrootpath    = flag.String("webrootpath", core.GetEnvAsString("WEBROOT_PATH", ""), "The path to use before each endpoint (useful for nginx config)")
 		port           = flag.Int("port", core.GetEnvAsInt("PORT", 3000), "the port to listen to")
 		err            error
@@ -80,6 +81,9 @@ func main() {
 		WebRootPath: *webrootpath,
 		Logger:      Log.Topic("config"),
 	}
+	if len(*wrapupCode) > 0 {
+		MyAppConfig.WrapupCode = &gcloudcx.WrapupCode{Name: *wrapupCode}
+	}
 	if MyAppConfig.BotURL, err = url.Parse(*botURL); err != nil {
 		Log.Fatalf("The Chat BOT URL %s is invalid", *botURL, err)
 		fmt.Fprintf(os.Stderr, "The Chat BOT URL %s is invalid. Error: %s", *botURL, err)
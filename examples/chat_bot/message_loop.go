@@ -63,9 +63,10 @@ func MessageLoop(config *AppConfig) {
 							log.Infof("Wrapping up chat")
 							// Once the transfer is initiated, we should "Wrapup" the participant
 							//   if needed (queue request a wrapup)
-							wrapup := &gcloudcx.Wrapup{Code: "Default Wrap-up Code", Name: "Default Wap-up Code"}
-							if err := topic.Conversation.Wrapup(participant, wrapup); err != nil {
-								log.Errorf("Failed to wrapup Participant %s", participant)
+							if config.WrapupCode == nil {
+								log.Warnf("No Wrap-up Code is configured, Participant %s will not be wrapped up", participant)
+							} else if err := topic.Conversation.Wrapup(participant, &gcloudcx.Wrapup{Code: config.WrapupCode.ID.String(), Name: config.WrapupCode.Name}); err != nil {
+								log.Errorf("Failed to wrapup Participant %s", participant, err)
 								continue
 							}
 						}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gildas/go-core"
+	"github.com/gildas/go-gcloudcx"
+	"github.com/gildas/go-logger"
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+)
+
+// Config describes the load to generate
+type Config struct {
+	Client       *gcloudcx.Client
+	Target       *gcloudcx.RoutingTarget
+	Sessions     int
+	Rate         float64       // new sessions per second
+	Script       []string      // messages sent by each guest
+	ThinkTime    time.Duration // pause between 2 guest messages
+	Typing       bool          // send a typing indicator before each message
+	AgentTimeout time.Duration // how long a guest waits for an agent
+}
+
+func main() {
+	_ = godotenv.Load()
+	var (
+		region         = flag.String("region", core.GetEnvAsString("PURECLOUD_REGION", "mypurecloud.com"), "the GENESYS Cloud Region. \nDefault: mypurecloud.com")
+		clientID       = flag.String("clientid", core.GetEnvAsString("PURECLOUD_CLIENTID", ""), "the GENESYS Cloud Client ID for authentication")
+		clientSecret   = flag.String("secret", core.GetEnvAsString("PURECLOUD_CLIENTSECRET", ""), "the GENESYS Cloud Client Secret for authentication")
+		organizationID = flag.String("org", core.GetEnvAsString("PURECLOUD_ORGANIZATIONID", ""), "the GENESYS Cloud Organization ID")
+		deploymentID   = flag.String("deployment", core.GetEnvAsString("PURECLOUD_DEPLOYMENTID", ""), "the GENESYS Cloud Chat Deployment ID")
+		queue          = flag.String("queue", core.GetEnvAsString("PURECLOUD_QUEUE", ""), "the name of the queue to route the chats to")
+		sessions       = flag.Int("sessions", 10, "the number of guest chats to create")
+		rate           = flag.Float64("rate", 1, "the number of new guest chats per second")
+		scriptFile     = flag.String("script", "", "a file with the messages sent by each guest, one per line")
+		thinkTime      = flag.Duration("think", 5*time.Second, "the pause between 2 guest messages")
+		typing         = flag.Bool("typing", true, "send a typing indicator before each message")
+		agentTimeout   = flag.Duration("agent-timeout", 5*time.Minute, "how long a guest waits for an agent")
+		format         = flag.String("format", "csv", "the report format: csv or json")
+		output         = flag.String("output", "", "the report file. \nDefault: stdout")
+	)
+	flag.Parse()
+
+	log := logger.Create("GuestChatLoad_Example", &logger.FileStream{Path: "./log/guest-chat-load.log", FilterLevel: logger.INFO, Unbuffered: true})
+	defer log.Flush()
+	log.Infof(strings.Repeat("-", 80))
+	log.Infof("Log Destination: %s", log)
+
+	script, err := loadScript(*scriptFile)
+	if err != nil {
+		log.Fatalf("Failed to load the script from %s", *scriptFile, err)
+		os.Exit(1)
+	}
+
+	config := &Config{
+		Client: gcloudcx.NewClient(&gcloudcx.ClientOptions{
+			Region:         *region,
+			OrganizationID: uuid.MustParse(*organizationID),
+			DeploymentID:   uuid.MustParse(*deploymentID),
+			Logger:         log,
+		}).SetAuthorizationGrant(&gcloudcx.ClientCredentialsGrant{
+			ClientID: uuid.MustParse(*clientID),
+			Secret:   *clientSecret,
+		}),
+		Target:       &gcloudcx.RoutingTarget{Type: "QUEUE", Address: *queue},
+		Sessions:     *sessions,
+		Rate:         *rate,
+		Script:       script,
+		ThinkTime:    *thinkTime,
+		Typing:       *typing,
+		AgentTimeout: *agentTimeout,
+	}
+	if config.Rate <= 0 {
+		config.Rate = 1
+	}
+
+	report := os.Stdout
+	if len(*output) > 0 {
+		if report, err = os.Create(*output); err != nil {
+			log.Fatalf("Failed to create the report %s", *output, err)
+			os.Exit(1)
+		}
+		defer report.Close()
+	}
+
+	log.Infof("Starting %d sessions at %.2f sessions/s against queue %s", config.Sessions, config.Rate, *queue)
+	results := make([]*SessionResult, config.Sessions)
+	waitGroup := sync.WaitGroup{}
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / config.Rate))
+	defer ticker.Stop()
+	for i := 0; i < config.Sessions; i++ {
+		if i > 0 {
+			<-ticker.C
+		}
+		waitGroup.Add(1)
+		go func(index int) {
+			defer waitGroup.Done()
+			results[index] = RunSession(config, index+1, log)
+		}(i)
+	}
+	waitGroup.Wait()
+
+	if err = WriteReport(report, *format, results); err != nil {
+		log.Errorf("Failed to write the report", err)
+		os.Exit(1)
+	}
+	log.Infof("Done with %d sessions", config.Sessions)
+}
+
+func loadScript(path string) ([]string, error) {
+	if len(path) == 0 {
+		return []string{"Hello", "I have a question about my order", "Thank you, bye"}, nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	script := []string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); len(line) > 0 {
+			script = append(script, line)
+		}
+	}
+	return script, scanner.Err()
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/gildas/go-errors"
+)
+
+// WriteReport writes the session results as CSV or JSON
+func WriteReport(writer io.Writer, format string, results []*SessionResult) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		return errors.JSONMarshalError.Wrap(encoder.Encode(results))
+	case "csv":
+		output := csv.NewWriter(writer)
+		_ = output.Write([]string{"session", "conversation_id", "started_at", "queue_wait_ms", "agent_join_ms", "messages", "avg_latency_ms", "max_latency_ms", "error"})
+		for _, result := range results {
+			_ = output.Write([]string{
+				strconv.Itoa(result.Session),
+				result.ConversationID.String(),
+				result.StartedAt.Format(time.RFC3339),
+				optionalMilliseconds(result.QueueWait),
+				milliseconds(result.AgentJoin),
+				strconv.Itoa(result.Messages),
+				milliseconds(result.AvgLatency),
+				milliseconds(result.MaxLatency),
+				result.Error,
+			})
+		}
+		output.Flush()
+		return errors.WithStack(output.Error())
+	default:
+		return errors.ArgumentInvalid.With("format", format).WithStack()
+	}
+}
+
+// MarshalJSON marshals this into JSON
+//
+// The durations are given in milliseconds, like in the CSV report
+func (result SessionResult) MarshalJSON() ([]byte, error) {
+	type surrogate SessionResult
+	var queueWait *int64
+	if result.QueueWait != 0 {
+		value := result.QueueWait.Milliseconds()
+		queueWait = &value
+	}
+	data, err := json.Marshal(struct {
+		surrogate
+		QueueWait  *int64 `json:"queueWaitMs,omitempty"`
+		AgentJoin  int64  `json:"agentJoinMs"`
+		AvgLatency int64  `json:"avgLatencyMs"`
+		MaxLatency int64  `json:"maxLatencyMs"`
+	}{
+		surrogate:  surrogate(result),
+		QueueWait:  queueWait,
+		AgentJoin:  result.AgentJoin.Milliseconds(),
+		AvgLatency: result.AvgLatency.Milliseconds(),
+		MaxLatency: result.MaxLatency.Milliseconds(),
+	})
+	return data, errors.JSONMarshalError.Wrap(err)
+}
+
+func milliseconds(duration time.Duration) string {
+	return strconv.FormatInt(duration.Milliseconds(), 10)
+}
+
+// optionalMilliseconds leaves the measures that were not taken empty
+func optionalMilliseconds(duration time.Duration) string {
+	if duration == 0 {
+		return ""
+	}
+	return milliseconds(duration)
+}
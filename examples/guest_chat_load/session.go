@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gildas/go-errors"
+	"github.com/gildas/go-gcloudcx"
+	"github.com/gildas/go-logger"
+	"github.com/google/uuid"
+)
+
+// SessionResult describes the measures of a guest chat session
+type SessionResult struct {
+	Session        int           `json:"session"`
+	ConversationID uuid.UUID     `json:"conversationId"`
+	StartedAt      time.Time     `json:"startedAt"`
+	QueueWait      time.Duration `json:"-"` // until an agent is alerted, 0 if GCloud never sent an alerting member
+	AgentJoin      time.Duration `json:"-"` // until an agent is connected
+	Messages       int           `json:"messages"`
+	AvgLatency     time.Duration `json:"-"` // between sending a message and receiving it back from GCloud
+	MaxLatency     time.Duration `json:"-"`
+	Error          string        `json:"error,omitempty"`
+}
+
+// session tracks the events of a guest chat
+type session struct {
+	Conversation *gcloudcx.ConversationGuestChat
+	Result       *SessionResult
+	pending      map[string]time.Time // message body -> sent time
+	latencies    []time.Duration
+	mutex        sync.Mutex
+	log          *logger.Logger
+}
+
+// RunSession runs a guest chat session with the configured script
+func RunSession(config *Config, index int, log *logger.Logger) *SessionResult {
+	result := &SessionResult{Session: index, StartedAt: time.Now().UTC()}
+	log = log.Child("session", "session", "session", index)
+
+	conversation := &gcloudcx.ConversationGuestChat{
+		Guest:  &gcloudcx.ChatMember{DisplayName: fmt.Sprintf("Load Guest %d", index), Role: "CUSTOMER"},
+		Target: config.Target,
+	}
+	if err := conversation.Initialize(config.Client); err != nil {
+		return result.fail(log, "Failed to create the guest chat", err)
+	}
+	result.ConversationID = conversation.ID
+	current := &session{Conversation: conversation, Result: result, pending: map[string]time.Time{}, log: log}
+	done := make(chan struct{})
+	var receiver sync.WaitGroup
+	receiver.Add(1)
+	go func() {
+		defer receiver.Done()
+		current.receive(done)
+	}()
+	defer func() {
+		// The receiver stops after the conversation is closed, and before the result is returned
+		_ = conversation.Close()
+		close(done)
+		receiver.Wait()
+	}()
+	if err := conversation.Connect(); err != nil {
+		return result.fail(log, "Failed to connect the guest chat", err)
+	}
+
+	agent, err := conversation.WaitForAgent(config.AgentTimeout)
+	if err != nil {
+		return result.fail(log, "No agent joined the chat", err)
+	}
+	current.mutex.Lock()
+	result.AgentJoin = time.Since(result.StartedAt)
+	current.mutex.Unlock()
+	log.Infof("Agent %s joined after %s", agent, result.AgentJoin)
+
+	for i, text := range config.Script {
+		if i > 0 {
+			time.Sleep(config.ThinkTime)
+		}
+		if config.Typing {
+			if err := conversation.SendTyping(); err != nil {
+				log.Warnf("Failed to send typing: %s", err)
+			}
+			time.Sleep(time.Second)
+		}
+		body := fmt.Sprintf("%s [%d.%d]", text, index, i+1)
+		current.mutex.Lock()
+		current.pending[body] = time.Now()
+		current.mutex.Unlock()
+		if err := conversation.SendMessage(body); err != nil {
+			return result.fail(log, "Failed to send a message", err)
+		}
+		result.Messages++
+	}
+	time.Sleep(config.ThinkTime) // let the last echoes come back
+	current.summarize()
+	return result
+}
+
+// receive consumes the topics of the guest chat until done is closed
+//
+// The queue wait is measured when a member other than the guest is alerting,
+// the member topics do not always tell the role, and only agents are alerted
+func (session *session) receive(done chan struct{}) {
+	guestID := session.Conversation.Guest.ID
+	for {
+		var topic gcloudcx.NotificationTopic
+		select {
+		case topic = <-session.Conversation.TopicReceived:
+		case <-done:
+			return
+		}
+		switch message := topic.(type) {
+		case *gcloudcx.ConversationGuestChatMemberTopic:
+			if message.Member != nil && message.Member.ID != guestID && message.Member.State == "ALERTING" {
+				session.mutex.Lock()
+				if session.Result.QueueWait == 0 {
+					session.Result.QueueWait = time.Since(session.Result.StartedAt)
+				}
+				session.mutex.Unlock()
+			}
+		case *gcloudcx.ConversationGuestChatMessageTopic:
+			if message.Sender == nil || message.Sender.ID != guestID {
+				continue
+			}
+			session.mutex.Lock()
+			if sent, found := session.pending[message.Body]; found {
+				session.latencies = append(session.latencies, time.Since(sent))
+				delete(session.pending, message.Body)
+			}
+			session.mutex.Unlock()
+		}
+	}
+}
+
+func (session *session) summarize() {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+	var total time.Duration
+	for _, latency := range session.latencies {
+		total += latency
+		if latency > session.Result.MaxLatency {
+			session.Result.MaxLatency = latency
+		}
+	}
+	if len(session.latencies) > 0 {
+		session.Result.AvgLatency = total / time.Duration(len(session.latencies))
+	}
+	if len(session.pending) > 0 {
+		session.log.Warnf("%d message(s) were not received back", len(session.pending))
+	}
+}
+
+func (result *SessionResult) fail(log *logger.Logger, message string, err error) *SessionResult {
+	log.Errorf(message, err)
+	result.Error = errors.WithMessage(err, message).Error()
+	return result
+}
@@ -1,8 +1,12 @@
 package gcloudcx
 
 import (
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/gildas/go-errors"
 	"github.com/gildas/go-logger"
 	"github.com/google/uuid"
 )
@@ -20,13 +24,47 @@ type Group struct {
 	Images       []*UserImage   `json:"images"`
 	Addresses    []*Contact     `json:"addresses"`
 	RulesVisible bool           `json:"rulesVisible"`
-	Visibility   bool           `json:"visibility"`
+	Visibility   string         `json:"visibility"` // public, owners, members
 	DateModified time.Time      `json:"dateModified"`
 	Version      int            `json:"version"`
 	Client       *Client        `json:"-"`
 	Logger       *logger.Logger `json:"-"`
 }
 
+// groupRequest is the payload sent to GENESYS Cloud to create or update a Group
+type groupRequest struct {
+	Name         string   `json:"name"`
+	Description  string   `json:"description,omitempty"`
+	Type         string   `json:"type,omitempty"`
+	Visibility   string   `json:"visibility,omitempty"`
+	RulesVisible bool     `json:"rulesVisible"`
+	OwnerIDs     []string `json:"ownerIds,omitempty"`
+	Version      int      `json:"version,omitempty"`
+}
+
+// groupOwnersRequest is the payload sent to GENESYS Cloud to update the owners of a Group
+//
+// ownerIds is always sent, so the last owner can be removed
+type groupOwnersRequest struct {
+	groupRequest
+	OwnerIDs []string `json:"ownerIds"`
+}
+
+func newGroupRequest(group *Group) groupRequest {
+	request := groupRequest{
+		Name:         group.Name,
+		Description:  group.Description,
+		Type:         group.Type,
+		Visibility:   group.Visibility,
+		RulesVisible: group.RulesVisible,
+		Version:      group.Version,
+	}
+	for _, owner := range group.Owners {
+		request.OwnerIDs = append(request.OwnerIDs, owner.ID.String())
+	}
+	return request
+}
+
 // Initialize initializes this from the given Client
 //   implements Initializable
 //   if the group ID is given in group, the group is fetched
@@ -59,3 +97,263 @@ func (group Group) String() string {
 	}
 	return group.ID.String()
 }
+
+// FetchGroups fetches all the Groups of the organization
+//   see https://developer.genesys.cloud/api/rest/v2/groups/#get-api-v2-groups
+func (client *Client) FetchGroups() ([]*Group, error) {
+	groups := []*Group{}
+	page := 1
+	for {
+		response := struct {
+			Entities  []*Group `json:"entities"`
+			PageCount int      `json:"pageCount"`
+		}{}
+		if err := client.Get(NewURI("/groups?pageSize=100&pageNumber=%d", page), &response); err != nil {
+			return nil, err
+		}
+		for _, group := range response.Entities {
+			group.Client = client
+			group.Logger = client.Logger.Topic("group").Scope("group").Record("group", group.ID)
+		}
+		groups = append(groups, response.Entities...)
+		if page >= response.PageCount {
+			break
+		}
+		page++
+	}
+	return groups, nil
+}
+
+// AddMembers adds the given users to this Group
+//   The current version of the group is fetched first as GENESYS Cloud requires it
+//   see https://developer.genesys.cloud/api/rest/v2/groups/#post-api-v2-groups--groupId--members
+func (group *Group) AddMembers(members ...Identifiable) error {
+	if len(members) == 0 {
+		return nil
+	}
+	current := &Group{}
+	if err := group.Client.Get(NewURI("/groups/%s", group.ID), &current); err != nil {
+		return err
+	}
+	payload := struct {
+		MemberIDs []string `json:"memberIds"`
+		Version   int      `json:"version"`
+	}{MemberIDs: make([]string, 0, len(members)), Version: current.Version}
+	for _, member := range members {
+		payload.MemberIDs = append(payload.MemberIDs, member.GetID().String())
+	}
+	return group.Client.Post(NewURI("/groups/%s/members", group.ID), payload, nil)
+}
+
+// CreateGroup creates a new Group from the given Group
+//   Name, Description, Type (official, social), Visibility (public, owners, members), RulesVisible, and Owners are used
+//   see https://developer.genesys.cloud/api/rest/v2/groups/#post-api-v2-groups
+func (client *Client) CreateGroup(group *Group) (*Group, error) {
+	if group == nil || len(group.Name) == 0 {
+		return nil, errors.ArgumentMissing.With("name").WithStack()
+	}
+	request := newGroupRequest(group)
+	request.Version = 0
+	if len(request.Type) == 0 {
+		request.Type = "official"
+	}
+	if len(request.Visibility) == 0 {
+		request.Visibility = "public"
+	}
+	created := &Group{}
+	if err := client.Post("/groups", request, &created); err != nil {
+		return nil, err
+	}
+	created.Client = client
+	created.Logger = client.Logger.Topic("group").Scope("group").Record("group", created.ID)
+	return created, nil
+}
+
+// Update updates this Group in GENESYS Cloud with its current Name, Description, Type, Visibility, RulesVisible, and Owners
+//   The Version of the group must be the current one
+//   see https://developer.genesys.cloud/api/rest/v2/groups/#put-api-v2-groups--groupId-
+func (group *Group) Update() error {
+	if len(group.Name) == 0 {
+		return errors.ArgumentMissing.With("name").WithStack()
+	}
+	return group.put(newGroupRequest(group))
+}
+
+// put sends the given payload to update this Group and replaces this with the updated Group
+func (group *Group) put(payload interface{}) error {
+	updated := &Group{}
+	if err := group.Client.Put(NewURI("/groups/%s", group.ID), payload, &updated); err != nil {
+		return err
+	}
+	updated.Client = group.Client
+	updated.Logger = group.Logger
+	*group = *updated
+	return nil
+}
+
+// Delete deletes this Group
+func (group *Group) Delete() error {
+	return group.Client.Delete(NewURI("/groups/%s", group.ID), nil)
+}
+
+// FetchMembers fetches a page of the members of this Group
+//   properties is one of more properties that should be expanded
+//   It returns the users of the page and the total number of pages
+//   see https://developer.genesys.cloud/api/rest/v2/groups/#get-api-v2-groups--groupId--members
+func (group *Group) FetchMembers(pageNumber, pageSize int, properties ...string) ([]*User, int, error) {
+	return group.fetchUsers("members", pageNumber, pageSize, properties)
+}
+
+// FetchAllMembers fetches all the members of this Group
+//   properties is one of more properties that should be expanded
+func (group *Group) FetchAllMembers(properties ...string) ([]*User, error) {
+	return group.fetchAllUsers("members", properties)
+}
+
+// FetchIndividuals fetches all the users of this Group, including the members of its nested groups
+//   see https://developer.genesys.cloud/api/rest/v2/groups/#get-api-v2-groups--groupId--individuals
+func (group *Group) FetchIndividuals() ([]*User, error) {
+	response := struct {
+		Entities []*User `json:"entities"`
+	}{}
+	if err := group.Client.Get(NewURI("/groups/%s/individuals", group.ID), &response); err != nil {
+		return nil, err
+	}
+	for _, user := range response.Entities {
+		user.Client = group.Client
+		user.Logger = group.Client.Logger.Child("user", "user", "user", user.ID)
+	}
+	return response.Entities, nil
+}
+
+func (group *Group) fetchUsers(collection string, pageNumber, pageSize int, properties []string) ([]*User, int, error) {
+	query := url.Values{}
+	if pageNumber > 0 {
+		query.Add("pageNumber", strconv.Itoa(pageNumber))
+	}
+	if pageSize > 0 {
+		query.Add("pageSize", strconv.Itoa(pageSize))
+	}
+	if len(properties) > 0 {
+		query.Add("expand", strings.Join(properties, ","))
+	}
+	response := struct {
+		Entities  []*User `json:"entities"`
+		PageCount int     `json:"pageCount"`
+	}{}
+	if err := group.Client.Get(NewURI("/groups/%s/%s?%s", group.ID, collection, query.Encode()), &response); err != nil {
+		return nil, 0, err
+	}
+	for _, user := range response.Entities {
+		user.Client = group.Client
+		user.Logger = group.Client.Logger.Child("user", "user", "user", user.ID)
+	}
+	return response.Entities, response.PageCount, nil
+}
+
+func (group *Group) fetchAllUsers(collection string, properties []string) ([]*User, error) {
+	users := []*User{}
+	page := 1
+	for {
+		entities, pageCount, err := group.fetchUsers(collection, page, 100, properties)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, entities...)
+		if page >= pageCount {
+			break
+		}
+		page++
+	}
+	return users, nil
+}
+
+// RemoveMembers removes the given users from this Group
+//   see https://developer.genesys.cloud/api/rest/v2/groups/#delete-api-v2-groups--groupId--members
+func (group *Group) RemoveMembers(members ...Identifiable) error {
+	if len(members) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(members))
+	for _, member := range members {
+		ids = append(ids, member.GetID().String())
+	}
+	query := url.Values{}
+	query.Add("ids", strings.Join(ids, ","))
+	return group.Client.Delete(NewURI("/groups/%s/members?%s", group.ID, query.Encode()), nil)
+}
+
+// FetchOwners fetches all the owners of this Group
+//   see https://developer.genesys.cloud/api/rest/v2/groups/#get-api-v2-groups--groupId--owners
+func (group *Group) FetchOwners(properties ...string) ([]*User, error) {
+	owners, err := group.fetchAllUsers("owners", properties)
+	if err != nil {
+		return nil, err
+	}
+	group.Owners = owners
+	return owners, nil
+}
+
+// AddOwners adds the given users to the owners of this Group
+//   The current version and owners of the group are fetched first
+func (group *Group) AddOwners(owners ...Identifiable) error {
+	return group.updateOwners(func(current []*User) []*User {
+		for _, owner := range owners {
+			found := false
+			for _, user := range current {
+				if user.ID == owner.GetID() {
+					found = true
+					break
+				}
+			}
+			if !found {
+				current = append(current, &User{ID: owner.GetID()})
+			}
+		}
+		return current
+	})
+}
+
+// RemoveOwners removes the given users from the owners of this Group
+//   The current version and owners of the group are fetched first
+func (group *Group) RemoveOwners(owners ...Identifiable) error {
+	return group.updateOwners(func(current []*User) []*User {
+		kept := []*User{}
+		for _, user := range current {
+			removed := false
+			for _, owner := range owners {
+				if user.ID == owner.GetID() {
+					removed = true
+					break
+				}
+			}
+			if !removed {
+				kept = append(kept, user)
+			}
+		}
+		return kept
+	})
+}
+
+func (group *Group) updateOwners(change func([]*User) []*User) error {
+	current := &Group{}
+	if err := group.Client.Get(NewURI("/groups/%s", group.ID), &current); err != nil {
+		return err
+	}
+	owners, err := group.fetchAllUsers("owners", nil)
+	if err != nil {
+		return err
+	}
+	current.Owners = change(owners)
+	current.Client = group.Client
+	current.Logger = group.Logger
+	request := groupOwnersRequest{groupRequest: newGroupRequest(current), OwnerIDs: []string{}}
+	for _, owner := range current.Owners {
+		request.OwnerIDs = append(request.OwnerIDs, owner.ID.String())
+	}
+	if err := current.put(request); err != nil {
+		return err
+	}
+	*group = *current
+	return nil
+}
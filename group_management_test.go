@@ -0,0 +1,193 @@
+package gcloudcx_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gildas/go-errors"
+	"github.com/gildas/go-gcloudcx"
+	"github.com/gildas/go-logger"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+type GroupManagementSuite struct {
+	suite.Suite
+	Name   string
+	Logger *logger.Logger
+	Start  time.Time
+
+	GroupID  uuid.UUID
+	OwnerID  uuid.UUID
+	Recorder *RequestRecorder
+	Server   *httptest.Server
+	Client   *gcloudcx.Client
+}
+
+func TestGroupManagementSuite(t *testing.T) {
+	suite.Run(t, new(GroupManagementSuite))
+}
+
+func (suite *GroupManagementSuite) TestCanCreateGroup() {
+	group, err := suite.Client.CreateGroup(&gcloudcx.Group{Name: "Sales Team", Owners: []*gcloudcx.User{{ID: suite.OwnerID}}})
+	suite.Require().Nilf(err, "Failed to create group. %s", err)
+	suite.Assert().Equal(suite.GroupID, group.ID)
+	suite.Assert().Equal("public", group.Visibility)
+	payload := map[string]interface{}{}
+	suite.Require().Nil(json.Unmarshal([]byte(suite.Recorder.Bodies()[0]), &payload))
+	suite.Assert().Equal("Sales Team", payload["name"])
+	suite.Assert().Equal("official", payload["type"])
+	suite.Assert().Equal("public", payload["visibility"])
+	suite.Assert().Equal([]interface{}{suite.OwnerID.String()}, payload["ownerIds"])
+	suite.Assert().NotContains(payload, "version")
+}
+
+func (suite *GroupManagementSuite) TestShouldNotCreateGroupWithoutName() {
+	_, err := suite.Client.CreateGroup(&gcloudcx.Group{})
+	suite.Require().NotNil(err, "Group should not have been created")
+	suite.Assert().True(errors.Is(err, errors.ArgumentMissing), "Error should be an ArgumentMissing")
+}
+
+func (suite *GroupManagementSuite) TestCanUpdateAndDeleteGroup() {
+	group := &gcloudcx.Group{ID: suite.GroupID}
+	err := group.Initialize(suite.Client)
+	suite.Require().Nilf(err, "Failed to initialize group. %s", err)
+	suite.Assert().Equal(3, group.Version)
+	group.Description = "The Sales Team"
+	err = group.Update()
+	suite.Require().Nilf(err, "Failed to update group. %s", err)
+	suite.Assert().Contains(suite.Recorder.Bodies()[1], `"version":3`)
+	suite.Assert().Contains(suite.Recorder.Bodies()[1], `"description":"The Sales Team"`)
+	err = group.Delete()
+	suite.Require().Nilf(err, "Failed to delete group. %s", err)
+	suite.Assert().Equal("DELETE /api/v2/groups/"+suite.GroupID.String(), suite.Recorder.Requests()[2])
+}
+
+func (suite *GroupManagementSuite) TestCanFetchMembers() {
+	group := &gcloudcx.Group{ID: suite.GroupID, Client: suite.Client}
+	members, err := group.FetchAllMembers("presence")
+	suite.Require().Nilf(err, "Failed to fetch members. %s", err)
+	suite.Assert().Len(members, 3)
+	suite.Assert().Equal([]string{
+		"GET /api/v2/groups/" + suite.GroupID.String() + "/members?expand=presence&pageNumber=1&pageSize=100",
+		"GET /api/v2/groups/" + suite.GroupID.String() + "/members?expand=presence&pageNumber=2&pageSize=100",
+	}, suite.Recorder.Requests())
+}
+
+func (suite *GroupManagementSuite) TestCanRemoveMembers() {
+	group := &gcloudcx.Group{ID: suite.GroupID, Client: suite.Client}
+	first, second := uuid.New(), uuid.New()
+	err := group.RemoveMembers(gcloudcx.User{ID: first}, gcloudcx.User{ID: second})
+	suite.Require().Nilf(err, "Failed to remove members. %s", err)
+	suite.Assert().Equal(fmt.Sprintf("DELETE /api/v2/groups/%s/members?ids=%s%%2C%s", suite.GroupID, first, second), suite.Recorder.Requests()[0])
+}
+
+func (suite *GroupManagementSuite) TestCanAddOwners() {
+	group := &gcloudcx.Group{ID: suite.GroupID, Client: suite.Client}
+	newOwner := uuid.New()
+	err := group.AddOwners(gcloudcx.User{ID: newOwner}, gcloudcx.User{ID: suite.OwnerID})
+	suite.Require().Nilf(err, "Failed to add owners. %s", err)
+	bodies := suite.Recorder.Bodies()
+	update := bodies[len(bodies)-1]
+	suite.Assert().Contains(update, fmt.Sprintf(`"ownerIds":["%s","%s"]`, suite.OwnerID, newOwner))
+}
+
+func (suite *GroupManagementSuite) TestCanRemoveTheOnlyOwner() {
+	group := &gcloudcx.Group{ID: suite.GroupID, Client: suite.Client}
+	err := group.RemoveOwners(gcloudcx.User{ID: suite.OwnerID})
+	suite.Require().Nilf(err, "Failed to remove owners. %s", err)
+	requests := suite.Recorder.Requests()
+	suite.Assert().Equal(fmt.Sprintf("PUT /api/v2/groups/%s", suite.GroupID), requests[len(requests)-1])
+	bodies := suite.Recorder.Bodies()
+	update := bodies[len(bodies)-1]
+	suite.Assert().Contains(update, `"ownerIds":[]`, "The owners should be sent even when there are none left")
+}
+
+func (suite *GroupManagementSuite) TestCanSearchGroups() {
+	group, err := suite.Client.FindGroupByName("sales team")
+	suite.Require().Nilf(err, "Failed to find group. %s", err)
+	suite.Assert().Equal(suite.GroupID, group.ID)
+	suite.Assert().Contains(suite.Recorder.Bodies()[0], `{"type":"EXACT","fields":["name"],"value":"sales team"}`)
+
+	_, err = suite.Client.FindGroupByName("nobody")
+	suite.Require().NotNil(err, "Group should not have been found")
+	suite.Assert().True(errors.Is(err, errors.NotFound), "Error should be a NotFound")
+}
+
+// Suite Tools
+
+func (suite *GroupManagementSuite) SetupSuite() {
+	suite.Name = strings.TrimSuffix(reflect.TypeOf(*suite).Name(), "Suite")
+	suite.Logger = logger.Create("test",
+		&logger.FileStream{
+			Path:        fmt.Sprintf("./log/test-%s.log", strings.ToLower(suite.Name)),
+			Unbuffered:  true,
+			FilterLevel: logger.TRACE,
+		},
+	).Child("test", "test")
+	suite.Logger.Infof("Suite Start: %s %s", suite.Name, strings.Repeat("=", 80-14-len(suite.Name)))
+	suite.GroupID = uuid.MustParse("7e7e0d8a-0b5c-4c6e-8f27-5f4c6a3e9a02")
+	suite.OwnerID = uuid.MustParse("06ffcd2e-1ada-412e-a5f5-30d7853246dd")
+	suite.Recorder = NewRequestRecorder(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		groupPath := "/api/v2/groups/" + suite.GroupID.String()
+		group := fmt.Sprintf(`{"id": "%s", "name": "Sales Team", "type": "official", "visibility": "public", "version": 3}`, suite.GroupID)
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v2/groups/search":
+			search := gcloudcx.GroupSearchQuery{}
+			_ = json.Unmarshal(body, &search)
+			if search.Query[0].Value == "sales team" {
+				_, _ = w.Write([]byte(`{"results": [` + group + `], "total": 1, "pageCount": 1}`))
+			} else {
+				_, _ = w.Write([]byte(`{"results": [], "total": 0, "pageCount": 0}`))
+			}
+		case r.URL.Path == "/api/v2/groups" || (r.URL.Path == groupPath && r.Method != http.MethodDelete):
+			_, _ = w.Write([]byte(group))
+		case r.Method == http.MethodGet && r.URL.Path == groupPath+"/members":
+			if r.URL.Query().Get("pageNumber") == "1" {
+				_, _ = w.Write([]byte(fmt.Sprintf(`{"entities": [{"id": "%s"}, {"id": "%s"}], "pageCount": 2}`, uuid.New(), uuid.New())))
+			} else {
+				_, _ = w.Write([]byte(fmt.Sprintf(`{"entities": [{"id": "%s"}], "pageCount": 2}`, uuid.New())))
+			}
+		case r.Method == http.MethodGet && r.URL.Path == groupPath+"/owners":
+			_, _ = w.Write([]byte(fmt.Sprintf(`{"entities": [{"id": "%s"}], "pageCount": 1}`, suite.OwnerID)))
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			_, _ = w.Write([]byte(`{}`))
+		}
+	})
+	suite.Server = httptest.NewServer(suite.Recorder)
+	suite.Client = CreateTestClient(suite.Server.URL, suite.Logger)
+}
+
+func (suite *GroupManagementSuite) TearDownSuite() {
+	suite.Server.Close()
+	if suite.T().Failed() {
+		suite.Logger.Warnf("At least one test failed, we are not cleaning")
+		suite.T().Log("At least one test failed, we are not cleaning")
+	} else {
+		suite.Logger.Infof("All tests succeeded, we are cleaning")
+	}
+	suite.Logger.Infof("Suite End: %s %s", suite.Name, strings.Repeat("=", 80-12-len(suite.Name)))
+	suite.Logger.Close()
+}
+
+func (suite *GroupManagementSuite) BeforeTest(suiteName, testName string) {
+	suite.Logger.Infof("Test Start: %s %s", testName, strings.Repeat("-", 80-13-len(testName)))
+	suite.Start = time.Now()
+	suite.Recorder.Reset()
+}
+
+func (suite *GroupManagementSuite) AfterTest(suiteName, testName string) {
+	duration := time.Since(suite.Start)
+	suite.Logger.Record("duration", duration.String()).Infof("Test End: %s %s", testName, strings.Repeat("-", 80-11-len(testName)))
+}
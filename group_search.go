@@ -0,0 +1,134 @@
+package gcloudcx
+
+import (
+	"strings"
+
+	"github.com/gildas/go-errors"
+)
+
+// GroupSearchQuery describes a query for the /groups/search API
+//
+// See https://developer.genesys.cloud/api/rest/v2/groups/#post-api-v2-groups-search
+type GroupSearchQuery struct {
+	Query      []*GroupSearchCriteria `json:"query"`
+	Expand     []string               `json:"expand,omitempty"`
+	SortOrder  string                 `json:"sortOrder,omitempty"`
+	SortBy     string                 `json:"sortBy,omitempty"`
+	PageSize   int                    `json:"pageSize,omitempty"`
+	PageNumber int                    `json:"pageNumber,omitempty"`
+}
+
+// GroupSearchCriteria describes a criteria of a GroupSearchQuery
+type GroupSearchCriteria struct {
+	Type     string   `json:"type"`
+	Fields   []string `json:"fields,omitempty"`
+	Value    string   `json:"value,omitempty"`
+	Values   []string `json:"values,omitempty"`
+	Operator string   `json:"operator,omitempty"`
+}
+
+// GroupSearchResults describes the results of a GroupSearchQuery
+type GroupSearchResults struct {
+	Groups     []*Group `json:"results"`
+	Total      int      `json:"total"`
+	PageSize   int      `json:"pageSize"`
+	PageNumber int      `json:"pageNumber"`
+	PageCount  int      `json:"pageCount"`
+}
+
+// NewGroupSearchQuery creates a new empty GroupSearchQuery
+//
+// Add criteria with the With... methods, e.g.:
+//
+//	query := gcloudcx.NewGroupSearchQuery().WithName("Sales").WithType("official")
+func NewGroupSearchQuery() *GroupSearchQuery {
+	return &GroupSearchQuery{Query: []*GroupSearchCriteria{}}
+}
+
+// WithCriteria adds a criteria to this query
+func (query *GroupSearchQuery) WithCriteria(criteria *GroupSearchCriteria) *GroupSearchQuery {
+	if criteria != nil {
+		query.Query = append(query.Query, criteria)
+	}
+	return query
+}
+
+// WithName adds a criteria that matches groups whose name contains the given value
+func (query *GroupSearchQuery) WithName(name string) *GroupSearchQuery {
+	return query.WithCriteria(&GroupSearchCriteria{Type: "CONTAINS", Fields: []string{"name"}, Value: name})
+}
+
+// WithExactName adds a criteria that matches the given name exactly
+func (query *GroupSearchQuery) WithExactName(name string) *GroupSearchQuery {
+	return query.WithCriteria(&GroupSearchCriteria{Type: "EXACT", Fields: []string{"name"}, Value: name})
+}
+
+// WithType adds a criteria that matches the given group type (official, social)
+func (query *GroupSearchQuery) WithType(groupType string) *GroupSearchQuery {
+	return query.WithCriteria(&GroupSearchCriteria{Type: "EXACT", Fields: []string{"type"}, Value: groupType})
+}
+
+// WithExpand tells which properties should be expanded in the results
+func (query *GroupSearchQuery) WithExpand(properties ...string) *GroupSearchQuery {
+	query.Expand = append(query.Expand, properties...)
+	return query
+}
+
+// WithPage sets the page to fetch
+func (query *GroupSearchQuery) WithPage(pageNumber, pageSize int) *GroupSearchQuery {
+	query.PageNumber = pageNumber
+	query.PageSize = pageSize
+	return query
+}
+
+// Validate validates this query
+func (query GroupSearchQuery) Validate() error {
+	if len(query.Query) == 0 {
+		return errors.ArgumentMissing.With("query").WithStack()
+	}
+	for _, criteria := range query.Query {
+		if len(criteria.Type) == 0 {
+			return errors.ArgumentMissing.With("type").WithStack()
+		}
+		if len(criteria.Value) == 0 && len(criteria.Values) == 0 {
+			return errors.ArgumentMissing.With("value").WithStack()
+		}
+	}
+	return nil
+}
+
+// SearchGroups searches groups with the given query
+func (client *Client) SearchGroups(query *GroupSearchQuery) (*GroupSearchResults, error) {
+	if query == nil {
+		return nil, errors.ArgumentMissing.With("query").WithStack()
+	}
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	results := &GroupSearchResults{}
+	if err := client.Post("/groups/search", query, &results); err != nil {
+		return nil, err
+	}
+	for _, group := range results.Groups {
+		group.Client = client
+		group.Logger = client.Logger.Topic("group").Scope("group").Record("group", group.ID)
+	}
+	return results, nil
+}
+
+// FindGroupByName finds a Group by its name (case insensitive)
+func (client *Client) FindGroupByName(name string) (*Group, error) {
+	if len(name) == 0 {
+		return nil, errors.ArgumentMissing.With("name").WithStack()
+	}
+	results, err := client.SearchGroups(NewGroupSearchQuery().WithExactName(name))
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range results.Groups {
+		if strings.EqualFold(group.Name, name) {
+			return group, nil
+		}
+	}
+	return nil, errors.NotFound.With("group", name).WithStack()
+}
@@ -19,10 +19,10 @@ type MediaSettings map[string]MediaSetting
 // MarshalJSON marshals this into JSON
 func (setting MediaSetting) MarshalJSON() ([]byte, error) {
 	data, err := json.Marshal(struct {
-		AlertingTimeout int64        `json:"durationMs"`
+		AlertingTimeout int64        `json:"alertingTimeoutSeconds"`
 		ServiceLevel    ServiceLevel `json:"serviceLevel"`
 	}{
-		AlertingTimeout: setting.AlertingTimeout.Milliseconds(),
+		AlertingTimeout: int64(setting.AlertingTimeout.Seconds()),
 		ServiceLevel:    setting.ServiceLevel,
 	})
 	return data, errors.JSONMarshalError.Wrap(err)
@@ -31,14 +31,14 @@ func (setting MediaSetting) MarshalJSON() ([]byte, error) {
 // UnmarshalJSON unmarshals JSON into this
 func (setting *MediaSetting) UnmarshalJSON(payload []byte) (err error) {
 	var inner struct {
-		AlertingTimeout int64        `json:"durationMs"`
+		AlertingTimeout int64        `json:"alertingTimeoutSeconds"`
 		ServiceLevel    ServiceLevel `json:"serviceLevel"`
 	}
 
 	if err = json.Unmarshal(payload, &inner); err != nil {
 		return errors.JSONUnmarshalError.Wrap(err)
 	}
-	setting.AlertingTimeout = time.Duration(inner.AlertingTimeout) * time.Millisecond
+	setting.AlertingTimeout = time.Duration(inner.AlertingTimeout) * time.Second
 	setting.ServiceLevel = inner.ServiceLevel
 	return
 }
@@ -76,6 +76,7 @@ func (topic *ConversationGuestChatMessageTopic) UnmarshalJSON(payload []byte) (e
 	if err != nil {
 		return errors.JSONUnmarshalError.Wrap(errors.ArgumentInvalid.With("id", inner.TopicName))
 	}
+	topic.ID = inner.EventBody.ID
 	topic.Name = inner.TopicName
 	topic.Type = inner.Metadata.Type
 	topic.Conversation = &ConversationGuestChat{ID: conversationID}
@@ -1,23 +1,70 @@
 package gcloudcx
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"io"
+	"mime"
 	"net/url"
+	"path"
 
 	"github.com/gildas/go-core"
 	"github.com/gildas/go-errors"
 )
 
 type OpenMessageAttachment struct {
-	ID        string   `json:"id"`
-	Type      string   `json:"mediaType"`
+	ID        string   `json:"id,omitempty"`
+	Type      string   `json:"mediaType"` // Image, Video, Audio, File, Link
 	URL       *url.URL `json:"-"`
 	Mime      string   `json:"mime,omitempty"`
 	Filename  string   `json:"filename,omitempty"`
+	FileSize  int64    `json:"fileSize,omitempty"`
 	Text      string   `json:"text,omitempty"`
 	Hash      string   `json:"sha256,omitempty"`
 }
 
+// NewOpenMessageAttachment creates a new attachment
+//
+// If mimeType is empty, it is guessed from the extension of the URL path.
+// The Filename is the last element of the URL path.
+func NewOpenMessageAttachment(mediaType string, attachmentURL *url.URL, mimeType string) (*OpenMessageAttachment, error) {
+	if attachmentURL == nil {
+		return nil, errors.ArgumentMissing.With("url").WithStack()
+	}
+	if !Contains(mediaType, []string{"Image", "Video", "Audio", "File", "Link"}) {
+		return nil, errors.ArgumentInvalid.With("mediaType", mediaType).WithStack()
+	}
+	attachment := &OpenMessageAttachment{
+		Type:     mediaType,
+		URL:      attachmentURL,
+		Mime:     mimeType,
+		Filename: path.Base(attachmentURL.Path),
+	}
+	if attachment.Filename == "." || attachment.Filename == "/" {
+		attachment.Filename = ""
+	}
+	if len(attachment.Mime) == 0 {
+		attachment.Mime = mime.TypeByExtension(path.Ext(attachmentURL.Path))
+	}
+	return attachment, nil
+}
+
+// ComputeHash reads the content of the attachment to compute its FileSize and sha256 Hash
+func (attachment *OpenMessageAttachment) ComputeHash(reader io.Reader) error {
+	if reader == nil {
+		return errors.ArgumentMissing.With("reader").WithStack()
+	}
+	hash := sha256.New()
+	size, err := io.Copy(hash, reader)
+	if err != nil {
+		return errors.RuntimeError.Wrap(err)
+	}
+	attachment.FileSize = size
+	attachment.Hash = hex.EncodeToString(hash.Sum(nil))
+	return nil
+}
+
 // MarshalJSON marshals this into JSON
 func (attachment OpenMessageAttachment) MarshalJSON() ([]byte, error) {
 	type surrogate OpenMessageAttachment
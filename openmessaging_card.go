@@ -0,0 +1,117 @@
+package gcloudcx
+
+import (
+	"encoding/json"
+	"net/url"
+
+	"github.com/gildas/go-core"
+	"github.com/gildas/go-errors"
+)
+
+// OpenMessageGenericTemplate describes a card (1 element) or a carousel (several elements)
+type OpenMessageGenericTemplate struct {
+	Cards []*OpenMessageCard `json:"cards"`
+}
+
+// OpenMessageCard describes a card of a GenericTemplate
+type OpenMessageCard struct {
+	Title         string                   `json:"title"`
+	Description   string                   `json:"description,omitempty"`
+	ImageURL      *url.URL                 `json:"-"`
+	VideoURL      *url.URL                 `json:"-"`
+	DefaultAction *OpenMessageCardAction   `json:"defaultAction,omitempty"`
+	Actions       []*OpenMessageCardAction `json:"actions,omitempty"`
+}
+
+// OpenMessageCardAction describes an action (button) of a card or a list
+type OpenMessageCardAction struct {
+	Type    string   `json:"type"` // Link, Postback
+	Text    string   `json:"text"`
+	Payload string   `json:"payload,omitempty"` // for Postback actions
+	URL     *url.URL `json:"-"`                 // for Link actions
+}
+
+// OpenMessageListTemplate describes a list of items
+type OpenMessageListTemplate struct {
+	Title       string                   `json:"title,omitempty"`
+	Description string                   `json:"description,omitempty"`
+	Items       []*OpenMessageCard       `json:"items"`
+	Actions     []*OpenMessageCardAction `json:"actions,omitempty"`
+}
+
+// NewOpenMessageCard creates a new card
+func NewOpenMessageCard(title, description string, imageURL *url.URL, actions ...*OpenMessageCardAction) *OpenMessageCard {
+	return &OpenMessageCard{Title: title, Description: description, ImageURL: imageURL, Actions: actions}
+}
+
+// NewLinkAction creates a new Link action
+func NewLinkAction(text string, link *url.URL) *OpenMessageCardAction {
+	return &OpenMessageCardAction{Type: "Link", Text: text, URL: link}
+}
+
+// NewPostbackAction creates a new Postback action
+func NewPostbackAction(text, payload string) *OpenMessageCardAction {
+	return &OpenMessageCardAction{Type: "Postback", Text: text, Payload: payload}
+}
+
+// MarshalJSON marshals this into JSON
+func (card OpenMessageCard) MarshalJSON() ([]byte, error) {
+	type surrogate OpenMessageCard
+	data, err := json.Marshal(struct {
+		surrogate
+		I *core.URL `json:"image,omitempty"`
+		V *core.URL `json:"video,omitempty"`
+	}{
+		surrogate: surrogate(card),
+		I:         (*core.URL)(card.ImageURL),
+		V:         (*core.URL)(card.VideoURL),
+	})
+	return data, errors.JSONMarshalError.Wrap(err)
+}
+
+// UnmarshalJSON unmarshals JSON into this
+func (card *OpenMessageCard) UnmarshalJSON(payload []byte) (err error) {
+	type surrogate OpenMessageCard
+	var inner struct {
+		surrogate
+		I *core.URL `json:"image"`
+		V *core.URL `json:"video"`
+	}
+
+	if err = json.Unmarshal(payload, &inner); err != nil {
+		return errors.JSONUnmarshalError.Wrap(err)
+	}
+	*card = OpenMessageCard(inner.surrogate)
+	card.ImageURL = (*url.URL)(inner.I)
+	card.VideoURL = (*url.URL)(inner.V)
+	return
+}
+
+// MarshalJSON marshals this into JSON
+func (action OpenMessageCardAction) MarshalJSON() ([]byte, error) {
+	type surrogate OpenMessageCardAction
+	data, err := json.Marshal(struct {
+		surrogate
+		U *core.URL `json:"url,omitempty"`
+	}{
+		surrogate: surrogate(action),
+		U:         (*core.URL)(action.URL),
+	})
+	return data, errors.JSONMarshalError.Wrap(err)
+}
+
+// UnmarshalJSON unmarshals JSON into this
+func (action *OpenMessageCardAction) UnmarshalJSON(payload []byte) (err error) {
+	type surrogate OpenMessageCardAction
+	var inner struct {
+		surrogate
+		U *core.URL `json:"url"`
+	}
+
+	if err = json.Unmarshal(payload, &inner); err != nil {
+		return errors.JSONUnmarshalError.Wrap(err)
+	}
+	*action = OpenMessageCardAction(inner.surrogate)
+	action.URL = (*url.URL)(inner.U)
+	return
+}
@@ -6,9 +6,116 @@ import (
 	"github.com/gildas/go-errors"
 )
 
+// OpenMessageContent describes the content of a Structured OpenMessage
+//
+// Only the field matching the Type is set
 type OpenMessageContent struct {
-	Type       string                 `json:"contentType"` // Attachment, Location, QuickReply, ButtonResponse, Notification, GenericTemplate, ListTemplate, Postback, Reactions, Mention
-	Attachment *OpenMessageAttachment `json:"attachment"`
+	Type            string                      `json:"contentType"` // Attachment, Location, QuickReply, ButtonResponse, Notification, GenericTemplate, ListTemplate, Postback, Reactions, Mention
+	Attachment      *OpenMessageAttachment      `json:"attachment,omitempty"`
+	Location        *OpenMessageLocation        `json:"location,omitempty"`
+	QuickReply      *OpenMessageQuickReply      `json:"quickReply,omitempty"`
+	ButtonResponse  *OpenMessageButtonResponse  `json:"buttonResponse,omitempty"`
+	Notification    *OpenMessageNotification    `json:"notification,omitempty"`
+	GenericTemplate *OpenMessageGenericTemplate `json:"generic,omitempty"`
+	ListTemplate    *OpenMessageListTemplate    `json:"list,omitempty"`
+	Postback        *OpenMessagePostback        `json:"postback,omitempty"`
+	Reactions       []*OpenMessageReaction      `json:"reactions,omitempty"`
+	Mention         *OpenMessageMention         `json:"mention,omitempty"`
+}
+
+// OpenMessageQuickReply describes a quick reply offered to the customer
+type OpenMessageQuickReply struct {
+	Text    string `json:"text"`
+	Payload string `json:"payload"`
+	Image   string `json:"image,omitempty"`
+	Action  string `json:"action,omitempty"` // Message
+}
+
+// OpenMessageButtonResponse describes the button or quick reply the customer chose
+type OpenMessageButtonResponse struct {
+	Type    string `json:"type"` // Button, QuickReply
+	Text    string `json:"text"`
+	Payload string `json:"payload"`
+}
+
+// OpenMessageNotification describes a notification (templated) content
+type OpenMessageNotification struct {
+	Text   string `json:"text,omitempty"`
+	Header string `json:"header,omitempty"`
+	Footer string `json:"footer,omitempty"`
+}
+
+// OpenMessagePostback describes a postback sent when the customer clicks on a Postback action
+type OpenMessagePostback struct {
+	Text    string `json:"text"`
+	Payload string `json:"payload"`
+}
+
+// OpenMessageReaction describes a reaction to a message
+type OpenMessageReaction struct {
+	Type  string `json:"reactionType"` // Like, Love, Wow, Haha, Sad, Angry, Thankful, Pride, Care, ...
+	Count int    `json:"count,omitempty"`
+}
+
+// OpenMessageMention describes a mention of a user
+type OpenMessageMention struct {
+	UserID      string `json:"userId"`
+	DisplayName string `json:"displayName,omitempty"`
+}
+
+// NewAttachmentContent creates a new Attachment content
+func NewAttachmentContent(attachment *OpenMessageAttachment) *OpenMessageContent {
+	return &OpenMessageContent{Type: "Attachment", Attachment: attachment}
+}
+
+// NewLocationContent creates a new Location content
+func NewLocationContent(location *OpenMessageLocation) *OpenMessageContent {
+	return &OpenMessageContent{Type: "Location", Location: location}
+}
+
+// NewQuickReplyContent creates a new QuickReply content
+func NewQuickReplyContent(text, payload string) *OpenMessageContent {
+	return &OpenMessageContent{Type: "QuickReply", QuickReply: &OpenMessageQuickReply{Text: text, Payload: payload, Action: "Message"}}
+}
+
+// NewButtonResponseContent creates a new ButtonResponse content
+func NewButtonResponseContent(buttonType, text, payload string) *OpenMessageContent {
+	return &OpenMessageContent{Type: "ButtonResponse", ButtonResponse: &OpenMessageButtonResponse{Type: buttonType, Text: text, Payload: payload}}
+}
+
+// NewNotificationContent creates a new Notification content
+func NewNotificationContent(text string) *OpenMessageContent {
+	return &OpenMessageContent{Type: "Notification", Notification: &OpenMessageNotification{Text: text}}
+}
+
+// NewCardContent creates a new GenericTemplate content with a single card
+func NewCardContent(card *OpenMessageCard) *OpenMessageContent {
+	return &OpenMessageContent{Type: "GenericTemplate", GenericTemplate: &OpenMessageGenericTemplate{Cards: []*OpenMessageCard{card}}}
+}
+
+// NewCarouselContent creates a new GenericTemplate content with several cards
+func NewCarouselContent(cards ...*OpenMessageCard) *OpenMessageContent {
+	return &OpenMessageContent{Type: "GenericTemplate", GenericTemplate: &OpenMessageGenericTemplate{Cards: cards}}
+}
+
+// NewListTemplateContent creates a new ListTemplate content
+func NewListTemplateContent(list *OpenMessageListTemplate) *OpenMessageContent {
+	return &OpenMessageContent{Type: "ListTemplate", ListTemplate: list}
+}
+
+// NewPostbackContent creates a new Postback content
+func NewPostbackContent(text, payload string) *OpenMessageContent {
+	return &OpenMessageContent{Type: "Postback", Postback: &OpenMessagePostback{Text: text, Payload: payload}}
+}
+
+// NewReactionsContent creates a new Reactions content
+func NewReactionsContent(reactions ...*OpenMessageReaction) *OpenMessageContent {
+	return &OpenMessageContent{Type: "Reactions", Reactions: reactions}
+}
+
+// NewMentionContent creates a new Mention content
+func NewMentionContent(userID, displayName string) *OpenMessageContent {
+	return &OpenMessageContent{Type: "Mention", Mention: &OpenMessageMention{UserID: userID, DisplayName: displayName}}
 }
 
 // UnmarshalJSON unmarshals JSON into this
@@ -20,13 +127,35 @@ func (content *OpenMessageContent) UnmarshalJSON(payload []byte) (err error) {
 		return errors.JSONUnmarshalError.Wrap(err)
 	}
 	*content = OpenMessageContent(inner)
-	if content.Attachment == nil {
-		return errors.ArgumentMissing.With("attachment").WithStack()
-	}
-	// if !Contains(content.Type, []string{"Attachment", "Location", "QuickReply", "ButtonResponse", "Notification", "GenericTemplate", "ListTemplate", "Postback", "Reactions", "Mention"}) {
-	if !Contains(content.Type, []string{"Attachment", "Notification"}) {
+	var missing bool
+	var property string
+	switch content.Type {
+	case "Attachment":
+		missing, property = content.Attachment == nil, "attachment"
+	case "Location":
+		missing, property = content.Location == nil, "location"
+	case "QuickReply":
+		missing, property = content.QuickReply == nil, "quickReply"
+	case "ButtonResponse":
+		missing, property = content.ButtonResponse == nil, "buttonResponse"
+	case "Notification":
+		missing, property = content.Notification == nil && content.Attachment == nil, "notification"
+	case "GenericTemplate":
+		missing, property = content.GenericTemplate == nil, "generic"
+	case "ListTemplate":
+		missing, property = content.ListTemplate == nil, "list"
+	case "Postback":
+		missing, property = content.Postback == nil, "postback"
+	case "Reactions":
+		missing, property = len(content.Reactions) == 0, "reactions"
+	case "Mention":
+		missing, property = content.Mention == nil, "mention"
+	default:
 		return errors.ArgumentInvalid.With("contentType", content.Type).WithStack()
 	}
+	if missing {
+		return errors.JSONPropertyMissing.With(property).WithStack()
+	}
 	return
 }
 
@@ -37,4 +166,4 @@ func Contains(value string, values []string) bool {
 		}
 	}
 	return false
-}
\ No newline at end of file
+}
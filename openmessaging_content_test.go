@@ -0,0 +1,142 @@
+package gcloudcx_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gildas/go-errors"
+	"github.com/gildas/go-gcloudcx"
+	"github.com/gildas/go-logger"
+	"github.com/stretchr/testify/suite"
+)
+
+type OpenMessagingContentSuite struct {
+	suite.Suite
+	Name   string
+	Logger *logger.Logger
+	Start  time.Time
+}
+
+func TestOpenMessagingContentSuite(t *testing.T) {
+	suite.Run(t, new(OpenMessagingContentSuite))
+}
+
+func (suite *OpenMessagingContentSuite) TestCanUnmarshalStructuredMessage() {
+	message := gcloudcx.OpenMessage{}
+	err := LoadObject("openmessaging-message-structured.json", &message)
+	suite.Require().Nilf(err, "Failed to Load Data. %s", err)
+	suite.Assert().Equal("Structured", message.Type)
+	suite.Require().Len(message.Content, 4)
+
+	suite.Assert().Equal("QuickReply", message.Content[0].Type)
+	suite.Require().NotNil(message.Content[0].QuickReply)
+	suite.Assert().Equal("Check my order", message.Content[0].QuickReply.Text)
+	suite.Assert().Equal("order", message.Content[0].QuickReply.Payload)
+
+	suite.Assert().Equal("GenericTemplate", message.Content[2].Type)
+	suite.Require().NotNil(message.Content[2].GenericTemplate)
+	suite.Require().Len(message.Content[2].GenericTemplate.Cards, 2)
+	card := message.Content[2].GenericTemplate.Cards[0]
+	suite.Assert().Equal("Blue Shirt", card.Title)
+	suite.Require().NotNil(card.ImageURL)
+	suite.Assert().Equal("https://www.acme.com/images/blue-shirt.png", card.ImageURL.String())
+	suite.Require().Len(card.Actions, 2)
+	suite.Require().NotNil(card.Actions[0].URL)
+	suite.Assert().Equal("https://www.acme.com/shirts/blue", card.Actions[0].URL.String())
+	suite.Assert().Equal("buy:blue-shirt", card.Actions[1].Payload)
+
+	suite.Assert().Equal("Location", message.Content[3].Type)
+	suite.Require().NotNil(message.Content[3].Location)
+	suite.Assert().Equal(35.6812, message.Content[3].Location.Latitude)
+	suite.Require().NotNil(message.Content[3].Location.URL)
+}
+
+func (suite *OpenMessagingContentSuite) TestCanMarshalContent() {
+	imageURL, _ := url.Parse("https://www.acme.com/images/blue-shirt.png")
+	linkURL, _ := url.Parse("https://www.acme.com/shirts/blue")
+	contents := []*gcloudcx.OpenMessageContent{
+		gcloudcx.NewQuickReplyContent("Yes", "yes"),
+		gcloudcx.NewButtonResponseContent("Button", "Buy", "buy:blue-shirt"),
+		gcloudcx.NewCarouselContent(
+			gcloudcx.NewOpenMessageCard("Blue Shirt", "100% cotton", imageURL,
+				gcloudcx.NewLinkAction("See more", linkURL),
+				gcloudcx.NewPostbackAction("Buy", "buy:blue-shirt"),
+			),
+		),
+		gcloudcx.NewPostbackContent("Buy", "buy:blue-shirt"),
+		gcloudcx.NewReactionsContent(&gcloudcx.OpenMessageReaction{Type: "Like", Count: 1}),
+		gcloudcx.NewMentionContent("1234", "John Doe"),
+	}
+	payload, err := json.Marshal(contents)
+	suite.Require().Nilf(err, "Failed to marshal contents. %s", err)
+	suite.Assert().Contains(string(payload), `"quickReply":{"text":"Yes","payload":"yes","action":"Message"}`)
+	suite.Assert().Contains(string(payload), `"image":"https://www.acme.com/images/blue-shirt.png"`)
+	suite.Assert().Contains(string(payload), `"url":"https://www.acme.com/shirts/blue"`)
+	suite.Assert().NotContains(string(payload), `"attachment"`)
+
+	var unmarshaled []*gcloudcx.OpenMessageContent
+	err = json.Unmarshal(payload, &unmarshaled)
+	suite.Require().Nilf(err, "Failed to unmarshal contents. %s", err)
+	suite.Require().Len(unmarshaled, len(contents))
+	for i, content := range unmarshaled {
+		suite.Assert().Equal(contents[i].Type, content.Type)
+	}
+	suite.Assert().Equal("John Doe", unmarshaled[5].Mention.DisplayName)
+}
+
+func (suite *OpenMessagingContentSuite) TestShouldNotUnmarshalContentWithInvalidType() {
+	content := gcloudcx.OpenMessageContent{}
+	err := json.Unmarshal([]byte(`{"contentType": "Hologram"}`), &content)
+	suite.Require().NotNil(err, "Data should not have been unmarshaled successfully")
+	suite.Assert().True(errors.Is(err, errors.ArgumentInvalid), "Error should be an ArgumentInvalid")
+}
+
+func (suite *OpenMessagingContentSuite) TestShouldNotUnmarshalContentWithMissingProperty() {
+	content := gcloudcx.OpenMessageContent{}
+	err := json.Unmarshal([]byte(`{"contentType": "QuickReply", "postback": {"text": "Buy", "payload": "buy"}}`), &content)
+	suite.Require().NotNil(err, "Data should not have been unmarshaled successfully")
+	suite.Assert().True(errors.Is(err, errors.JSONPropertyMissing), "Error should be a JSONPropertyMissing")
+	var details *errors.Error
+	suite.Require().True(errors.As(err, &details), "Error should be an errors.Error")
+	suite.Assert().Equal("quickReply", details.What)
+}
+
+// Suite Tools
+
+func (suite *OpenMessagingContentSuite) SetupSuite() {
+	suite.Name = strings.TrimSuffix(reflect.TypeOf(*suite).Name(), "Suite")
+	suite.Logger = logger.Create("test",
+		&logger.FileStream{
+			Path:        fmt.Sprintf("./log/test-%s.log", strings.ToLower(suite.Name)),
+			Unbuffered:  true,
+			FilterLevel: logger.TRACE,
+		},
+	).Child("test", "test")
+	suite.Logger.Infof("Suite Start: %s %s", suite.Name, strings.Repeat("=", 80-14-len(suite.Name)))
+}
+
+func (suite *OpenMessagingContentSuite) TearDownSuite() {
+	if suite.T().Failed() {
+		suite.Logger.Warnf("At least one test failed, we are not cleaning")
+		suite.T().Log("At least one test failed, we are not cleaning")
+	} else {
+		suite.Logger.Infof("All tests succeeded, we are cleaning")
+	}
+	suite.Logger.Infof("Suite End: %s %s", suite.Name, strings.Repeat("=", 80-12-len(suite.Name)))
+	suite.Logger.Close()
+}
+
+func (suite *OpenMessagingContentSuite) BeforeTest(suiteName, testName string) {
+	suite.Logger.Infof("Test Start: %s %s", testName, strings.Repeat("-", 80-13-len(testName)))
+	suite.Start = time.Now()
+}
+
+func (suite *OpenMessagingContentSuite) AfterTest(suiteName, testName string) {
+	duration := time.Since(suite.Start)
+	suite.Logger.Record("duration", duration.String()).Infof("Test End: %s %s", testName, strings.Repeat("-", 80-11-len(testName)))
+}
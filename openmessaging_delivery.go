@@ -0,0 +1,248 @@
+package gcloudcx
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gildas/go-errors"
+)
+
+// OpenMessageDeliveryTracker tracks the delivery status of the outbound messages sent by GCloud
+//
+// Outbound messages are tracked when they are received by the OpenMessagingWebhook (if its Tracker is set)
+// or when Track is called. Their status is updated by the middleware after it sends a receipt to GCloud.
+//
+// If OnUndelivered is set, it is called once for each message that is not Delivered, Read, or Failed
+// after the Threshold (see Check and Start).
+type OpenMessageDeliveryTracker struct {
+	Threshold     time.Duration
+	OnUndelivered func(delivery OpenMessageDelivery)
+	deliveries    map[string]*OpenMessageDelivery
+	mutex         sync.RWMutex
+}
+
+// OpenMessageDelivery describes the delivery of an outbound message
+type OpenMessageDelivery struct {
+	MessageID string
+	To        string
+	Status    string // Sent, Published, Delivered, Read, Failed, Removed
+	Reasons   []*StatusReason
+	SentAt    time.Time
+	UpdatedAt time.Time
+	Timeline  []OpenMessageDeliveryEvent
+	notified  bool
+}
+
+// OpenMessageDeliveryEvent describes a status change of a delivery
+type OpenMessageDeliveryEvent struct {
+	Status  string
+	Time    time.Time
+	Reasons []*StatusReason
+}
+
+// NewOpenMessageDeliveryTracker creates a new OpenMessageDeliveryTracker
+func NewOpenMessageDeliveryTracker(threshold time.Duration, onUndelivered func(delivery OpenMessageDelivery)) *OpenMessageDeliveryTracker {
+	return &OpenMessageDeliveryTracker{
+		Threshold:     threshold,
+		OnUndelivered: onUndelivered,
+		deliveries:    map[string]*OpenMessageDelivery{},
+	}
+}
+
+// Track starts tracking the given outbound message
+//
+// If the message is already tracked, nothing happens
+func (tracker *OpenMessageDeliveryTracker) Track(message *OpenMessage) error {
+	if message == nil || len(message.ID) == 0 {
+		return errors.ArgumentMissing.With("message").WithStack()
+	}
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	if tracker.deliveries == nil {
+		tracker.deliveries = map[string]*OpenMessageDelivery{}
+	}
+	if _, found := tracker.deliveries[message.ID]; found {
+		return nil
+	}
+	now := time.Now().UTC()
+	delivery := &OpenMessageDelivery{
+		MessageID: message.ID,
+		Status:    "Sent",
+		SentAt:    now,
+		UpdatedAt: now,
+		Timeline:  []OpenMessageDeliveryEvent{{Status: "Sent", Time: now}},
+	}
+	if message.Channel != nil {
+		if !message.Channel.Time.IsZero() {
+			delivery.SentAt = message.Channel.Time
+			delivery.Timeline[0].Time = message.Channel.Time
+		}
+		if message.Channel.To != nil {
+			delivery.To = message.Channel.To.ID
+		}
+	}
+	tracker.deliveries[message.ID] = delivery
+	return nil
+}
+
+// deliveryStatusRanks gives the order of the delivery statuses, a message can only move to a status of a higher rank
+//
+// Failed and Removed are final and can follow any other status.
+var deliveryStatusRanks = map[string]int{
+	"Sent":      0,
+	"Published": 1,
+	"Delivered": 2,
+	"Read":      3,
+	"Failed":    4,
+	"Removed":   4,
+}
+
+// Update updates the status of a tracked message
+//
+// status is one of Published, Delivered, Read, Failed, Removed
+//
+// The status only moves forward (Sent, Published, Delivered, Read, then Failed or Removed),
+// so receipts received out of order or twice are ignored.
+func (tracker *OpenMessageDeliveryTracker) Update(messageID, status string, reasons ...*StatusReason) error {
+	if !Contains(status, []string{"Published", "Delivered", "Read", "Failed", "Removed"}) {
+		return errors.ArgumentInvalid.With("status", status).WithStack()
+	}
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	delivery, found := tracker.deliveries[messageID]
+	if !found {
+		return errors.NotFound.With("message", messageID).WithStack()
+	}
+	if deliveryStatusRanks[status] <= deliveryStatusRanks[delivery.Status] {
+		return nil
+	}
+	now := time.Now().UTC()
+	delivery.Status = status
+	delivery.Reasons = reasons
+	delivery.UpdatedAt = now
+	delivery.Timeline = append(delivery.Timeline, OpenMessageDeliveryEvent{Status: status, Time: now, Reasons: reasons})
+	return nil
+}
+
+// UpdateFromReceipt updates the status of the messages a receipt is about
+func (tracker *OpenMessageDeliveryTracker) UpdateFromReceipt(receipt *OpenMessage) error {
+	if receipt == nil {
+		return errors.ArgumentMissing.With("receipt").WithStack()
+	}
+	if receipt.Type != "Receipt" {
+		return errors.ArgumentInvalid.With("type", receipt.Type).WithStack()
+	}
+	if len(receipt.RelatedMessages) == 0 {
+		return tracker.Update(receipt.ID, receipt.Status, receipt.Reasons...)
+	}
+	for _, related := range receipt.RelatedMessages {
+		if err := tracker.Update(related.ID, receipt.Status, receipt.Reasons...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get gets a copy of the delivery of the given message
+func (tracker *OpenMessageDeliveryTracker) Get(messageID string) (OpenMessageDelivery, bool) {
+	tracker.mutex.RLock()
+	defer tracker.mutex.RUnlock()
+	delivery, found := tracker.deliveries[messageID]
+	if !found {
+		return OpenMessageDelivery{}, false
+	}
+	return delivery.copy(), true
+}
+
+// Status gets the current delivery status of the given message
+func (tracker *OpenMessageDeliveryTracker) Status(messageID string) (string, bool) {
+	delivery, found := tracker.Get(messageID)
+	return delivery.Status, found
+}
+
+// Timeline gets the status changes of the given message
+func (tracker *OpenMessageDeliveryTracker) Timeline(messageID string) []OpenMessageDeliveryEvent {
+	delivery, _ := tracker.Get(messageID)
+	return delivery.Timeline
+}
+
+// Forget stops tracking the given message
+func (tracker *OpenMessageDeliveryTracker) Forget(messageID string) {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	delete(tracker.deliveries, messageID)
+}
+
+// Purge stops tracking the messages that were not updated since the given duration
+func (tracker *OpenMessageDeliveryTracker) Purge(olderThan time.Duration) int {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	purged := 0
+	limit := time.Now().UTC().Add(-olderThan)
+	for id, delivery := range tracker.deliveries {
+		if delivery.UpdatedAt.Before(limit) {
+			delete(tracker.deliveries, id)
+			purged++
+		}
+	}
+	return purged
+}
+
+// Check calls OnUndelivered for each message that is still undelivered after the Threshold
+//
+// Each message is reported only once. Returns the undelivered messages that were reported.
+func (tracker *OpenMessageDeliveryTracker) Check(now time.Time) []OpenMessageDelivery {
+	tracker.mutex.Lock()
+	undelivered := []OpenMessageDelivery{}
+	for _, delivery := range tracker.deliveries {
+		if !delivery.notified && !delivery.IsFinal() && now.Sub(delivery.SentAt) > tracker.Threshold {
+			delivery.notified = true
+			undelivered = append(undelivered, delivery.copy())
+		}
+	}
+	tracker.mutex.Unlock()
+
+	if tracker.OnUndelivered != nil {
+		for _, delivery := range undelivered {
+			tracker.OnUndelivered(delivery)
+		}
+	}
+	return undelivered
+}
+
+// Start checks for undelivered messages at the given interval until the context is done
+//
+// The interval must be positive.
+func (tracker *OpenMessageDeliveryTracker) Start(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		return errors.ArgumentInvalid.With("interval", interval).WithStack()
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			tracker.Check(now)
+		}
+	}
+}
+
+// IsFinal tells if the message reached a final status (Delivered, Read, Failed, Removed)
+func (delivery OpenMessageDelivery) IsFinal() bool {
+	return Contains(delivery.Status, []string{"Delivered", "Read", "Failed", "Removed"})
+}
+
+// IsFailed tells if the message could not be delivered
+func (delivery OpenMessageDelivery) IsFailed() bool {
+	return delivery.Status == "Failed"
+}
+
+func (delivery OpenMessageDelivery) copy() OpenMessageDelivery {
+	timeline := make([]OpenMessageDeliveryEvent, len(delivery.Timeline))
+	copy(timeline, delivery.Timeline)
+	delivery.Timeline = timeline
+	return delivery
+}
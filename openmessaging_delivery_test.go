@@ -0,0 +1,189 @@
+package gcloudcx_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gildas/go-errors"
+	"github.com/gildas/go-gcloudcx"
+	"github.com/gildas/go-logger"
+	"github.com/stretchr/testify/suite"
+)
+
+type OpenMessagingDeliverySuite struct {
+	suite.Suite
+	Name   string
+	Logger *logger.Logger
+	Start  time.Time
+}
+
+func TestOpenMessagingDeliverySuite(t *testing.T) {
+	suite.Run(t, new(OpenMessagingDeliverySuite))
+}
+
+func (suite *OpenMessagingDeliverySuite) TestCanTrackMessage() {
+	message := gcloudcx.OpenMessage{}
+	err := LoadObject("openmessaging-message.json", &message)
+	suite.Require().Nilf(err, "Failed to Load Data. %s", err)
+
+	tracker := gcloudcx.NewOpenMessageDeliveryTracker(time.Minute, nil)
+	err = tracker.Track(&message)
+	suite.Require().Nilf(err, "Failed to track message. %s", err)
+
+	delivery, found := tracker.Get(message.ID)
+	suite.Require().True(found, "Message should be tracked")
+	suite.Assert().Equal("Sent", delivery.Status)
+	suite.Assert().Equal("gildas@kkt", delivery.To)
+	suite.Assert().Equal(message.Channel.Time, delivery.SentAt)
+
+	err = tracker.Update(message.ID, "Delivered")
+	suite.Require().Nilf(err, "Failed to update message. %s", err)
+	err = tracker.UpdateFromReceipt(&gcloudcx.OpenMessage{
+		ID:              "receipt-1",
+		Type:            "Receipt",
+		Status:          "Read",
+		RelatedMessages: []*gcloudcx.OpenMessage{{ID: message.ID}},
+	})
+	suite.Require().Nilf(err, "Failed to update message from receipt. %s", err)
+
+	status, _ := tracker.Status(message.ID)
+	suite.Assert().Equal("Read", status)
+	timeline := tracker.Timeline(message.ID)
+	suite.Require().Len(timeline, 3)
+	suite.Assert().Equal("Sent", timeline[0].Status)
+	suite.Assert().Equal("Delivered", timeline[1].Status)
+	suite.Assert().Equal("Read", timeline[2].Status)
+}
+
+func (suite *OpenMessagingDeliverySuite) TestCanTrackFailedMessage() {
+	tracker := gcloudcx.NewOpenMessageDeliveryTracker(time.Minute, nil)
+	_ = tracker.Track(&gcloudcx.OpenMessage{ID: "1234"})
+	err := tracker.Update("1234", "Failed", &gcloudcx.StatusReason{Code: "RecipientOptedOut", Message: "The recipient opted out"})
+	suite.Require().Nilf(err, "Failed to update message. %s", err)
+	delivery, _ := tracker.Get("1234")
+	suite.Assert().True(delivery.IsFailed())
+	suite.Assert().True(delivery.IsFinal())
+	suite.Require().Len(delivery.Reasons, 1)
+	suite.Assert().Equal("RecipientOptedOut", delivery.Reasons[0].Code)
+}
+
+func (suite *OpenMessagingDeliverySuite) TestShouldNotUpdateUnknownMessage() {
+	tracker := gcloudcx.NewOpenMessageDeliveryTracker(time.Minute, nil)
+	err := tracker.Update("unknown", "Delivered")
+	suite.Require().NotNil(err, "Unknown messages should not be updated")
+	suite.Assert().True(errors.Is(err, errors.NotFound), "Error should be a NotFound")
+
+	_ = tracker.Track(&gcloudcx.OpenMessage{ID: "1234"})
+	err = tracker.Update("1234", "Lost")
+	suite.Require().NotNil(err, "Invalid statuses should be rejected")
+	suite.Assert().True(errors.Is(err, errors.ArgumentInvalid), "Error should be an ArgumentInvalid")
+}
+
+func (suite *OpenMessagingDeliverySuite) TestShouldOnlyMoveStatusForward() {
+	tracker := gcloudcx.NewOpenMessageDeliveryTracker(time.Minute, nil)
+	_ = tracker.Track(&gcloudcx.OpenMessage{ID: "1234"})
+
+	suite.Require().Nil(tracker.Update("1234", "Read"))
+	suite.Require().Nil(tracker.Update("1234", "Delivered"), "Late receipts should be ignored, not rejected")
+	suite.Require().Nil(tracker.UpdateFromReceipt(&gcloudcx.OpenMessage{ID: "1234", Type: "Receipt", Status: "Published"}))
+	suite.Require().Nil(tracker.Update("1234", "Read"), "Duplicate receipts should be ignored")
+	status, _ := tracker.Status("1234")
+	suite.Assert().Equal("Read", status)
+	timeline := tracker.Timeline("1234")
+	suite.Require().Len(timeline, 2)
+	suite.Assert().Equal("Sent", timeline[0].Status)
+	suite.Assert().Equal("Read", timeline[1].Status)
+
+	suite.Require().Nil(tracker.Update("1234", "Removed"))
+	suite.Require().Nil(tracker.Update("1234", "Failed"), "Nothing should follow a final status")
+	delivery, _ := tracker.Get("1234")
+	suite.Assert().Equal("Removed", delivery.Status)
+	suite.Assert().Len(delivery.Timeline, 3)
+}
+
+func (suite *OpenMessagingDeliverySuite) TestCanFailDeliveredMessage() {
+	tracker := gcloudcx.NewOpenMessageDeliveryTracker(time.Minute, nil)
+	_ = tracker.Track(&gcloudcx.OpenMessage{ID: "1234"})
+	suite.Require().Nil(tracker.Update("1234", "Published"))
+	suite.Require().Nil(tracker.Update("1234", "Delivered"))
+	suite.Require().Nil(tracker.Update("1234", "Failed", &gcloudcx.StatusReason{Code: "MessageExpired", Message: "Expired"}))
+	delivery, _ := tracker.Get("1234")
+	suite.Assert().True(delivery.IsFailed())
+	suite.Require().Len(delivery.Reasons, 1)
+	suite.Assert().Equal([]string{"Sent", "Published", "Delivered", "Failed"}, []string{
+		delivery.Timeline[0].Status, delivery.Timeline[1].Status, delivery.Timeline[2].Status, delivery.Timeline[3].Status,
+	})
+}
+
+func (suite *OpenMessagingDeliverySuite) TestCanReportUndeliveredMessages() {
+	reported := []string{}
+	tracker := gcloudcx.NewOpenMessageDeliveryTracker(time.Minute, func(delivery gcloudcx.OpenMessageDelivery) {
+		reported = append(reported, delivery.MessageID)
+	})
+	_ = tracker.Track(&gcloudcx.OpenMessage{ID: "delivered"})
+	_ = tracker.Track(&gcloudcx.OpenMessage{ID: "published"})
+	_ = tracker.Track(&gcloudcx.OpenMessage{ID: "sent"})
+	_ = tracker.Update("delivered", "Delivered")
+	_ = tracker.Update("published", "Published")
+
+	suite.Assert().Empty(tracker.Check(time.Now()), "No message should be undelivered yet")
+	undelivered := tracker.Check(time.Now().Add(2 * time.Minute))
+	suite.Assert().Len(undelivered, 2)
+	suite.Assert().ElementsMatch([]string{"published", "sent"}, reported)
+	suite.Assert().Empty(tracker.Check(time.Now().Add(3*time.Minute)), "Messages should be reported only once")
+}
+
+func (suite *OpenMessagingDeliverySuite) TestShouldNotStartWithInvalidInterval() {
+	tracker := gcloudcx.NewOpenMessageDeliveryTracker(time.Minute, nil)
+	for _, interval := range []time.Duration{0, -time.Second} {
+		err := tracker.Start(context.Background(), interval)
+		suite.Require().NotNil(err, "Start should fail with interval %s", interval)
+		suite.Assert().True(errors.Is(err, errors.ArgumentInvalid), "Error should be an ArgumentInvalid")
+	}
+}
+
+func (suite *OpenMessagingDeliverySuite) TestCanStopTracker() {
+	tracker := gcloudcx.NewOpenMessageDeliveryTracker(time.Minute, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	suite.Assert().Nil(tracker.Start(ctx, time.Second), "Start should return when the context is done")
+}
+
+// Suite Tools
+
+func (suite *OpenMessagingDeliverySuite) SetupSuite() {
+	suite.Name = strings.TrimSuffix(reflect.TypeOf(*suite).Name(), "Suite")
+	suite.Logger = logger.Create("test",
+		&logger.FileStream{
+			Path:        fmt.Sprintf("./log/test-%s.log", strings.ToLower(suite.Name)),
+			Unbuffered:  true,
+			FilterLevel: logger.TRACE,
+		},
+	).Child("test", "test")
+	suite.Logger.Infof("Suite Start: %s %s", suite.Name, strings.Repeat("=", 80-14-len(suite.Name)))
+}
+
+func (suite *OpenMessagingDeliverySuite) TearDownSuite() {
+	if suite.T().Failed() {
+		suite.Logger.Warnf("At least one test failed, we are not cleaning")
+		suite.T().Log("At least one test failed, we are not cleaning")
+	} else {
+		suite.Logger.Infof("All tests succeeded, we are cleaning")
+	}
+	suite.Logger.Infof("Suite End: %s %s", suite.Name, strings.Repeat("=", 80-12-len(suite.Name)))
+	suite.Logger.Close()
+}
+
+func (suite *OpenMessagingDeliverySuite) BeforeTest(suiteName, testName string) {
+	suite.Logger.Infof("Test Start: %s %s", testName, strings.Repeat("-", 80-13-len(testName)))
+	suite.Start = time.Now()
+}
+
+func (suite *OpenMessagingDeliverySuite) AfterTest(suiteName, testName string) {
+	duration := time.Since(suite.Start)
+	suite.Logger.Record("duration", duration.String()).Infof("Test End: %s %s", testName, strings.Repeat("-", 80-11-len(testName)))
+}
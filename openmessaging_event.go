@@ -0,0 +1,41 @@
+package gcloudcx
+
+import "time"
+
+// OpenMessageEvent describes an event (typing, presence) carried by an OpenMessage of type Event
+type OpenMessageEvent struct {
+	Type     string                    `json:"eventType"` // Typing, Presence
+	Typing   *OpenMessageTypingEvent   `json:"typing,omitempty"`
+	Presence *OpenMessagePresenceEvent `json:"presence,omitempty"`
+}
+
+// OpenMessageTypingEvent describes a typing indicator
+type OpenMessageTypingEvent struct {
+	Type     string `json:"type"`               // On
+	Duration int64  `json:"duration,omitempty"` // in milliseconds
+}
+
+// OpenMessagePresenceEvent describes a presence event
+type OpenMessagePresenceEvent struct {
+	Type string `json:"type"` // Join, Disconnect, SignIn, Clear, Sleep
+}
+
+// NewTypingEvent creates a new Typing event
+//
+// If duration is 0, GCloud uses its default typing duration
+func NewTypingEvent(duration time.Duration) *OpenMessageEvent {
+	return &OpenMessageEvent{
+		Type:   "Typing",
+		Typing: &OpenMessageTypingEvent{Type: "On", Duration: duration.Milliseconds()},
+	}
+}
+
+// NewPresenceEvent creates a new Presence event
+//
+// presenceType is one of Join, Disconnect, SignIn, Clear, Sleep
+func NewPresenceEvent(presenceType string) *OpenMessageEvent {
+	return &OpenMessageEvent{
+		Type:     "Presence",
+		Presence: &OpenMessagePresenceEvent{Type: presenceType},
+	}
+}
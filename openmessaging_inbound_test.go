@@ -0,0 +1,290 @@
+package gcloudcx_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/gildas/go-errors"
+	"github.com/gildas/go-gcloudcx"
+	"github.com/gildas/go-logger"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+type OpenMessagingInboundSuite struct {
+	suite.Suite
+	Name   string
+	Logger *logger.Logger
+	Start  time.Time
+
+	Integration *gcloudcx.OpenMessagingIntegration
+	From        *gcloudcx.OpenMessageFrom
+	Recorder    *RequestRecorder
+	Server      *httptest.Server
+}
+
+func TestOpenMessagingInboundSuite(t *testing.T) {
+	suite.Run(t, new(OpenMessagingInboundSuite))
+}
+
+func (suite *OpenMessagingInboundSuite) TestCanSendTextMessage() {
+	result, err := suite.Integration.SendInboundTextMessage(suite.From, "msg-001", "Hello")
+	suite.Require().Nilf(err, "Failed to send message. %s", err)
+	suite.Assert().Equal("abcd-1234", result.ID)
+	suite.Assert().Equal([]string{"POST /api/v2/conversations/messages/inbound/open"}, suite.Recorder.Requests())
+
+	payload := suite.lastPayload()
+	suite.Assert().Equal("Inbound", payload["direction"])
+	suite.Assert().Equal("Text", payload["type"])
+	suite.Assert().Equal("Hello", payload["text"])
+	channel := payload["channel"].(map[string]interface{})
+	suite.Assert().Equal("msg-001", channel["messageId"])
+	suite.Assert().Equal(suite.Integration.ID.String(), channel["to"].(map[string]interface{})["id"])
+	suite.Assert().Equal(suite.From.ID, channel["from"].(map[string]interface{})["id"])
+	suite.Assert().NotContains(payload, "status")
+	suite.Assert().NotContains(payload, "isFinalReceipt")
+	suite.Assert().NotContains(payload, "events")
+}
+
+func (suite *OpenMessagingInboundSuite) TestShouldKeepEmptyTextInMessage() {
+	_, err := suite.Integration.SendInboundTextMessage(suite.From, "msg-002", "")
+	suite.Require().Nilf(err, "Failed to send message. %s", err)
+	payload := suite.lastPayload()
+	suite.Assert().Contains(payload, "text", "The text should always be sent")
+	suite.Assert().Equal("", payload["text"])
+}
+
+func (suite *OpenMessagingInboundSuite) TestCanSendAttachmentMessage() {
+	attachmentURL, _ := url.Parse("https://www.acme.com/images/logo.png")
+	attachment, err := gcloudcx.NewOpenMessageAttachment("Image", attachmentURL, "")
+	suite.Require().Nilf(err, "Failed to create attachment. %s", err)
+	suite.Require().Nil(attachment.ComputeHash(strings.NewReader("hello world")))
+
+	_, err = suite.Integration.SendInboundAttachmentMessage(suite.From, "msg-003", "Our logo", attachment)
+	suite.Require().Nilf(err, "Failed to send message. %s", err)
+	suite.Assert().Equal([]string{"POST /api/v2/conversations/messages/inbound/open"}, suite.Recorder.Requests())
+
+	payload := suite.lastPayload()
+	suite.Assert().Equal("Text", payload["type"])
+	suite.Assert().Equal("Our logo", payload["text"])
+	contents := payload["content"].([]interface{})
+	suite.Require().Len(contents, 1)
+	content := contents[0].(map[string]interface{})
+	suite.Assert().Equal("Attachment", content["contentType"])
+	sent := content["attachment"].(map[string]interface{})
+	suite.Assert().Equal("Image", sent["mediaType"])
+	suite.Assert().Equal("https://www.acme.com/images/logo.png", sent["url"])
+	suite.Assert().Equal("image/png", sent["mime"])
+	suite.Assert().Equal("logo.png", sent["filename"])
+	suite.Assert().Equal(float64(11), sent["fileSize"])
+	suite.Assert().Equal("b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9", sent["sha256"])
+}
+
+func (suite *OpenMessagingInboundSuite) TestCanSendImageMessage() {
+	imageURL, _ := url.Parse("https://www.acme.com/images/logo.jpg")
+	_, err := suite.Integration.SendInboundImageMessage(suite.From, "msg-004", "", "image/jpeg", imageURL)
+	suite.Require().Nilf(err, "Failed to send message. %s", err)
+	sent := suite.lastPayload()["content"].([]interface{})[0].(map[string]interface{})["attachment"].(map[string]interface{})
+	suite.Assert().Equal("Image", sent["mediaType"])
+	suite.Assert().Equal("image/jpeg", sent["mime"])
+	suite.Assert().Equal("https://www.acme.com/images/logo.jpg", sent["url"])
+}
+
+func (suite *OpenMessagingInboundSuite) TestShouldNotSendAttachmentWithoutURL() {
+	_, err := suite.Integration.SendInboundAttachmentMessage(suite.From, "msg-005", "", &gcloudcx.OpenMessageAttachment{Type: "File"})
+	suite.Require().NotNil(err, "Message should not have been sent")
+	suite.Assert().True(errors.Is(err, errors.ArgumentMissing), "Error should be an ArgumentMissing")
+	_, err = suite.Integration.SendInboundAttachmentMessage(suite.From, "msg-005", "", nil)
+	suite.Assert().True(errors.Is(err, errors.ArgumentMissing), "Error should be an ArgumentMissing")
+	suite.Assert().Empty(suite.Recorder.Requests())
+}
+
+func (suite *OpenMessagingInboundSuite) TestCanSendReceipt() {
+	message := &gcloudcx.OpenMessage{
+		ID:      "gcloud-msg-001",
+		Channel: gcloudcx.NewOpenMessageChannel("gcloud-msg-001", &gcloudcx.OpenMessageTo{ID: "gildas@kkt"}, nil),
+	}
+	_, err := suite.Integration.SendInboundReceipt(message, "Failed", true, &gcloudcx.OpenMessageReceiptOptions{
+		Reasons: []*gcloudcx.StatusReason{{Code: "RecipientOptedOut", Message: "Nope"}},
+	})
+	suite.Require().Nilf(err, "Failed to send receipt. %s", err)
+	suite.Assert().Equal([]string{
+		fmt.Sprintf("POST /api/v2/conversations/messages/%s/inbound/open/receipt", suite.Integration.ID),
+	}, suite.Recorder.Requests())
+
+	payload := suite.lastPayload()
+	suite.Assert().Equal("gcloud-msg-001", payload["id"])
+	suite.Assert().Equal("Outbound", payload["direction"])
+	suite.Assert().Equal("Receipt", payload["type"])
+	suite.Assert().Equal("Failed", payload["status"])
+	suite.Assert().Equal(true, payload["isFinalReceipt"])
+	suite.Assert().NotContains(payload, "relatedMessages")
+	channel := payload["channel"].(map[string]interface{})
+	suite.Assert().Equal("gcloud-msg-001", channel["messageId"])
+	suite.Assert().Equal("gildas@kkt", channel["to"].(map[string]interface{})["id"])
+	reasons := payload["reasons"].([]interface{})
+	suite.Require().Len(reasons, 1)
+	suite.Assert().Equal("RecipientOptedOut", reasons[0].(map[string]interface{})["code"])
+}
+
+func (suite *OpenMessagingInboundSuite) TestCanSendReceiptWithRelatedMessages() {
+	message := &gcloudcx.OpenMessage{
+		ID:      "gcloud-msg-001",
+		Channel: gcloudcx.NewOpenMessageChannel("gcloud-msg-001", &gcloudcx.OpenMessageTo{ID: "gildas@kkt"}, nil),
+	}
+	_, err := suite.Integration.SendInboundReceipt(message, "Read", true, &gcloudcx.OpenMessageReceiptOptions{
+		RelatedMessages: []*gcloudcx.OpenMessage{{ID: "gcloud-msg-002", Text: "Not sent"}, {ID: "gcloud-msg-003"}},
+	})
+	suite.Require().Nilf(err, "Failed to send receipt. %s", err)
+
+	payload := suite.lastPayload()
+	suite.Assert().Equal("Read", payload["status"])
+	suite.Assert().NotContains(payload, "reasons")
+	related := payload["relatedMessages"].([]interface{})
+	suite.Require().Len(related, 2)
+	suite.Assert().Equal("gcloud-msg-002", related[0].(map[string]interface{})["id"])
+	suite.Assert().Equal("gcloud-msg-003", related[1].(map[string]interface{})["id"])
+	suite.Assert().Empty(related[0].(map[string]interface{})["text"], "Only the ID of related messages should be sent")
+}
+
+func (suite *OpenMessagingInboundSuite) TestShouldNotSendReceiptWithInvalidArguments() {
+	message := &gcloudcx.OpenMessage{
+		ID:      "gcloud-msg-001",
+		Channel: gcloudcx.NewOpenMessageChannel("gcloud-msg-001", &gcloudcx.OpenMessageTo{ID: "gildas@kkt"}, nil),
+	}
+	_, err := suite.Integration.SendInboundReceipt(message, "Seen", false, nil)
+	suite.Assert().True(errors.Is(err, errors.ArgumentInvalid), "Error should be an ArgumentInvalid")
+	_, err = suite.Integration.SendInboundReceipt(nil, "Read", false, nil)
+	suite.Assert().True(errors.Is(err, errors.ArgumentMissing), "Error should be an ArgumentMissing")
+	_, err = suite.Integration.SendInboundReceipt(&gcloudcx.OpenMessage{ID: "gcloud-msg-001"}, "Read", false, nil)
+	suite.Assert().True(errors.Is(err, errors.ArgumentMissing), "Error should be an ArgumentMissing")
+	_, err = suite.Integration.SendInboundReceipt(message, "Read", false, &gcloudcx.OpenMessageReceiptOptions{RelatedMessages: []*gcloudcx.OpenMessage{{}}})
+	suite.Assert().True(errors.Is(err, errors.ArgumentMissing), "Error should be an ArgumentMissing")
+	suite.Assert().Empty(suite.Recorder.Requests())
+}
+
+func (suite *OpenMessagingInboundSuite) TestCanSendTypingIndicator() {
+	_, err := suite.Integration.SendTypingIndicator(suite.From)
+	suite.Require().Nilf(err, "Failed to send typing indicator. %s", err)
+	suite.Assert().Equal([]string{
+		fmt.Sprintf("POST /api/v2/conversations/messages/%s/inbound/open/event", suite.Integration.ID),
+	}, suite.Recorder.Requests())
+
+	payload := suite.lastPayload()
+	suite.Assert().Equal("Inbound", payload["direction"])
+	suite.Assert().Equal("Event", payload["type"])
+	events := payload["events"].([]interface{})
+	suite.Require().Len(events, 1)
+	event := events[0].(map[string]interface{})
+	suite.Assert().Equal("Typing", event["eventType"])
+	suite.Assert().Equal("On", event["typing"].(map[string]interface{})["type"])
+	suite.Assert().NotContains(event["typing"], "duration", "The default duration should not be sent")
+	suite.Assert().NotContains(event, "presence")
+}
+
+func (suite *OpenMessagingInboundSuite) TestCanSendPresenceEvents() {
+	_, err := suite.Integration.SendInboundEvents(suite.From, gcloudcx.NewPresenceEvent("Join"), gcloudcx.NewTypingEvent(5*time.Second))
+	suite.Require().Nilf(err, "Failed to send events. %s", err)
+	events := suite.lastPayload()["events"].([]interface{})
+	suite.Require().Len(events, 2)
+	presence := events[0].(map[string]interface{})
+	suite.Assert().Equal("Presence", presence["eventType"])
+	suite.Assert().Equal("Join", presence["presence"].(map[string]interface{})["type"])
+	typing := events[1].(map[string]interface{})
+	suite.Assert().Equal(float64(5000), typing["typing"].(map[string]interface{})["duration"])
+}
+
+func (suite *OpenMessagingInboundSuite) TestShouldNotSendEventsWithoutEvents() {
+	_, err := suite.Integration.SendInboundEvents(suite.From)
+	suite.Assert().True(errors.Is(err, errors.ArgumentMissing), "Error should be an ArgumentMissing")
+	suite.Assert().Empty(suite.Recorder.Requests())
+}
+
+func (suite *OpenMessagingInboundSuite) TestCanComputeHash() {
+	attachment := &gcloudcx.OpenMessageAttachment{Type: "File"}
+	err := attachment.ComputeHash(strings.NewReader("hello world"))
+	suite.Require().Nilf(err, "Failed to compute hash. %s", err)
+	suite.Assert().Equal(int64(11), attachment.FileSize)
+	suite.Assert().Equal("b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9", attachment.Hash)
+
+	err = attachment.ComputeHash(strings.NewReader(""))
+	suite.Require().Nilf(err, "Failed to compute hash. %s", err)
+	suite.Assert().Equal(int64(0), attachment.FileSize)
+	suite.Assert().Equal("e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", attachment.Hash)
+}
+
+func (suite *OpenMessagingInboundSuite) TestShouldNotComputeHashWithFailingReader() {
+	attachment := &gcloudcx.OpenMessageAttachment{Type: "File"}
+	err := attachment.ComputeHash(nil)
+	suite.Assert().True(errors.Is(err, errors.ArgumentMissing), "Error should be an ArgumentMissing")
+	err = attachment.ComputeHash(iotest.ErrReader(errors.New("disk on fire")))
+	suite.Require().NotNil(err, "ComputeHash should have failed")
+	suite.Assert().True(errors.Is(err, errors.RuntimeError), "Error should be a RuntimeError")
+	suite.Assert().Empty(attachment.Hash)
+}
+
+func (suite *OpenMessagingInboundSuite) lastPayload() map[string]interface{} {
+	bodies := suite.Recorder.Bodies()
+	suite.Require().NotEmpty(bodies, "No request was sent")
+	payload := map[string]interface{}{}
+	err := json.Unmarshal([]byte(bodies[len(bodies)-1]), &payload)
+	suite.Require().Nilf(err, "Failed to unmarshal the payload. %s", err)
+	return payload
+}
+
+// Suite Tools
+
+func (suite *OpenMessagingInboundSuite) SetupSuite() {
+	suite.Name = strings.TrimSuffix(reflect.TypeOf(*suite).Name(), "Suite")
+	suite.Logger = logger.Create("test",
+		&logger.FileStream{
+			Path:        fmt.Sprintf("./log/test-%s.log", strings.ToLower(suite.Name)),
+			Unbuffered:  true,
+			FilterLevel: logger.TRACE,
+		},
+	).Child("test", "test")
+	suite.Logger.Infof("Suite Start: %s %s", suite.Name, strings.Repeat("=", 80-14-len(suite.Name)))
+	suite.Recorder = NewRequestRecorder(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"id": "abcd-1234"}`))
+	})
+	suite.Server = httptest.NewServer(suite.Recorder)
+	suite.Integration = &gcloudcx.OpenMessagingIntegration{
+		ID:     uuid.MustParse("5a5a0d8a-0b5c-4c6e-8f27-5f4c6a3e9a01"),
+		Client: CreateTestClient(suite.Server.URL, suite.Logger),
+		Logger: suite.Logger,
+	}
+	suite.From = &gcloudcx.OpenMessageFrom{ID: "gildas@kkt", Type: "Email", Firstname: "Gildas", Lastname: "Cherruel"}
+}
+
+func (suite *OpenMessagingInboundSuite) TearDownSuite() {
+	suite.Server.Close()
+	if suite.T().Failed() {
+		suite.Logger.Warnf("At least one test failed, we are not cleaning")
+		suite.T().Log("At least one test failed, we are not cleaning")
+	} else {
+		suite.Logger.Infof("All tests succeeded, we are cleaning")
+	}
+	suite.Logger.Infof("Suite End: %s %s", suite.Name, strings.Repeat("=", 80-12-len(suite.Name)))
+	suite.Logger.Close()
+}
+
+func (suite *OpenMessagingInboundSuite) BeforeTest(suiteName, testName string) {
+	suite.Logger.Infof("Test Start: %s %s", testName, strings.Repeat("-", 80-13-len(testName)))
+	suite.Start = time.Now()
+	suite.Recorder.Reset()
+}
+
+func (suite *OpenMessagingInboundSuite) AfterTest(suiteName, testName string) {
+	duration := time.Since(suite.Start)
+	suite.Logger.Record("duration", duration.String()).Infof("Test End: %s %s", testName, strings.Repeat("-", 80-11-len(testName)))
+}
@@ -1,6 +1,7 @@
 package gcloudcx
 
 import (
+	"context"
 	"encoding/json"
 	"net/url"
 	"strings"
@@ -54,30 +55,41 @@ func (integration *OpenMessagingIntegration) Initialize(parameters ...interface{
 }
 
 // FetchOpenMessagingIntegrations Fetches all OpenMessagingIntegration object
+//
+// All the pages are fetched
 func FetchOpenMessagingIntegrations(parameters ...interface{}) ([]*OpenMessagingIntegration, error) {
 	client, logger, _, err := parseParameters(nil, parameters...)
 	if err != nil {
 		return nil, err
 	}
-	response := struct {
-		Integrations []*OpenMessagingIntegration `json:"entities"`
-		PageSize     int                         `json:"pageSize"`
-		PageNumber   int                         `json:"pageNumber"`
-		PageCount    int                         `json:"pageCount"`
-		PageTotal    int                         `json:"total"`
-		FirstURI     string                      `json:"firstUri"`
-		SelfURI      string                      `json:"selfUri"`
-		LastURI      string                      `json:"lastUri"`
-	}{}
-	if err = client.Get("/conversations/messaging/integrations/open", &response); err != nil {
-		return nil, err
-	}
-	logger.Record("response", response).Infof("Got a response")
-	for _, integration := range response.Integrations {
-		integration.Client = client
-		integration.Logger = logger.Child("openmessagingintegration", "openmessagingintegration", "openmesssagingintegration", integration.ID)
+	integrations := []*OpenMessagingIntegration{}
+	page := 1
+	for {
+		response := struct {
+			Integrations []*OpenMessagingIntegration `json:"entities"`
+			PageSize     int                         `json:"pageSize"`
+			PageNumber   int                         `json:"pageNumber"`
+			PageCount    int                         `json:"pageCount"`
+			PageTotal    int                         `json:"total"`
+			FirstURI     string                      `json:"firstUri"`
+			SelfURI      string                      `json:"selfUri"`
+			LastURI      string                      `json:"lastUri"`
+		}{}
+		if err = client.Get(NewURI("/conversations/messaging/integrations/open?pageSize=100&pageNumber=%d", page), &response); err != nil {
+			return nil, err
+		}
+		logger.Record("response", response).Infof("Got a response")
+		for _, integration := range response.Integrations {
+			integration.Client = client
+			integration.Logger = logger.Child("openmessagingintegration", "openmessagingintegration", "openmesssagingintegration", integration.ID)
+		}
+		integrations = append(integrations, response.Integrations...)
+		if page >= response.PageCount {
+			break
+		}
+		page++
 	}
-	return response.Integrations, nil
+	return integrations, nil
 }
 
 // FetchOpenMessagingIntegration Fetches an OpenMessagingIntegration object
@@ -157,9 +169,83 @@ func (integration *OpenMessagingIntegration) Create(name string, webhookURL *url
 	}
 	integration.Logger.Record("response", response).Debugf("Created integration %#v", response)
 	integration.ID = response.ID
+	integration.Name = name
+	integration.WebhookURL = webhookURL
+	integration.WebhookToken = token
+	integration.CreateStatus = response.CreateStatus
 	return nil
 }
 
+// CreateAndWait creates a new OpenMessaging Integration and waits until GENESYS Cloud completed its creation
+//
+// If timeout is 0, the context is used as is
+func (integration *OpenMessagingIntegration) CreateAndWait(ctx context.Context, name string, webhookURL *url.URL, token string, timeout time.Duration) error {
+	if err := integration.Create(name, webhookURL, token); err != nil {
+		return err
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return integration.WaitUntilCreated(ctx, 0)
+}
+
+// Refresh fetches the current state of this integration
+func (integration *OpenMessagingIntegration) Refresh() error {
+	if integration.ID == uuid.Nil {
+		return errors.ArgumentMissing.With("ID").WithStack()
+	}
+	response := &OpenMessagingIntegration{}
+	if err := integration.Client.Get(NewURI("/conversations/messaging/integrations/open/%s", integration.ID), &response); err != nil {
+		return err
+	}
+	integration.Name = response.Name
+	integration.WebhookURL = response.WebhookURL
+	integration.Recipient = response.Recipient
+	integration.SupportedContent = response.SupportedContent
+	integration.DateModified = response.DateModified
+	integration.ModifiedBy = response.ModifiedBy
+	integration.CreateStatus = response.CreateStatus
+	integration.CreateError = response.CreateError
+	return nil
+}
+
+// IsCreated tells if GENESYS Cloud completed the creation of this integration
+func (integration *OpenMessagingIntegration) IsCreated() bool {
+	return integration.CreateStatus == "Completed"
+}
+
+// WaitUntilCreated polls this integration until its CreateStatus is Completed or Error, or the context is done
+//
+// If the creation failed, the CreateError is returned
+func (integration *OpenMessagingIntegration) WaitUntilCreated(ctx context.Context, pollInterval time.Duration) error {
+	if pollInterval <= 0 {
+		pollInterval = 2 * time.Second
+	}
+	log := integration.Logger.Scope("wait")
+	for {
+		switch integration.CreateStatus {
+		case "Completed":
+			return nil
+		case "Error":
+			if integration.CreateError != nil {
+				return errors.CreationFailed.Wrap(integration.CreateError)
+			}
+			return errors.CreationFailed.With("integration", integration.ID.String()).WithStack()
+		}
+		select {
+		case <-ctx.Done():
+			return errors.WithStack(ctx.Err())
+		case <-time.After(pollInterval):
+		}
+		if err := integration.Refresh(); err != nil {
+			return err
+		}
+		log.Debugf("Create status: %s", integration.CreateStatus)
+	}
+}
+
 // Delete deletes an OpenMessaging Integration
 //
 // If the integration was not created, nothing is done
@@ -174,30 +260,86 @@ func (integration *OpenMessagingIntegration) Delete() error {
 //
 // If the integration was not created, an error is return without reaching GENESYS Cloud
 func (integration *OpenMessagingIntegration) Update(name string, webhookURL *url.URL, token string) error {
+	return integration.UpdateWith(OpenMessagingIntegrationUpdate{
+		Name:         name,
+		WebhookURL:   webhookURL,
+		WebhookToken: token,
+	})
+}
+
+// OpenMessagingIntegrationUpdate describes a partial update of an OpenMessaging Integration
+//
+// Only the fields that are set are sent to GENESYS Cloud
+type OpenMessagingIntegrationUpdate struct {
+	Name             string
+	WebhookURL       *url.URL
+	WebhookToken     string
+	Recipient        *DomainEntityRef
+	SupportedContent *AddressableEntityRef
+}
+
+// UpdateWith updates some properties of an OpenMessaging Integration
+//
+// If the integration was not created, an error is return without reaching GENESYS Cloud
+func (integration *OpenMessagingIntegration) UpdateWith(update OpenMessagingIntegrationUpdate) error {
 	if integration.ID == uuid.Nil {
 		return errors.ArgumentMissing.With("ID").WithStack()
 	}
+	payload := struct {
+		Name             string                `json:"name,omitempty"`
+		Webhook          string                `json:"outboundNotificationWebhookUrl,omitempty"`
+		Token            string                `json:"outboundNotificationWebhookSignatureSecretToken,omitempty"`
+		Recipient        *DomainEntityRef      `json:"recipient,omitempty"`
+		SupportedContent *AddressableEntityRef `json:"supportedContent,omitempty"`
+	}{
+		Name:             update.Name,
+		Token:            update.WebhookToken,
+		Recipient:        update.Recipient,
+		SupportedContent: update.SupportedContent,
+	}
+	if update.WebhookURL != nil {
+		payload.Webhook = update.WebhookURL.String()
+	}
 	response := &OpenMessagingIntegration{}
 	err := integration.Client.Patch(
 		NewURI("/conversations/messaging/integrations/open/%s", integration.ID),
-		struct {
-			Name    string `json:"name"`
-			Webhook string `json:"outboundNotificationWebhookUrl"`
-			Token   string `json:"outboundNotificationWebhookSignatureSecretToken"`
-		}{
-			Name:    name,
-			Webhook: webhookURL.String(),
-			Token:   token,
-		},
+		payload,
 		&response,
 	)
 	if err != nil {
 		return errors.CreationFailed.Wrap(err)
 	}
 	integration.Logger.Record("response", response).Debugf("Updated integration %#v", response)
+	if len(update.Name) > 0 {
+		integration.Name = update.Name
+	}
+	if update.WebhookURL != nil {
+		integration.WebhookURL = update.WebhookURL
+	}
+	if len(update.WebhookToken) > 0 {
+		integration.WebhookToken = update.WebhookToken
+	}
+	if update.Recipient != nil {
+		integration.Recipient = update.Recipient
+	}
+	if update.SupportedContent != nil {
+		integration.SupportedContent = update.SupportedContent
+	}
+	integration.DateModified = response.DateModified
+	integration.ModifiedBy = response.ModifiedBy
 	return nil
 }
 
+// SetSupportedContent sets the Supported Content profile of this integration
+func (integration *OpenMessagingIntegration) SetSupportedContent(supportedContent Identifiable) error {
+	if supportedContent == nil {
+		return errors.ArgumentMissing.With("supportedContent").WithStack()
+	}
+	return integration.UpdateWith(OpenMessagingIntegrationUpdate{
+		SupportedContent: &AddressableEntityRef{ID: supportedContent.GetID()},
+	})
+}
+
 // SendInboundTextMessage sends a text message from the middleware to GENESYS Cloud
 //
 // See https://developer.genesys.cloud/api/digital/openmessaging/inboundMessages#send-an-inbound-open-message
@@ -227,9 +369,26 @@ func (integration *OpenMessagingIntegration) SendInboundTextMessage(from *OpenMe
 //
 // See https://developer.genesys.cloud/api/digital/openmessaging/inboundMessages#inbound-message-with-attached-photo
 func (integration *OpenMessagingIntegration) SendInboundImageMessage(from *OpenMessageFrom, messageID, text string, imageMimeType string, imageURL *url.URL) (*OpenMessageResult, error) {
+	return integration.SendInboundAttachmentMessage(from, messageID, text, &OpenMessageAttachment{
+		Type: "Image",
+		Mime: imageMimeType,
+		URL:  imageURL,
+	})
+}
+
+// SendInboundAttachmentMessage sends a message with an attachment (image, video, audio, file) from the middleware to GENESYS Cloud
+//
+// See https://developer.genesys.cloud/api/digital/openmessaging/inboundMessages#inbound-message-with-attached-photo
+func (integration *OpenMessagingIntegration) SendInboundAttachmentMessage(from *OpenMessageFrom, messageID, text string, attachment *OpenMessageAttachment) (*OpenMessageResult, error) {
 	if integration.ID == uuid.Nil {
 		return nil, errors.ArgumentMissing.With("ID").WithStack()
 	}
+	if attachment == nil {
+		return nil, errors.ArgumentMissing.With("attachment").WithStack()
+	}
+	if attachment.URL == nil {
+		return nil, errors.ArgumentMissing.With("attachment.url").WithStack()
+	}
 	result := &OpenMessageResult{}
 	err := integration.Client.Post(
 		"/conversations/messages/inbound/open",
@@ -243,14 +402,7 @@ func (integration *OpenMessagingIntegration) SendInboundImageMessage(from *OpenM
 			Type: "Text",
 			Text: text,
 			Content: []*OpenMessageContent{
-				{
-					Type: "Attachment",
-					Attachment: &OpenMessageAttachment{
-						Type: "Image",
-						Mime: imageMimeType,
-						URL:  imageURL,
-					},
-				},
+				NewAttachmentContent(attachment),
 			},
 		},
 		&result,
@@ -258,32 +410,142 @@ func (integration *OpenMessagingIntegration) SendInboundImageMessage(from *OpenM
 	return result, err
 }
 
-// SendOutboundMessage sends a message from GENESYS Cloud to the middleware
+// OpenMessageReceiptOptions contains the optional parts of a receipt
+type OpenMessageReceiptOptions struct {
+	Reasons         []*StatusReason // Failed receipts should come with at least one reason
+	RelatedMessages []*OpenMessage  // The other outbound messages the receipt is about (only their ID is sent)
+}
+
+// SendInboundReceipt sends a receipt (delivered, read, failed, ...) about an outbound message from the middleware to GENESYS Cloud
 //
-// The message can be only text as it is sent bia the AgentLess Message API.
+// status is one of Delivered, Read, Failed, Published, Removed.
+// options is optional, it gives the reasons and the related messages of the receipt.
 //
-// This is mainly for debugging purposes
+// See https://developer.genesys.cloud/api/digital/openmessaging/receipts
+func (integration *OpenMessagingIntegration) SendInboundReceipt(message *OpenMessage, status string, isFinal bool, options *OpenMessageReceiptOptions) (*OpenMessageResult, error) {
+	if integration.ID == uuid.Nil {
+		return nil, errors.ArgumentMissing.With("ID").WithStack()
+	}
+	if message == nil || len(message.ID) == 0 {
+		return nil, errors.ArgumentMissing.With("message").WithStack()
+	}
+	if message.Channel == nil || message.Channel.To == nil {
+		return nil, errors.ArgumentMissing.With("message.channel.to").WithStack()
+	}
+	if !Contains(status, []string{"Delivered", "Read", "Failed", "Published", "Removed"}) {
+		return nil, errors.ArgumentInvalid.With("status", status).WithStack()
+	}
+	if options == nil {
+		options = &OpenMessageReceiptOptions{}
+	}
+	var related []*OpenMessage
+	for _, relatedMessage := range options.RelatedMessages {
+		if relatedMessage == nil || len(relatedMessage.ID) == 0 {
+			return nil, errors.ArgumentMissing.With("relatedMessages.id").WithStack()
+		}
+		related = append(related, &OpenMessage{ID: relatedMessage.ID})
+	}
+	result := &OpenMessageResult{}
+	err := integration.Client.Post(
+		NewURI("/conversations/messages/%s/inbound/open/receipt", integration.ID),
+		&OpenMessage{
+			ID:        message.ID,
+			Direction: "Outbound",
+			Channel: NewOpenMessageChannel(
+				message.ID,
+				message.Channel.To,
+				nil,
+			),
+			Type:            "Receipt",
+			Status:          status,
+			IsFinalReceipt:  isFinal,
+			Reasons:         options.Reasons,
+			RelatedMessages: related,
+		},
+		&result,
+	)
+	return result, err
+}
+
+// SendInboundEvents sends events (typing, presence) from the middleware to GENESYS Cloud
 //
-// See https://developer.genesys.cloud/api/digital/openmessaging/outboundMessages#send-an-agentless-outbound-text-message
-func (integration *OpenMessagingIntegration) SendOutboundMessage(destination, text string) (*AgentlessMessageResult, error) {
+// See https://developer.genesys.cloud/api/digital/openmessaging/inboundEventMessages
+func (integration *OpenMessagingIntegration) SendInboundEvents(from *OpenMessageFrom, events ...*OpenMessageEvent) (*OpenMessageResult, error) {
 	if integration.ID == uuid.Nil {
 		return nil, errors.ArgumentMissing.With("ID").WithStack()
 	}
-	result := &AgentlessMessageResult{}
+	if len(events) == 0 {
+		return nil, errors.ArgumentMissing.With("events").WithStack()
+	}
+	result := &OpenMessageResult{}
 	err := integration.Client.Post(
-		"/conversations/messages/agentless",
-		AgentlessMessage{
-			From:          integration.ID.String(),
-			To:            destination,
-			MessengerType: "Open",
-			Text:          text,
+		NewURI("/conversations/messages/%s/inbound/open/event", integration.ID),
+		&OpenMessage{
+			Direction: "Inbound",
+			Channel: NewOpenMessageChannel(
+				"",
+				&OpenMessageTo{ ID: integration.ID.String() },
+				from,
+			),
+			Type:   "Event",
+			Events: events,
 		},
 		&result,
 	)
-	if err != nil {
-		return nil, err
+	return result, err
+}
+
+// SendTypingIndicator tells GENESYS Cloud the customer is typing
+func (integration *OpenMessagingIntegration) SendTypingIndicator(from *OpenMessageFrom) (*OpenMessageResult, error) {
+	return integration.SendInboundEvents(from, NewTypingEvent(0))
+}
+
+// SendInboundStructuredMessage sends a structured message (quick replies, cards, location, ...) from the middleware to GENESYS Cloud
+//
+// See https://developer.genesys.cloud/api/digital/openmessaging/inboundMessages
+func (integration *OpenMessagingIntegration) SendInboundStructuredMessage(from *OpenMessageFrom, messageID, text string, contents ...*OpenMessageContent) (*OpenMessageResult, error) {
+	if integration.ID == uuid.Nil {
+		return nil, errors.ArgumentMissing.With("ID").WithStack()
+	}
+	if len(contents) == 0 {
+		return nil, errors.ArgumentMissing.With("contents").WithStack()
+	}
+	result := &OpenMessageResult{}
+	err := integration.Client.Post(
+		"/conversations/messages/inbound/open",
+		&OpenMessage{
+			Direction: "Inbound",
+			Channel: NewOpenMessageChannel(
+				messageID,
+				&OpenMessageTo{ ID: integration.ID.String() },
+				from,
+			),
+			Type:    "Structured",
+			Text:    text,
+			Content: contents,
+		},
+		&result,
+	)
+	return result, err
+}
+
+// SendOutboundMessage sends a message from GENESYS Cloud to the middleware
+//
+// The message can be only text as it is sent bia the AgentLess Message API.
+//
+// This is mainly for debugging purposes
+//
+// See https://developer.genesys.cloud/api/digital/openmessaging/outboundMessages#send-an-agentless-outbound-text-message
+func (integration *OpenMessagingIntegration) SendOutboundMessage(destination, text string) (*AgentlessMessageResult, error) {
+	if integration.ID == uuid.Nil {
+		return nil, errors.ArgumentMissing.With("ID").WithStack()
 	}
-	return result, nil
+	return integration.Client.SendAgentlessMessage(&AgentlessMessage{
+		From:          integration.ID.String(),
+		To:            destination,
+		MessengerType: MessengerTypeOpen,
+		Text:          text,
+	})
 }
 
 // GetID gets the identifier of this
@@ -331,5 +593,3 @@ func (integration *OpenMessagingIntegration) UnmarshalJSON(payload []byte) (err
 	integration.WebhookURL = (*url.URL)(inner.W)
 	return
 }
-
-// TODO: There is also a PATCH method... we might want to provide some func
@@ -0,0 +1,262 @@
+package gcloudcx_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gildas/go-errors"
+	"github.com/gildas/go-gcloudcx"
+	"github.com/gildas/go-logger"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+type OpenMessagingLifecycleSuite struct {
+	suite.Suite
+	Name   string
+	Logger *logger.Logger
+	Start  time.Time
+
+	IntegrationID uuid.UUID
+	Recorder      *RequestRecorder
+	Server        *httptest.Server
+	Client        *gcloudcx.Client
+	Statuses      *statusSequence
+}
+
+// statusSequence gives the createStatus returned by each request, the last one is repeated
+type statusSequence struct {
+	statuses []string
+	mutex    sync.Mutex
+}
+
+func TestOpenMessagingLifecycleSuite(t *testing.T) {
+	suite.Run(t, new(OpenMessagingLifecycleSuite))
+}
+
+func (suite *OpenMessagingLifecycleSuite) TestCanCreateAndWaitWhenAlreadyCompleted() {
+	suite.setStatuses("Completed")
+	integration := suite.newIntegration()
+	webhookURL, _ := url.Parse("https://www.acme.com/hook")
+	err := integration.CreateAndWait(context.Background(), "TEST-GO-GCLOUDCX", webhookURL, "DEADBEEF", time.Second)
+	suite.Require().Nilf(err, "Failed to create integration. %s", err)
+	suite.Assert().Equal(suite.IntegrationID, integration.ID)
+	suite.Assert().True(integration.IsCreated())
+	suite.Assert().Equal([]string{"POST /api/v2/conversations/messaging/integrations/open"}, suite.Recorder.Requests(), "There should be no polling")
+	suite.Assert().JSONEq(`{"name":"TEST-GO-GCLOUDCX","outboundNotificationWebhookUrl":"https://www.acme.com/hook","outboundNotificationWebhookSignatureSecretToken":"DEADBEEF"}`, suite.Recorder.Bodies()[0])
+}
+
+func (suite *OpenMessagingLifecycleSuite) TestCanWaitUntilCreated() {
+	suite.setStatuses("Initiated", "Initiated", "Completed")
+	integration := suite.newIntegration()
+	integration.ID = suite.IntegrationID
+	integration.CreateStatus = "Initiated"
+	err := integration.WaitUntilCreated(context.Background(), 10*time.Millisecond)
+	suite.Require().Nilf(err, "Failed to wait for integration. %s", err)
+	suite.Assert().True(integration.IsCreated())
+	suite.Assert().Equal("TEST-GO-GCLOUDCX", integration.Name)
+	suite.Assert().Len(suite.Recorder.Requests(), 3, "The integration should have been polled until Completed")
+}
+
+func (suite *OpenMessagingLifecycleSuite) TestShouldFailWaitingWhenCreationFailed() {
+	suite.setStatuses("Initiated", "Error")
+	integration := suite.newIntegration()
+	integration.ID = suite.IntegrationID
+	integration.CreateStatus = "Initiated"
+	err := integration.WaitUntilCreated(context.Background(), 10*time.Millisecond)
+	suite.Require().NotNil(err, "Waiting should have failed")
+	suite.Assert().True(errors.Is(err, errors.CreationFailed), "Error should be a CreationFailed")
+	suite.Assert().Contains(err.Error(), "Webhook is not reachable")
+	suite.Assert().False(integration.IsCreated())
+}
+
+func (suite *OpenMessagingLifecycleSuite) TestShouldTimeoutWaitingForCreation() {
+	suite.setStatuses("Initiated")
+	integration := suite.newIntegration()
+	webhookURL, _ := url.Parse("https://www.acme.com/hook")
+	err := integration.CreateAndWait(context.Background(), "TEST-GO-GCLOUDCX", webhookURL, "DEADBEEF", 50*time.Millisecond)
+	suite.Require().NotNil(err, "Waiting should have timed out")
+	suite.Assert().True(errors.Is(err, context.DeadlineExceeded), "Error should be a DeadlineExceeded")
+	suite.Assert().Equal(suite.IntegrationID, integration.ID, "The integration should still be created")
+
+	suite.Recorder.Reset()
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err = integration.WaitUntilCreated(ctx, 20*time.Millisecond)
+	suite.Require().NotNil(err, "Waiting should have timed out")
+	suite.Assert().True(errors.Is(err, context.DeadlineExceeded), "Error should be a DeadlineExceeded")
+	suite.Assert().NotEmpty(suite.Recorder.Requests(), "The integration should have been polled")
+}
+
+func (suite *OpenMessagingLifecycleSuite) TestCanUpdatePartially() {
+	integration := suite.newIntegration()
+	integration.ID = suite.IntegrationID
+	integration.Name = "TEST-GO-GCLOUDCX"
+	integration.WebhookToken = "DEADBEEF"
+
+	err := integration.UpdateWith(gcloudcx.OpenMessagingIntegrationUpdate{Name: "TEST-GO-GCLOUDCX-2"})
+	suite.Require().Nilf(err, "Failed to update integration. %s", err)
+	suite.Assert().Equal([]string{"PATCH /api/v2/conversations/messaging/integrations/open/" + suite.IntegrationID.String()}, suite.Recorder.Requests())
+	suite.Assert().JSONEq(`{"name":"TEST-GO-GCLOUDCX-2"}`, suite.Recorder.Bodies()[0])
+	suite.Assert().Equal("TEST-GO-GCLOUDCX-2", integration.Name)
+	suite.Assert().Equal("DEADBEEF", integration.WebhookToken, "The token should not have changed")
+
+	suite.Recorder.Reset()
+	webhookURL, _ := url.Parse("https://www.acme.com/hook2")
+	err = integration.UpdateWith(gcloudcx.OpenMessagingIntegrationUpdate{WebhookURL: webhookURL, WebhookToken: "BADC0FFEE"})
+	suite.Require().Nilf(err, "Failed to update integration. %s", err)
+	suite.Assert().JSONEq(`{"outboundNotificationWebhookUrl":"https://www.acme.com/hook2","outboundNotificationWebhookSignatureSecretToken":"BADC0FFEE"}`, suite.Recorder.Bodies()[0])
+	suite.Assert().Equal("TEST-GO-GCLOUDCX-2", integration.Name, "The name should not have changed")
+	suite.Assert().Equal("BADC0FFEE", integration.WebhookToken)
+	suite.Assert().Equal("https://www.acme.com/hook2", integration.WebhookURL.String())
+}
+
+func (suite *OpenMessagingLifecycleSuite) TestShouldNotUpdateIntegrationWithoutID() {
+	err := suite.newIntegration().UpdateWith(gcloudcx.OpenMessagingIntegrationUpdate{Name: "Nope"})
+	suite.Assert().True(errors.Is(err, errors.ArgumentMissing), "Error should be an ArgumentMissing")
+	suite.Assert().Empty(suite.Recorder.Requests())
+}
+
+func (suite *OpenMessagingLifecycleSuite) TestCanSetSupportedContent() {
+	integration := suite.newIntegration()
+	integration.ID = suite.IntegrationID
+	supportedContent := gcloudcx.SupportedContent{ID: uuid.MustParse("7b7b0d8a-0b5c-4c6e-8f27-5f4c6a3e9a02"), Name: "Images Only"}
+
+	err := integration.SetSupportedContent(supportedContent)
+	suite.Require().Nilf(err, "Failed to set supported content. %s", err)
+	suite.Assert().JSONEq(fmt.Sprintf(`{"supportedContent":{"id":"%s"}}`, supportedContent.ID), suite.Recorder.Bodies()[0])
+	suite.Require().NotNil(integration.SupportedContent)
+	suite.Assert().Equal(supportedContent.ID, integration.SupportedContent.ID)
+
+	err = integration.SetSupportedContent(nil)
+	suite.Assert().True(errors.Is(err, errors.ArgumentMissing), "Error should be an ArgumentMissing")
+}
+
+func (suite *OpenMessagingLifecycleSuite) TestCanMatchSupportedContentMediaTypes() {
+	content := gcloudcx.SupportedContent{MediaTypes: &gcloudcx.SupportedContentMediaTypes{}}
+	content.MediaTypes.Allow.Inbound = []gcloudcx.SupportedContentMediaType{{Type: "image/*"}, {Type: "Video/MPEG"}}
+	content.MediaTypes.Allow.Outbound = []gcloudcx.SupportedContentMediaType{{Type: "*/*"}}
+
+	suite.Assert().True(content.AllowsInbound("image/png"))
+	suite.Assert().True(content.AllowsInbound("IMAGE/JPEG"), "Wildcards should be case insensitive")
+	suite.Assert().True(content.AllowsInbound("video/mpeg"), "Exact types should be case insensitive")
+	suite.Assert().False(content.AllowsInbound("video/mp4"))
+	suite.Assert().False(content.AllowsInbound("imagery/png"), "image/* should not match another type")
+	suite.Assert().True(content.AllowsOutbound("application/pdf"))
+	suite.Assert().False(gcloudcx.SupportedContent{}.AllowsInbound("image/png"), "A profile without media types allows nothing")
+	suite.Assert().False(gcloudcx.SupportedContent{}.AllowsOutbound("image/png"), "A profile without media types allows nothing")
+}
+
+func (suite *OpenMessagingLifecycleSuite) TestCanFetchSupportedContentByName() {
+	content, err := suite.Client.FetchSupportedContent("images only")
+	suite.Require().Nilf(err, "Failed to fetch supported content. %s", err)
+	suite.Assert().Equal("Images Only", content.Name)
+	suite.Assert().True(content.AllowsInbound("image/gif"))
+	suite.Assert().Equal([]string{
+		"GET /api/v2/conversations/messaging/supportedcontent?pageSize=100&pageNumber=1",
+		"GET /api/v2/conversations/messaging/supportedcontent?pageSize=100&pageNumber=2",
+	}, suite.Recorder.Requests())
+
+	suite.Recorder.Reset()
+	_, err = suite.Client.FetchSupportedContent("videos only")
+	suite.Assert().True(errors.Is(err, errors.NotFound), "Error should be a NotFound")
+}
+
+func (suite *OpenMessagingLifecycleSuite) newIntegration() *gcloudcx.OpenMessagingIntegration {
+	integration := &gcloudcx.OpenMessagingIntegration{}
+	err := integration.Initialize(suite.Client)
+	suite.Require().Nilf(err, "Failed to initialize integration. %s", err)
+	return integration
+}
+
+func (suite *OpenMessagingLifecycleSuite) setStatuses(statuses ...string) {
+	suite.Statuses.mutex.Lock()
+	defer suite.Statuses.mutex.Unlock()
+	suite.Statuses.statuses = statuses
+}
+
+func (sequence *statusSequence) next() string {
+	sequence.mutex.Lock()
+	defer sequence.mutex.Unlock()
+	status := sequence.statuses[0]
+	if len(sequence.statuses) > 1 {
+		sequence.statuses = sequence.statuses[1:]
+	}
+	return status
+}
+
+// Suite Tools
+
+func (suite *OpenMessagingLifecycleSuite) SetupSuite() {
+	suite.Name = strings.TrimSuffix(reflect.TypeOf(*suite).Name(), "Suite")
+	suite.Logger = logger.Create("test",
+		&logger.FileStream{
+			Path:        fmt.Sprintf("./log/test-%s.log", strings.ToLower(suite.Name)),
+			Unbuffered:  true,
+			FilterLevel: logger.TRACE,
+		},
+	).Child("test", "test")
+	suite.Logger.Infof("Suite Start: %s %s", suite.Name, strings.Repeat("=", 80-14-len(suite.Name)))
+	suite.IntegrationID = uuid.MustParse("6c6c0d8a-0b5c-4c6e-8f27-5f4c6a3e9a03")
+	suite.Statuses = &statusSequence{}
+	suite.Recorder = NewRequestRecorder(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		integrationPath := "/api/v2/conversations/messaging/integrations/open/" + suite.IntegrationID.String()
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v2/conversations/messaging/integrations/open":
+			_, _ = w.Write([]byte(fmt.Sprintf(`{"id": "%s", "createStatus": "%s"}`, suite.IntegrationID, suite.Statuses.next())))
+		case r.Method == http.MethodGet && r.URL.Path == integrationPath:
+			status := suite.Statuses.next()
+			createError := ""
+			if status == "Error" {
+				createError = `, "createError": {"status": 400, "code": "bad.request", "message": "Webhook is not reachable"}`
+			}
+			_, _ = w.Write([]byte(fmt.Sprintf(`{"id": "%s", "name": "TEST-GO-GCLOUDCX", "createStatus": "%s"%s}`, suite.IntegrationID, status, createError)))
+		case r.Method == http.MethodPatch && r.URL.Path == integrationPath:
+			_, _ = w.Write([]byte(fmt.Sprintf(`{"id": "%s", "dateModified": "2021-06-01T10:00:00.000Z"}`, suite.IntegrationID)))
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v2/conversations/messaging/supportedcontent":
+			if r.URL.Query().Get("pageNumber") == "1" {
+				_, _ = w.Write([]byte(`{"entities": [{"id": "8a8a0d8a-0b5c-4c6e-8f27-5f4c6a3e9a04", "name": "Everything"}], "pageCount": 2}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"entities": [{"id": "7b7b0d8a-0b5c-4c6e-8f27-5f4c6a3e9a02", "name": "Images Only", "mediaTypes": {"allow": {"inbound": [{"type": "image/*"}], "outbound": []}}}], "pageCount": 2}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"status": 404, "code": "not.found", "message": "not found"}`))
+		}
+	})
+	suite.Server = httptest.NewServer(suite.Recorder)
+	suite.Client = CreateTestClient(suite.Server.URL, suite.Logger)
+}
+
+func (suite *OpenMessagingLifecycleSuite) TearDownSuite() {
+	suite.Server.Close()
+	if suite.T().Failed() {
+		suite.Logger.Warnf("At least one test failed, we are not cleaning")
+		suite.T().Log("At least one test failed, we are not cleaning")
+	} else {
+		suite.Logger.Infof("All tests succeeded, we are cleaning")
+	}
+	suite.Logger.Infof("Suite End: %s %s", suite.Name, strings.Repeat("=", 80-12-len(suite.Name)))
+	suite.Logger.Close()
+}
+
+func (suite *OpenMessagingLifecycleSuite) BeforeTest(suiteName, testName string) {
+	suite.Logger.Infof("Test Start: %s %s", testName, strings.Repeat("-", 80-13-len(testName)))
+	suite.Start = time.Now()
+	suite.Recorder.Reset()
+	suite.setStatuses("Initiated")
+}
+
+func (suite *OpenMessagingLifecycleSuite) AfterTest(suiteName, testName string) {
+	duration := time.Since(suite.Start)
+	suite.Logger.Record("duration", duration.String()).Infof("Test End: %s %s", testName, strings.Repeat("-", 80-11-len(testName)))
+}
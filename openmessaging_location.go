@@ -0,0 +1,47 @@
+package gcloudcx
+
+import (
+	"encoding/json"
+	"net/url"
+
+	"github.com/gildas/go-core"
+	"github.com/gildas/go-errors"
+)
+
+// OpenMessageLocation describes a location shared in an OpenMessage
+type OpenMessageLocation struct {
+	Latitude  float64  `json:"latitude"`
+	Longitude float64  `json:"longitude"`
+	Address   string   `json:"address,omitempty"`
+	Text      string   `json:"text,omitempty"`
+	URL       *url.URL `json:"-"`
+}
+
+// MarshalJSON marshals this into JSON
+func (location OpenMessageLocation) MarshalJSON() ([]byte, error) {
+	type surrogate OpenMessageLocation
+	data, err := json.Marshal(struct {
+		surrogate
+		U *core.URL `json:"url,omitempty"`
+	}{
+		surrogate: surrogate(location),
+		U:         (*core.URL)(location.URL),
+	})
+	return data, errors.JSONMarshalError.Wrap(err)
+}
+
+// UnmarshalJSON unmarshals JSON into this
+func (location *OpenMessageLocation) UnmarshalJSON(payload []byte) (err error) {
+	type surrogate OpenMessageLocation
+	var inner struct {
+		surrogate
+		U *core.URL `json:"url"`
+	}
+
+	if err = json.Unmarshal(payload, &inner); err != nil {
+		return errors.JSONUnmarshalError.Wrap(err)
+	}
+	*location = OpenMessageLocation(inner.surrogate)
+	location.URL = (*url.URL)(inner.U)
+	return
+}
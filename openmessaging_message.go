@@ -4,9 +4,12 @@ type OpenMessage struct {
 	ID              string                `json:"id,omitempty"`
 	Channel         *OpenMessageChannel   `json:"channel"`
 	Direction       string                `json:"direction"`
-	Type            string                `json:"type"` // Text, Structured, Receipt
+	Type            string                `json:"type"` // Text, Structured, Receipt, Event
 	Text            string                `json:"text"`
+	Status          string                `json:"status,omitempty"` // Receipt only: Delivered, Read, Failed, Published, Removed
+	IsFinalReceipt  bool                  `json:"isFinalReceipt,omitempty"`
 	Content         []*OpenMessageContent `json:"content,omitempty"`
+	Events          []*OpenMessageEvent   `json:"events,omitempty"`
 	RelatedMessages []*OpenMessage        `json:"relatedMessages,omitempty"`
 	Reasons         []*StatusReason       `json:"reasons,omitempty"`
 }
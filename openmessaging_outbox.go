@@ -0,0 +1,328 @@
+package gcloudcx
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gildas/go-errors"
+	"github.com/gildas/go-logger"
+)
+
+// OpenMessageOutbox sends inbound OpenMessages to GENESYS Cloud reliably
+//
+// Messages are persisted in a Store before being sent, and are retried with an exponential backoff until they are sent
+// or their error is permanent (HTTP 4xx other than 401, 403, 408 and 429) or MaxAttempts is reached, in which case OnFailed is called.
+// A failed message is forgotten, so it can be enqueued again.
+//
+// Messages are deduplicated by their Channel.MessageID, the IDs of the sent messages are kept in the Store for the DedupWindow.
+// The messages of a given sender (Channel.From.ID)
+// are sent in the order they were enqueued. A message is not sent until the previous messages of its sender are sent or failed.
+type OpenMessageOutbox struct {
+	Integration *OpenMessagingIntegration
+	Store       OpenMessageOutboxStore
+	MaxAttempts int           // Default: 5
+	MinBackoff  time.Duration // Default: 1 second
+	MaxBackoff  time.Duration // Default: 5 minutes
+	DedupWindow time.Duration // How long the ID of a sent message is remembered, Default: 24 hours
+	OnSent      func(entry OpenMessageOutboxEntry, result *OpenMessageResult)
+	OnFailed    func(entry OpenMessageOutboxEntry, err error)
+	Logger      *logger.Logger
+	queues      map[string][]*OpenMessageOutboxEntry
+	known       map[string]time.Time
+	sequence    uint64
+	mutex       sync.Mutex
+	processing  sync.Mutex
+}
+
+// OpenMessageOutboxEntry describes a message waiting in an OpenMessageOutbox
+type OpenMessageOutboxEntry struct {
+	Message     *OpenMessage `json:"message"`
+	Sender      string       `json:"sender"`
+	Sequence    uint64       `json:"sequence"`
+	Attempts    int          `json:"attempts"`
+	EnqueuedAt  time.Time    `json:"enqueuedAt"`
+	NextAttempt time.Time    `json:"nextAttempt"`
+	LastError   string       `json:"lastError,omitempty"`
+}
+
+// NewOpenMessageOutbox creates a new OpenMessageOutbox for the given integration
+//
+// The messages that are still in the store are loaded. If store is nil, messages are kept in memory only.
+func NewOpenMessageOutbox(integration *OpenMessagingIntegration, store OpenMessageOutboxStore) (*OpenMessageOutbox, error) {
+	if integration == nil {
+		return nil, errors.ArgumentMissing.With("integration").WithStack()
+	}
+	if store == nil {
+		store = NewMemoryOpenMessageOutboxStore()
+	}
+	outbox := &OpenMessageOutbox{
+		Integration: integration,
+		Store:       store,
+		MaxAttempts: 5,
+		MinBackoff:  1 * time.Second,
+		MaxBackoff:  5 * time.Minute,
+		DedupWindow: 24 * time.Hour,
+		Logger:      logger.CreateIfNil(integration.Logger, "gcloudcx").Child("outbox", "outbox"),
+		queues:      map[string][]*OpenMessageOutboxEntry{},
+		known:       map[string]time.Time{},
+	}
+	entries, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	sent, err := store.LoadSent()
+	if err != nil {
+		return nil, err
+	}
+	for id, sentAt := range sent {
+		outbox.known[id] = sentAt
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Sequence < entries[j].Sequence })
+	pending := 0
+	for _, entry := range entries {
+		if _, found := sent[entry.Message.Channel.MessageID]; found {
+			_ = store.Delete(entry.Message.Channel.MessageID) // sent before the entry could be deleted
+			continue
+		}
+		outbox.queues[entry.Sender] = append(outbox.queues[entry.Sender], entry)
+		outbox.known[entry.Message.Channel.MessageID] = time.Time{}
+		if entry.Sequence > outbox.sequence {
+			outbox.sequence = entry.Sequence
+		}
+		pending++
+	}
+	if pending > 0 {
+		outbox.Logger.Infof("Loaded %d pending messages", pending)
+	}
+	return outbox, nil
+}
+
+// Enqueue stores a message to be sent to GENESYS Cloud
+//
+// The message must have a Channel with a MessageID and a From.
+// If a message with the same MessageID is pending or was sent recently, the message is ignored and false is returned.
+func (outbox *OpenMessageOutbox) Enqueue(message *OpenMessage) (bool, error) {
+	if message == nil {
+		return false, errors.ArgumentMissing.With("message").WithStack()
+	}
+	if message.Channel == nil || len(message.Channel.MessageID) == 0 {
+		return false, errors.ArgumentMissing.With("channel.messageId").WithStack()
+	}
+	if message.Channel.From == nil || len(message.Channel.From.ID) == 0 {
+		return false, errors.ArgumentMissing.With("channel.from").WithStack()
+	}
+	message.Direction = "Inbound"
+	if message.Channel.To == nil {
+		message.Channel.To = &OpenMessageTo{ID: outbox.Integration.ID.String()}
+	}
+
+	outbox.mutex.Lock()
+	defer outbox.mutex.Unlock()
+	if _, found := outbox.known[message.Channel.MessageID]; found {
+		outbox.Logger.Debugf("Message %s is a duplicate, ignoring", message.Channel.MessageID)
+		return false, nil
+	}
+	outbox.sequence++
+	now := time.Now().UTC()
+	entry := &OpenMessageOutboxEntry{
+		Message:     message,
+		Sender:      message.Channel.From.ID,
+		Sequence:    outbox.sequence,
+		EnqueuedAt:  now,
+		NextAttempt: now,
+	}
+	if err := outbox.Store.Save(entry); err != nil {
+		return false, err
+	}
+	outbox.queues[entry.Sender] = append(outbox.queues[entry.Sender], entry)
+	outbox.known[message.Channel.MessageID] = time.Time{}
+	return true, nil
+}
+
+// Pending gets the number of messages waiting to be sent
+func (outbox *OpenMessageOutbox) Pending() int {
+	outbox.mutex.Lock()
+	defer outbox.mutex.Unlock()
+	count := 0
+	for _, queue := range outbox.queues {
+		count += len(queue)
+	}
+	return count
+}
+
+// Process sends the messages that are due at the given time
+//
+// Returns the number of messages that were sent successfully
+func (outbox *OpenMessageOutbox) Process(ctx context.Context, now time.Time) int {
+	outbox.processing.Lock()
+	defer outbox.processing.Unlock()
+
+	sent := 0
+	for _, sender := range outbox.senders() {
+		for {
+			if ctx.Err() != nil {
+				return sent
+			}
+			entry := outbox.head(sender)
+			if entry == nil || entry.NextAttempt.After(now) {
+				break
+			}
+			success, retrying := outbox.send(entry, now)
+			if success {
+				sent++
+			}
+			if retrying {
+				break // the next messages of this sender must wait
+			}
+		}
+	}
+	outbox.purge(now)
+	return sent
+}
+
+// Run processes the outbox at the given interval until the context is done
+//
+// The interval must be positive.
+func (outbox *OpenMessageOutbox) Run(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		return errors.ArgumentInvalid.With("interval", interval).WithStack()
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			outbox.Process(ctx, now.UTC())
+		}
+	}
+}
+
+// send sends an entry, tells if it was sent or if it will be retried
+func (outbox *OpenMessageOutbox) send(entry *OpenMessageOutboxEntry, now time.Time) (sent bool, retrying bool) {
+	log := outbox.Logger.Scope("send").Record("message", entry.Message.Channel.MessageID)
+	result := &OpenMessageResult{}
+	err := outbox.Integration.Client.Post("/conversations/messages/inbound/open", entry.Message, &result)
+
+	outbox.mutex.Lock()
+	entry.Attempts++
+	if err == nil {
+		outbox.remove(entry, now, true)
+		outbox.mutex.Unlock()
+		log.Debugf("Message sent after %d attempt(s)", entry.Attempts)
+		if outbox.OnSent != nil {
+			outbox.OnSent(*entry, result)
+		}
+		return true, false
+	}
+	entry.LastError = err.Error()
+	if isPermanentError(err) || entry.Attempts >= outbox.MaxAttempts {
+		outbox.remove(entry, now, false)
+		outbox.mutex.Unlock()
+		log.Errorf("Message failed after %d attempt(s)", entry.Attempts, err)
+		if outbox.OnFailed != nil {
+			outbox.OnFailed(*entry, err)
+		}
+		return false, false
+	}
+	entry.NextAttempt = now.Add(outbox.backoff(entry.Attempts))
+	if storeErr := outbox.Store.Save(entry); storeErr != nil {
+		log.Errorf("Failed to save message", storeErr)
+	}
+	outbox.mutex.Unlock()
+	log.Warnf("Message failed (attempt %d), retrying at %s: %s", entry.Attempts, entry.NextAttempt, err)
+	return false, true
+}
+
+// remove removes the head of a sender queue, the caller must hold the mutex
+//
+// Sent messages are remembered for the DedupWindow, failed messages are forgotten.
+func (outbox *OpenMessageOutbox) remove(entry *OpenMessageOutboxEntry, now time.Time, sent bool) {
+	queue := outbox.queues[entry.Sender]
+	if len(queue) > 0 && queue[0] == entry {
+		queue = queue[1:]
+	}
+	if len(queue) == 0 {
+		delete(outbox.queues, entry.Sender)
+	} else {
+		outbox.queues[entry.Sender] = queue
+	}
+	if sent {
+		outbox.known[entry.Message.Channel.MessageID] = now
+		if err := outbox.Store.MarkSent(entry.Message.Channel.MessageID, now); err != nil {
+			outbox.Logger.Errorf("Failed to remember message %s in the store", entry.Message.Channel.MessageID, err)
+		}
+	} else {
+		delete(outbox.known, entry.Message.Channel.MessageID)
+	}
+	if err := outbox.Store.Delete(entry.Message.Channel.MessageID); err != nil {
+		outbox.Logger.Errorf("Failed to delete message %s from the store", entry.Message.Channel.MessageID, err)
+	}
+}
+
+// purge forgets the IDs of the messages that were sent before the DedupWindow
+func (outbox *OpenMessageOutbox) purge(now time.Time) {
+	outbox.mutex.Lock()
+	defer outbox.mutex.Unlock()
+	purged := 0
+	for id, sentAt := range outbox.known {
+		if !sentAt.IsZero() && now.Sub(sentAt) > outbox.DedupWindow {
+			delete(outbox.known, id)
+			purged++
+		}
+	}
+	if purged == 0 {
+		return
+	}
+	if err := outbox.Store.PurgeSent(now.Add(-outbox.DedupWindow)); err != nil {
+		outbox.Logger.Errorf("Failed to purge the sent messages from the store", err)
+	}
+}
+
+func (outbox *OpenMessageOutbox) senders() []string {
+	outbox.mutex.Lock()
+	defer outbox.mutex.Unlock()
+	senders := make([]string, 0, len(outbox.queues))
+	for sender := range outbox.queues {
+		senders = append(senders, sender)
+	}
+	return senders
+}
+
+func (outbox *OpenMessageOutbox) head(sender string) *OpenMessageOutboxEntry {
+	outbox.mutex.Lock()
+	defer outbox.mutex.Unlock()
+	if queue := outbox.queues[sender]; len(queue) > 0 {
+		return queue[0]
+	}
+	return nil
+}
+
+func (outbox *OpenMessageOutbox) backoff(attempts int) time.Duration {
+	backoff := outbox.MinBackoff
+	for i := 1; i < attempts && backoff < outbox.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > outbox.MaxBackoff {
+		backoff = outbox.MaxBackoff
+	}
+	return backoff
+}
+
+// isPermanentError tells if retrying a request that failed with the given error is useless
+//
+// 401 and 403 are not permanent as they usually go away once the token is refreshed or the permissions are granted.
+func isPermanentError(err error) bool {
+	var apiError APIError
+	if errors.As(err, &apiError) {
+		switch apiError.Status {
+		case 401, 403, 408, 429:
+			return false
+		}
+		return apiError.Status >= 400 && apiError.Status < 500
+	}
+	return false
+}
@@ -0,0 +1,261 @@
+package gcloudcx
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gildas/go-errors"
+)
+
+// OpenMessageOutboxStore persists the entries of an OpenMessageOutbox
+type OpenMessageOutboxStore interface {
+	// Save saves or replaces an entry
+	Save(entry *OpenMessageOutboxEntry) error
+	// Delete deletes the entry of the given message
+	Delete(messageID string) error
+	// Load loads all the entries
+	Load() ([]*OpenMessageOutboxEntry, error)
+	// MarkSent remembers that the given message was sent (or failed for good), so it is not sent again
+	MarkSent(messageID string, sentAt time.Time) error
+	// LoadSent loads the IDs of the messages that were sent and when they were sent
+	LoadSent() (map[string]time.Time, error)
+	// PurgeSent forgets the messages that were sent before the given time
+	PurgeSent(before time.Time) error
+}
+
+// MemoryOpenMessageOutboxStore stores the entries of an OpenMessageOutbox in memory
+type MemoryOpenMessageOutboxStore struct {
+	entries map[string]*OpenMessageOutboxEntry
+	sent    map[string]time.Time
+	mutex   sync.Mutex
+}
+
+// FileOpenMessageOutboxStore stores the entries of an OpenMessageOutbox as JSON files in a folder
+//
+// The IDs of the sent messages are stored in the "sent" subfolder
+type FileOpenMessageOutboxStore struct {
+	Path string
+}
+
+// NewMemoryOpenMessageOutboxStore creates a new MemoryOpenMessageOutboxStore
+func NewMemoryOpenMessageOutboxStore() *MemoryOpenMessageOutboxStore {
+	return &MemoryOpenMessageOutboxStore{entries: map[string]*OpenMessageOutboxEntry{}, sent: map[string]time.Time{}}
+}
+
+// Save saves or replaces an entry
+//   implements OpenMessageOutboxStore
+func (store *MemoryOpenMessageOutboxStore) Save(entry *OpenMessageOutboxEntry) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if store.entries == nil {
+		store.entries = map[string]*OpenMessageOutboxEntry{}
+	}
+	store.entries[entry.Message.Channel.MessageID] = entry
+	return nil
+}
+
+// Delete deletes the entry of the given message
+//   implements OpenMessageOutboxStore
+func (store *MemoryOpenMessageOutboxStore) Delete(messageID string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	delete(store.entries, messageID)
+	return nil
+}
+
+// Load loads all the entries
+//   implements OpenMessageOutboxStore
+func (store *MemoryOpenMessageOutboxStore) Load() ([]*OpenMessageOutboxEntry, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	entries := make([]*OpenMessageOutboxEntry, 0, len(store.entries))
+	for _, entry := range store.entries {
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// MarkSent remembers that the given message was sent
+//   implements OpenMessageOutboxStore
+func (store *MemoryOpenMessageOutboxStore) MarkSent(messageID string, sentAt time.Time) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if store.sent == nil {
+		store.sent = map[string]time.Time{}
+	}
+	store.sent[messageID] = sentAt
+	return nil
+}
+
+// LoadSent loads the IDs of the messages that were sent
+//   implements OpenMessageOutboxStore
+func (store *MemoryOpenMessageOutboxStore) LoadSent() (map[string]time.Time, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	sent := make(map[string]time.Time, len(store.sent))
+	for id, sentAt := range store.sent {
+		sent[id] = sentAt
+	}
+	return sent, nil
+}
+
+// PurgeSent forgets the messages that were sent before the given time
+//   implements OpenMessageOutboxStore
+func (store *MemoryOpenMessageOutboxStore) PurgeSent(before time.Time) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	for id, sentAt := range store.sent {
+		if sentAt.Before(before) {
+			delete(store.sent, id)
+		}
+	}
+	return nil
+}
+
+// NewFileOpenMessageOutboxStore creates a new FileOpenMessageOutboxStore in the given folder
+//
+// The folder is created if needed
+func NewFileOpenMessageOutboxStore(path string) (*FileOpenMessageOutboxStore, error) {
+	if len(path) == 0 {
+		return nil, errors.ArgumentMissing.With("path").WithStack()
+	}
+	if err := os.MkdirAll(filepath.Join(path, "sent"), 0700); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &FileOpenMessageOutboxStore{Path: path}, nil
+}
+
+// Save saves or replaces an entry
+//   implements OpenMessageOutboxStore
+func (store *FileOpenMessageOutboxStore) Save(entry *OpenMessageOutboxEntry) error {
+	payload, err := json.Marshal(entry)
+	if err != nil {
+		return errors.JSONMarshalError.Wrap(err)
+	}
+	filename := store.filename(entry.Message.Channel.MessageID)
+	if err = ioutil.WriteFile(filename+".tmp", payload, 0600); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.Rename(filename+".tmp", filename))
+}
+
+// Delete deletes the entry of the given message
+//   implements OpenMessageOutboxStore
+func (store *FileOpenMessageOutboxStore) Delete(messageID string) error {
+	if err := os.Remove(store.filename(messageID)); err != nil && !os.IsNotExist(err) {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// Load loads all the entries
+//   implements OpenMessageOutboxStore
+func (store *FileOpenMessageOutboxStore) Load() ([]*OpenMessageOutboxEntry, error) {
+	files, err := ioutil.ReadDir(store.Path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	entries := []*OpenMessageOutboxEntry{}
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		payload, err := ioutil.ReadFile(filepath.Join(store.Path, file.Name()))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		entry := &OpenMessageOutboxEntry{}
+		if err = json.Unmarshal(payload, entry); err != nil {
+			return nil, errors.JSONUnmarshalError.Wrap(err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// MarkSent remembers that the given message was sent
+//   implements OpenMessageOutboxStore
+func (store *FileOpenMessageOutboxStore) MarkSent(messageID string, sentAt time.Time) error {
+	payload, err := json.Marshal(sentMessage{ID: messageID, SentAt: sentAt})
+	if err != nil {
+		return errors.JSONMarshalError.Wrap(err)
+	}
+	return errors.WithStack(ioutil.WriteFile(store.sentFilename(messageID), payload, 0600))
+}
+
+// LoadSent loads the IDs of the messages that were sent
+//   implements OpenMessageOutboxStore
+func (store *FileOpenMessageOutboxStore) LoadSent() (map[string]time.Time, error) {
+	messages, err := store.loadSent()
+	if err != nil {
+		return nil, err
+	}
+	sent := make(map[string]time.Time, len(messages))
+	for _, message := range messages {
+		sent[message.ID] = message.SentAt
+	}
+	return sent, nil
+}
+
+// PurgeSent forgets the messages that were sent before the given time
+//   implements OpenMessageOutboxStore
+func (store *FileOpenMessageOutboxStore) PurgeSent(before time.Time) error {
+	messages, err := store.loadSent()
+	if err != nil {
+		return err
+	}
+	for _, message := range messages {
+		if message.SentAt.Before(before) {
+			if err := os.Remove(store.sentFilename(message.ID)); err != nil && !os.IsNotExist(err) {
+				return errors.WithStack(err)
+			}
+		}
+	}
+	return nil
+}
+
+// sentMessage is the content of the files in the "sent" subfolder
+type sentMessage struct {
+	ID     string    `json:"id"`
+	SentAt time.Time `json:"sentAt"`
+}
+
+func (store *FileOpenMessageOutboxStore) loadSent() ([]sentMessage, error) {
+	files, err := ioutil.ReadDir(filepath.Join(store.Path, "sent"))
+	if os.IsNotExist(err) {
+		return []sentMessage{}, nil
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	messages := []sentMessage{}
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		payload, err := ioutil.ReadFile(filepath.Join(store.Path, "sent", file.Name()))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		message := sentMessage{}
+		if err = json.Unmarshal(payload, &message); err != nil {
+			return nil, errors.JSONUnmarshalError.Wrap(err)
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+func (store *FileOpenMessageOutboxStore) sentFilename(messageID string) string {
+	return filepath.Join(store.Path, "sent", url.PathEscape(messageID)+".json")
+}
+
+func (store *FileOpenMessageOutboxStore) filename(messageID string) string {
+	return filepath.Join(store.Path, url.PathEscape(messageID)+".json")
+}
@@ -0,0 +1,295 @@
+package gcloudcx_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gildas/go-errors"
+	"github.com/gildas/go-gcloudcx"
+	"github.com/gildas/go-logger"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+type OpenMessagingOutboxSuite struct {
+	suite.Suite
+	Name   string
+	Logger *logger.Logger
+	Start  time.Time
+
+	Server   *httptest.Server
+	Received []string
+	Failures map[string]int // message ID -> HTTP status to return
+}
+
+func TestOpenMessagingOutboxSuite(t *testing.T) {
+	suite.Run(t, new(OpenMessagingOutboxSuite))
+}
+
+func (suite *OpenMessagingOutboxSuite) TestCanSendMessagesInOrder() {
+	outbox := suite.newOutbox(nil)
+	for _, id := range []string{"a-1", "a-2", "a-3"} {
+		queued, err := outbox.Enqueue(suite.newMessage("alice", id))
+		suite.Require().Nilf(err, "Failed to enqueue message. %s", err)
+		suite.Assert().True(queued)
+	}
+	queued, err := outbox.Enqueue(suite.newMessage("alice", "a-2"))
+	suite.Require().Nilf(err, "Failed to enqueue message. %s", err)
+	suite.Assert().False(queued, "Duplicate messages should be ignored")
+	suite.Assert().Equal(3, outbox.Pending())
+
+	sent := outbox.Process(context.Background(), time.Now())
+	suite.Assert().Equal(3, sent)
+	suite.Assert().Equal(0, outbox.Pending())
+	suite.Assert().Equal([]string{"a-1", "a-2", "a-3"}, suite.Received)
+
+	queued, _ = outbox.Enqueue(suite.newMessage("alice", "a-1"))
+	suite.Assert().False(queued, "Sent messages should be ignored")
+}
+
+func (suite *OpenMessagingOutboxSuite) TestShouldRetryAndKeepOrder() {
+	suite.Failures["b-1"] = http.StatusServiceUnavailable
+	outbox := suite.newOutbox(nil)
+	_, _ = outbox.Enqueue(suite.newMessage("bob", "b-1"))
+	_, _ = outbox.Enqueue(suite.newMessage("bob", "b-2"))
+	_, _ = outbox.Enqueue(suite.newMessage("carol", "c-1"))
+
+	now := time.Now()
+	sent := outbox.Process(context.Background(), now)
+	suite.Assert().Equal(1, sent, "Only carol's message should be sent")
+	sort.Strings(suite.Received)
+	suite.Assert().Equal([]string{"b-1", "c-1"}, suite.Received)
+	suite.Assert().Equal(2, outbox.Pending())
+
+	delete(suite.Failures, "b-1")
+	suite.Received = []string{}
+	suite.Assert().Equal(0, outbox.Process(context.Background(), now), "Messages should wait for the backoff")
+	sent = outbox.Process(context.Background(), now.Add(time.Minute))
+	suite.Assert().Equal(2, sent)
+	suite.Assert().Equal([]string{"b-1", "b-2"}, suite.Received)
+}
+
+func (suite *OpenMessagingOutboxSuite) TestShouldReportPermanentFailures() {
+	suite.Failures["d-1"] = http.StatusBadRequest
+	failed := []string{}
+	outbox := suite.newOutbox(nil)
+	outbox.OnFailed = func(entry gcloudcx.OpenMessageOutboxEntry, err error) {
+		failed = append(failed, entry.Message.Channel.MessageID)
+	}
+	_, _ = outbox.Enqueue(suite.newMessage("dave", "d-1"))
+	_, _ = outbox.Enqueue(suite.newMessage("dave", "d-2"))
+
+	sent := outbox.Process(context.Background(), time.Now())
+	suite.Assert().Equal(1, sent)
+	suite.Assert().Equal([]string{"d-1"}, failed)
+	suite.Assert().Equal(0, outbox.Pending())
+}
+
+func (suite *OpenMessagingOutboxSuite) TestCanEnqueueFailedMessageAgain() {
+	store := gcloudcx.NewMemoryOpenMessageOutboxStore()
+	suite.Failures["i-1"] = http.StatusBadRequest
+	outbox := suite.newOutbox(store)
+	_, _ = outbox.Enqueue(suite.newMessage("ivan", "i-1"))
+	suite.Require().Equal(0, outbox.Process(context.Background(), time.Now()))
+	sent, err := store.LoadSent()
+	suite.Require().Nilf(err, "Failed to load sent messages. %s", err)
+	suite.Assert().NotContains(sent, "i-1", "Failed messages should not be marked as sent")
+	entries, _ := store.Load()
+	suite.Assert().Empty(entries, "The failed entry should have been deleted")
+
+	delete(suite.Failures, "i-1")
+	queued, err := outbox.Enqueue(suite.newMessage("ivan", "i-1"))
+	suite.Require().Nilf(err, "Failed to enqueue message. %s", err)
+	suite.Assert().True(queued, "Failed messages can be enqueued again")
+	suite.Assert().Equal(1, outbox.Process(context.Background(), time.Now()))
+	suite.Assert().Equal([]string{"i-1", "i-1"}, suite.Received)
+}
+
+func (suite *OpenMessagingOutboxSuite) TestShouldRetryAuthorizationFailures() {
+	failed := []string{}
+	onFailed := func(entry gcloudcx.OpenMessageOutboxEntry, err error) {
+		failed = append(failed, entry.Message.Channel.MessageID)
+	}
+	suite.Failures["j-1"] = http.StatusUnauthorized
+	suite.Failures["k-1"] = http.StatusForbidden
+	unauthorized := suite.newOutbox(nil)
+	unauthorized.OnFailed = onFailed
+	forbidden := suite.newOutbox(nil)
+	forbidden.OnFailed = onFailed
+	_, _ = unauthorized.Enqueue(suite.newMessage("judy", "j-1"))
+	_, _ = forbidden.Enqueue(suite.newMessage("kim", "k-1"))
+
+	now := time.Now()
+	suite.Assert().Equal(0, unauthorized.Process(context.Background(), now))
+	suite.Assert().Equal(0, forbidden.Process(context.Background(), now))
+	suite.Assert().Empty(failed, "401 and 403 should be retried")
+	suite.Assert().Equal(1, unauthorized.Pending())
+	suite.Assert().Equal(1, forbidden.Pending())
+
+	delete(suite.Failures, "k-1")
+	suite.Assert().Equal(1, forbidden.Process(context.Background(), now.Add(time.Minute)), "The forbidden message should be sent once allowed")
+}
+
+func (suite *OpenMessagingOutboxSuite) TestShouldNotRunWithInvalidInterval() {
+	outbox := suite.newOutbox(nil)
+	for _, interval := range []time.Duration{0, -time.Second} {
+		err := outbox.Run(context.Background(), interval)
+		suite.Require().NotNil(err, "Run should fail with interval %s", interval)
+		suite.Assert().True(errors.Is(err, errors.ArgumentInvalid), "Error should be an ArgumentInvalid")
+	}
+}
+
+func (suite *OpenMessagingOutboxSuite) TestCanReloadPendingMessages() {
+	store, err := gcloudcx.NewFileOpenMessageOutboxStore(suite.T().TempDir())
+	suite.Require().Nilf(err, "Failed to create store. %s", err)
+	suite.Failures["e-1"] = http.StatusInternalServerError
+	outbox := suite.newOutbox(store)
+	_, _ = outbox.Enqueue(suite.newMessage("eve", "e-1"))
+	_, _ = outbox.Enqueue(suite.newMessage("eve", "e-2"))
+	outbox.Process(context.Background(), time.Now())
+	suite.Assert().Equal(2, outbox.Pending())
+
+	delete(suite.Failures, "e-1")
+	suite.Received = []string{}
+	reloaded := suite.newOutbox(store)
+	suite.Require().Equal(2, reloaded.Pending())
+	queued, _ := reloaded.Enqueue(suite.newMessage("eve", "e-1"))
+	suite.Assert().False(queued, "Reloaded messages should be deduplicated")
+	sent := reloaded.Process(context.Background(), time.Now().Add(time.Minute))
+	suite.Assert().Equal(2, sent)
+	suite.Assert().Equal([]string{"e-1", "e-2"}, suite.Received)
+}
+
+func (suite *OpenMessagingOutboxSuite) TestShouldRememberSentMessagesAfterRestart() {
+	folder := suite.T().TempDir()
+	store, err := gcloudcx.NewFileOpenMessageOutboxStore(folder)
+	suite.Require().Nilf(err, "Failed to create store. %s", err)
+	outbox := suite.newOutbox(store)
+	_, _ = outbox.Enqueue(suite.newMessage("frank", "f-1"))
+	suite.Require().Equal(1, outbox.Process(context.Background(), time.Now()))
+
+	restarted, err := gcloudcx.NewFileOpenMessageOutboxStore(folder)
+	suite.Require().Nilf(err, "Failed to create store. %s", err)
+	reloaded := suite.newOutbox(restarted)
+	suite.Assert().Equal(0, reloaded.Pending())
+	queued, err := reloaded.Enqueue(suite.newMessage("frank", "f-1"))
+	suite.Require().Nilf(err, "Failed to enqueue message. %s", err)
+	suite.Assert().False(queued, "Messages sent before the restart should be deduplicated")
+	suite.Assert().Equal([]string{"f-1"}, suite.Received)
+}
+
+func (suite *OpenMessagingOutboxSuite) TestShouldNotResendMessageMarkedAsSent() {
+	store := gcloudcx.NewMemoryOpenMessageOutboxStore()
+	message := suite.newMessage("grace", "g-1")
+	_ = store.Save(&gcloudcx.OpenMessageOutboxEntry{Message: message, Sender: "grace", Sequence: 1})
+	_ = store.MarkSent("g-1", time.Now().UTC()) // the outbox stopped before deleting the entry
+
+	outbox := suite.newOutbox(store)
+	suite.Assert().Equal(0, outbox.Pending())
+	entries, _ := store.Load()
+	suite.Assert().Empty(entries, "The sent entry should have been deleted")
+}
+
+func (suite *OpenMessagingOutboxSuite) TestShouldForgetSentMessagesAfterDedupWindow() {
+	folder := suite.T().TempDir()
+	store, err := gcloudcx.NewFileOpenMessageOutboxStore(folder)
+	suite.Require().Nilf(err, "Failed to create store. %s", err)
+	outbox := suite.newOutbox(store)
+	outbox.DedupWindow = time.Hour
+	_, _ = outbox.Enqueue(suite.newMessage("heidi", "h-1"))
+	now := time.Now().UTC()
+	suite.Require().Equal(1, outbox.Process(context.Background(), now))
+	sent, err := store.LoadSent()
+	suite.Require().Nilf(err, "Failed to load sent messages. %s", err)
+	suite.Assert().Contains(sent, "h-1")
+
+	outbox.Process(context.Background(), now.Add(2*time.Hour))
+	sent, err = store.LoadSent()
+	suite.Require().Nilf(err, "Failed to load sent messages. %s", err)
+	suite.Assert().Empty(sent, "Sent messages should be purged from the store after the DedupWindow")
+	queued, _ := suite.newOutbox(store).Enqueue(suite.newMessage("heidi", "h-1"))
+	suite.Assert().True(queued, "Messages sent before the DedupWindow can be sent again")
+}
+
+func (suite *OpenMessagingOutboxSuite) newOutbox(store gcloudcx.OpenMessageOutboxStore) *gcloudcx.OpenMessageOutbox {
+	integration := &gcloudcx.OpenMessagingIntegration{
+		ID:     uuid.MustParse("34071108-1569-4cb0-9137-a326b8a9e815"),
+		Client: CreateTestClient(suite.Server.URL, suite.Logger),
+		Logger: suite.Logger,
+	}
+	outbox, err := gcloudcx.NewOpenMessageOutbox(integration, store)
+	suite.Require().Nilf(err, "Failed to create outbox. %s", err)
+	return outbox
+}
+
+func (suite *OpenMessagingOutboxSuite) newMessage(sender, id string) *gcloudcx.OpenMessage {
+	return &gcloudcx.OpenMessage{
+		Channel: gcloudcx.NewOpenMessageChannel(id, nil, &gcloudcx.OpenMessageFrom{ID: sender, Type: "Opaque"}),
+		Type:    "Text",
+		Text:    "Hello from " + sender,
+	}
+}
+
+func (suite *OpenMessagingOutboxSuite) handler(w http.ResponseWriter, r *http.Request) {
+	message := gcloudcx.OpenMessage{}
+	if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	suite.Received = append(suite.Received, message.Channel.MessageID)
+	if status, found := suite.Failures[message.Channel.MessageID]; found {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(fmt.Sprintf(`{"status": %d, "code": "test.failure", "message": "Test Failure"}`, status)))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(fmt.Sprintf(`{"id": "%s", "type": "Text", "direction": "Inbound"}`, message.Channel.MessageID)))
+}
+
+// Suite Tools
+
+func (suite *OpenMessagingOutboxSuite) SetupSuite() {
+	suite.Name = strings.TrimSuffix(reflect.TypeOf(*suite).Name(), "Suite")
+	suite.Logger = logger.Create("test",
+		&logger.FileStream{
+			Path:        fmt.Sprintf("./log/test-%s.log", strings.ToLower(suite.Name)),
+			Unbuffered:  true,
+			FilterLevel: logger.TRACE,
+		},
+	).Child("test", "test")
+	suite.Logger.Infof("Suite Start: %s %s", suite.Name, strings.Repeat("=", 80-14-len(suite.Name)))
+	suite.Server = httptest.NewServer(http.HandlerFunc(suite.handler))
+}
+
+func (suite *OpenMessagingOutboxSuite) TearDownSuite() {
+	suite.Server.Close()
+	if suite.T().Failed() {
+		suite.Logger.Warnf("At least one test failed, we are not cleaning")
+		suite.T().Log("At least one test failed, we are not cleaning")
+	} else {
+		suite.Logger.Infof("All tests succeeded, we are cleaning")
+	}
+	suite.Logger.Infof("Suite End: %s %s", suite.Name, strings.Repeat("=", 80-12-len(suite.Name)))
+	suite.Logger.Close()
+}
+
+func (suite *OpenMessagingOutboxSuite) BeforeTest(suiteName, testName string) {
+	suite.Logger.Infof("Test Start: %s %s", testName, strings.Repeat("-", 80-13-len(testName)))
+	suite.Start = time.Now()
+	suite.Received = []string{}
+	suite.Failures = map[string]int{}
+}
+
+func (suite *OpenMessagingOutboxSuite) AfterTest(suiteName, testName string) {
+	duration := time.Since(suite.Start)
+	suite.Logger.Record("duration", duration.String()).Infof("Test End: %s %s", testName, strings.Repeat("-", 80-11-len(testName)))
+}
@@ -0,0 +1,237 @@
+package gcloudcx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gildas/go-core"
+	"github.com/gildas/go-errors"
+	"github.com/gildas/go-logger"
+	"github.com/google/uuid"
+)
+
+// OpenMessagingRouter is an http.Handler that receives the outbound messages of several OpenMessagingIntegrations
+//
+// Each integration has its own OpenMessagingWebhook, which verifies the signature with the integration's WebhookToken
+// and dispatches the message to the integration's callbacks.
+//
+// The integration is found with the ID of the message's Channel.To, or Channel.From if the former is not a known integration.
+type OpenMessagingRouter struct {
+	Client *Client
+	// OnAdded is called when an integration is added to the router, typically to set its callbacks and WebhookToken
+	OnAdded func(webhook *OpenMessagingWebhook)
+	// OnRemoved is called when an integration is removed from the router
+	OnRemoved func(webhook *OpenMessagingWebhook)
+	Logger    *logger.Logger
+	webhooks  map[uuid.UUID]*OpenMessagingWebhook
+	mutex     sync.RWMutex
+}
+
+// NewOpenMessagingRouter creates a new OpenMessagingRouter
+func NewOpenMessagingRouter(client *Client) *OpenMessagingRouter {
+	var log *logger.Logger
+	if client != nil {
+		log = client.Logger
+	}
+	return &OpenMessagingRouter{
+		Client:   client,
+		Logger:   logger.CreateIfNil(log, "gcloudcx").Child("router", "router"),
+		webhooks: map[uuid.UUID]*OpenMessagingWebhook{},
+	}
+}
+
+// Add adds an integration to this router
+//
+// If the integration is already routed, its webhook is returned and OnAdded is not called.
+// OnAdded is called before the webhook starts receiving messages.
+func (router *OpenMessagingRouter) Add(integration *OpenMessagingIntegration) (*OpenMessagingWebhook, error) {
+	if integration == nil || integration.ID == uuid.Nil {
+		return nil, errors.ArgumentMissing.With("integration").WithStack()
+	}
+	if webhook, found := router.find(integration.ID.String()); found {
+		return webhook, nil
+	}
+	webhook := integration.NewWebhook()
+	if router.OnAdded != nil {
+		router.OnAdded(webhook)
+	}
+
+	router.mutex.Lock()
+	if router.webhooks == nil {
+		router.webhooks = map[uuid.UUID]*OpenMessagingWebhook{}
+	}
+	if existing, found := router.webhooks[integration.ID]; found {
+		router.mutex.Unlock()
+		return existing, nil
+	}
+	router.webhooks[integration.ID] = webhook
+	router.mutex.Unlock()
+
+	router.Logger.Infof("Added integration %s (%s)", integration, integration.ID)
+	return webhook, nil
+}
+
+// Remove removes an integration from this router
+func (router *OpenMessagingRouter) Remove(integration Identifiable) {
+	if integration == nil {
+		return
+	}
+	router.mutex.Lock()
+	webhook, found := router.webhooks[integration.GetID()]
+	delete(router.webhooks, integration.GetID())
+	router.mutex.Unlock()
+
+	if found {
+		router.Logger.Infof("Removed integration %s (%s)", webhook.GetIntegration(), integration.GetID())
+		if router.OnRemoved != nil {
+			router.OnRemoved(webhook)
+		}
+	}
+}
+
+// Get gets the webhook of the given integration
+func (router *OpenMessagingRouter) Get(integration Identifiable) (*OpenMessagingWebhook, bool) {
+	if integration == nil {
+		return nil, false
+	}
+	return router.find(integration.GetID().String())
+}
+
+// Integrations gets the integrations routed by this router
+func (router *OpenMessagingRouter) Integrations() []*OpenMessagingIntegration {
+	router.mutex.RLock()
+	defer router.mutex.RUnlock()
+	integrations := make([]*OpenMessagingIntegration, 0, len(router.webhooks))
+	for _, webhook := range router.webhooks {
+		integrations = append(integrations, webhook.GetIntegration())
+	}
+	return integrations
+}
+
+// Sync fetches the OpenMessagingIntegrations from GENESYS Cloud and adds the new ones and removes the deleted ones
+//
+// The integrations that are already routed are replaced by an updated copy, so the requests being served are not disturbed.
+//
+// As GENESYS Cloud does not return the WebhookToken, the token of an existing integration is kept
+// and OnAdded should set the token of new integrations.
+func (router *OpenMessagingRouter) Sync() error {
+	if router.Client == nil {
+		return errors.ArgumentMissing.With("Client").WithStack()
+	}
+	integrations, err := FetchOpenMessagingIntegrations(router.Client, router.Logger)
+	if err != nil {
+		return err
+	}
+	fetched := map[uuid.UUID]bool{}
+	for _, integration := range integrations {
+		fetched[integration.ID] = true
+		if webhook, found := router.Get(integration); found {
+			updated := *webhook.GetIntegration()
+			updated.Name = integration.Name
+			updated.WebhookURL = integration.WebhookURL
+			updated.CreateStatus = integration.CreateStatus
+			if len(integration.WebhookToken) > 0 {
+				updated.WebhookToken = integration.WebhookToken
+			}
+			webhook.SetIntegration(&updated)
+			continue
+		}
+		if _, err := router.Add(integration); err != nil {
+			return err
+		}
+	}
+	for _, integration := range router.Integrations() {
+		if !fetched[integration.ID] {
+			router.Remove(integration)
+		}
+	}
+	return nil
+}
+
+// StartSync synchronizes the integrations at the given interval until the context is done
+//
+// The interval must be positive.
+func (router *OpenMessagingRouter) StartSync(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		return errors.ArgumentInvalid.With("interval", interval).WithStack()
+	}
+	log := router.Logger.Scope("sync")
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := router.Sync(); err != nil {
+				log.Errorf("Failed to synchronize integrations", err)
+			}
+		}
+	}
+}
+
+// ServeHTTP routes a request sent by GCloud to the webhook of its integration
+//   implements http.Handler
+func (router *OpenMessagingRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log := router.Logger.Scope("route")
+
+	if r.Method != http.MethodPost {
+		log.Errorf("Method %s is not allowed", r.Method)
+		core.RespondWithError(w, http.StatusMethodNotAllowed, errors.HTTPMethodNotAllowed.WithStack())
+		return
+	}
+	defer r.Body.Close()
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		log.Errorf("Failed to read the request body", err)
+		core.RespondWithError(w, http.StatusBadRequest, errors.HTTPBadRequest.Wrap(err))
+		return
+	}
+
+	message := struct {
+		Channel *OpenMessageChannel `json:"channel"`
+	}{}
+	if err = json.Unmarshal(body, &message); err != nil {
+		log.Errorf("Failed to unmarshal message", err)
+		core.RespondWithError(w, http.StatusBadRequest, errors.JSONUnmarshalError.Wrap(err))
+		return
+	}
+	if message.Channel == nil {
+		log.Errorf("Message is missing its channel")
+		core.RespondWithError(w, http.StatusBadRequest, errors.JSONPropertyMissing.With("channel").WithStack())
+		return
+	}
+
+	var webhook *OpenMessagingWebhook
+	var found bool
+	if message.Channel.To != nil {
+		webhook, found = router.find(message.Channel.To.ID)
+	}
+	if !found && message.Channel.From != nil {
+		webhook, found = router.find(message.Channel.From.ID)
+	}
+	if !found {
+		log.Errorf("No integration matches the message channel")
+		core.RespondWithError(w, http.StatusNotFound, errors.NotFound.With("integration").WithStack())
+		return
+	}
+	log.Debugf("Routing message to integration %s", webhook.GetIntegration())
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	webhook.ServeHTTP(w, r)
+}
+
+func (router *OpenMessagingRouter) find(id string) (*OpenMessagingWebhook, bool) {
+	integrationID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, false
+	}
+	router.mutex.RLock()
+	defer router.mutex.RUnlock()
+	webhook, found := router.webhooks[integrationID]
+	return webhook, found
+}
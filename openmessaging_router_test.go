@@ -0,0 +1,270 @@
+package gcloudcx_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gildas/go-errors"
+	"github.com/gildas/go-gcloudcx"
+	"github.com/gildas/go-logger"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+type OpenMessagingRouterSuite struct {
+	suite.Suite
+	Name   string
+	Logger *logger.Logger
+	Start  time.Time
+
+	IntegrationIDs []uuid.UUID
+	Recorder       *RequestRecorder
+	Server         *httptest.Server
+	Client         *gcloudcx.Client
+}
+
+func TestOpenMessagingRouterSuite(t *testing.T) {
+	suite.Run(t, new(OpenMessagingRouterSuite))
+}
+
+func (suite *OpenMessagingRouterSuite) TestCanAddAndRemoveIntegration() {
+	added, removed := 0, 0
+	router := gcloudcx.NewOpenMessagingRouter(suite.Client)
+	router.OnAdded = func(webhook *gcloudcx.OpenMessagingWebhook) { added++ }
+	router.OnRemoved = func(webhook *gcloudcx.OpenMessagingWebhook) { removed++ }
+	integration := suite.integration(0, "DEADBEEF")
+
+	webhook, err := router.Add(integration)
+	suite.Require().Nilf(err, "Failed to add integration. %s", err)
+	suite.Require().NotNil(webhook)
+	suite.Assert().Equal(integration, webhook.GetIntegration())
+	again, err := router.Add(integration)
+	suite.Require().Nilf(err, "Failed to add integration. %s", err)
+	suite.Assert().Same(webhook, again, "The same webhook should be returned")
+	suite.Assert().Equal(1, added, "OnAdded should be called once")
+
+	found, ok := router.Get(integration)
+	suite.Assert().True(ok, "The integration should be routed")
+	suite.Assert().Same(webhook, found)
+	suite.Assert().Len(router.Integrations(), 1)
+
+	router.Remove(integration)
+	router.Remove(integration)
+	suite.Assert().Equal(1, removed, "OnRemoved should be called once")
+	_, ok = router.Get(integration)
+	suite.Assert().False(ok, "The integration should not be routed anymore")
+	suite.Assert().Empty(router.Integrations())
+}
+
+func (suite *OpenMessagingRouterSuite) TestShouldNotAddIntegrationWithoutID() {
+	router := gcloudcx.NewOpenMessagingRouter(suite.Client)
+	_, err := router.Add(&gcloudcx.OpenMessagingIntegration{Name: "No ID"})
+	suite.Assert().True(errors.Is(err, errors.ArgumentMissing), "Error should be an ArgumentMissing")
+	_, err = router.Add(nil)
+	suite.Assert().True(errors.Is(err, errors.ArgumentMissing), "Error should be an ArgumentMissing")
+}
+
+func (suite *OpenMessagingRouterSuite) TestCanRouteMessages() {
+	received := map[uuid.UUID]int{}
+	router := gcloudcx.NewOpenMessagingRouter(suite.Client)
+	router.OnAdded = func(webhook *gcloudcx.OpenMessagingWebhook) {
+		id := webhook.Integration.ID
+		webhook.OnMessage = func(ctx context.Context, message *gcloudcx.OpenMessage) error {
+			received[id]++
+			return nil
+		}
+	}
+	first, second := suite.integration(0, "FIRST-TOKEN"), suite.integration(1, "SECOND-TOKEN")
+	_, _ = router.Add(first)
+	_, _ = router.Add(second)
+
+	payload := []byte(fmt.Sprintf(`{"id": "1", "channel": {"platform": "Open", "to": {"id": "%s"}}, "type": "Text", "text": "Hello"}`, first.ID))
+	response := suite.send(router, payload, gcloudcx.SignOpenMessagingPayload(payload, "FIRST-TOKEN"))
+	suite.Assert().Equal(http.StatusOK, response.Code)
+
+	payload = []byte(fmt.Sprintf(`{"id": "2", "channel": {"platform": "Open", "to": {"id": "gildas@kkt"}, "from": {"id": "%s"}}, "type": "Text", "text": "Hello"}`, second.ID))
+	response = suite.send(router, payload, gcloudcx.SignOpenMessagingPayload(payload, "SECOND-TOKEN"))
+	suite.Assert().Equal(http.StatusOK, response.Code, "The integration should be found with Channel.From")
+
+	payload = []byte(fmt.Sprintf(`{"id": "3", "channel": {"platform": "Open", "to": {"id": "%s"}}, "type": "Text", "text": "Hello"}`, second.ID))
+	response = suite.send(router, payload, gcloudcx.SignOpenMessagingPayload(payload, "FIRST-TOKEN"))
+	suite.Assert().Equal(http.StatusForbidden, response.Code, "The token of the routed integration should be used")
+
+	suite.Assert().Equal(1, received[first.ID])
+	suite.Assert().Equal(1, received[second.ID])
+}
+
+func (suite *OpenMessagingRouterSuite) TestShouldNotRouteMessageOfUnknownIntegration() {
+	router := gcloudcx.NewOpenMessagingRouter(suite.Client)
+	_, _ = router.Add(suite.integration(0, "DEADBEEF"))
+
+	payload := []byte(fmt.Sprintf(`{"id": "1", "channel": {"platform": "Open", "to": {"id": "%s"}}, "type": "Text", "text": "Hello"}`, uuid.New()))
+	response := suite.send(router, payload, gcloudcx.SignOpenMessagingPayload(payload, "DEADBEEF"))
+	suite.Assert().Equal(http.StatusNotFound, response.Code)
+
+	payload = []byte(`{"id": "2", "channel": {"platform": "Open", "to": {"id": "gildas@kkt"}, "from": {"id": "not-a-uuid"}}, "type": "Text", "text": "Hello"}`)
+	response = suite.send(router, payload, gcloudcx.SignOpenMessagingPayload(payload, "DEADBEEF"))
+	suite.Assert().Equal(http.StatusNotFound, response.Code)
+
+	payload = []byte(`{"id": "3", "type": "Text", "text": "Hello"}`)
+	response = suite.send(router, payload, gcloudcx.SignOpenMessagingPayload(payload, "DEADBEEF"))
+	suite.Assert().Equal(http.StatusBadRequest, response.Code)
+
+	response = httptest.NewRecorder()
+	router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/hook", nil))
+	suite.Assert().Equal(http.StatusMethodNotAllowed, response.Code)
+}
+
+func (suite *OpenMessagingRouterSuite) TestCanSyncIntegrationsOnSeveralPages() {
+	added, removed := []uuid.UUID{}, []uuid.UUID{}
+	router := gcloudcx.NewOpenMessagingRouter(suite.Client)
+	_, _ = router.Add(suite.integration(0, "FIRST-TOKEN"))
+	deleted := &gcloudcx.OpenMessagingIntegration{ID: uuid.New(), Name: "Deleted"}
+	_, _ = router.Add(deleted)
+	router.OnAdded = func(webhook *gcloudcx.OpenMessagingWebhook) {
+		added = append(added, webhook.Integration.ID)
+		webhook.Integration.WebhookToken = "SECOND-TOKEN"
+	}
+	router.OnRemoved = func(webhook *gcloudcx.OpenMessagingWebhook) { removed = append(removed, webhook.GetIntegration().ID) }
+
+	err := router.Sync()
+	suite.Require().Nilf(err, "Failed to sync integrations. %s", err)
+	suite.Assert().Equal([]string{
+		"GET /api/v2/conversations/messaging/integrations/open?pageSize=100&pageNumber=1",
+		"GET /api/v2/conversations/messaging/integrations/open?pageSize=100&pageNumber=2",
+	}, suite.Recorder.Requests())
+	suite.Assert().Equal([]uuid.UUID{suite.IntegrationIDs[1]}, added, "The integration of the second page should be added")
+	suite.Assert().Equal([]uuid.UUID{deleted.ID}, removed, "Only the deleted integration should be removed")
+
+	webhook, found := router.Get(suite.integration(0, ""))
+	suite.Require().True(found, "The first integration should still be routed")
+	suite.Assert().Equal("First (renamed)", webhook.GetIntegration().Name)
+	suite.Assert().Equal("FIRST-TOKEN", webhook.GetIntegration().WebhookToken, "The token should be kept")
+	webhook, found = router.Get(suite.integration(1, ""))
+	suite.Require().True(found, "The second integration should be routed")
+	suite.Assert().Equal("SECOND-TOKEN", webhook.GetIntegration().WebhookToken)
+}
+
+func (suite *OpenMessagingRouterSuite) TestShouldNotSyncWhenFetchFails() {
+	router := gcloudcx.NewOpenMessagingRouter(CreateTestClient("http://127.0.0.1:1", suite.Logger))
+	integration := suite.integration(0, "DEADBEEF")
+	_, _ = router.Add(integration)
+	err := router.Sync()
+	suite.Require().NotNil(err, "Sync should have failed")
+	_, found := router.Get(integration)
+	suite.Assert().True(found, "Integrations should not be removed when the fetch fails")
+}
+
+func (suite *OpenMessagingRouterSuite) TestShouldNotStartSyncWithInvalidInterval() {
+	router := gcloudcx.NewOpenMessagingRouter(suite.Client)
+	for _, interval := range []time.Duration{0, -time.Second} {
+		err := router.StartSync(context.Background(), interval)
+		suite.Require().NotNil(err, "StartSync should fail with interval %s", interval)
+		suite.Assert().True(errors.Is(err, errors.ArgumentInvalid), "Error should be an ArgumentInvalid")
+	}
+}
+
+func (suite *OpenMessagingRouterSuite) TestCanSyncWhileRouting() {
+	router := gcloudcx.NewOpenMessagingRouter(suite.Client)
+	webhook, _ := router.Add(suite.integration(0, "FIRST-TOKEN"))
+	webhook.OnMessage = func(ctx context.Context, message *gcloudcx.OpenMessage) error { return nil }
+	payload := []byte(fmt.Sprintf(`{"id": "1", "channel": {"platform": "Open", "to": {"id": "%s"}}, "type": "Text", "text": "Hello"}`, suite.IntegrationIDs[0]))
+	signature := gcloudcx.SignOpenMessagingPayload(payload, "FIRST-TOKEN")
+
+	var waiter sync.WaitGroup
+	waiter.Add(1)
+	go func() {
+		defer waiter.Done()
+		for i := 0; i < 5; i++ {
+			_ = router.Sync()
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		response := suite.send(router, payload, signature)
+		suite.Assert().Equal(http.StatusOK, response.Code)
+	}
+	waiter.Wait()
+}
+
+func (suite *OpenMessagingRouterSuite) integration(index int, token string) *gcloudcx.OpenMessagingIntegration {
+	return &gcloudcx.OpenMessagingIntegration{
+		ID:           suite.IntegrationIDs[index],
+		Name:         fmt.Sprintf("Integration %d", index+1),
+		WebhookToken: token,
+		Client:       suite.Client,
+		Logger:       suite.Logger,
+	}
+}
+
+func (suite *OpenMessagingRouterSuite) send(handler http.Handler, payload []byte, signature string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodPost, "/hook", bytes.NewReader(payload))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-Hub-Signature-256", signature)
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+	return response
+}
+
+// Suite Tools
+
+func (suite *OpenMessagingRouterSuite) SetupSuite() {
+	suite.Name = strings.TrimSuffix(reflect.TypeOf(*suite).Name(), "Suite")
+	suite.Logger = logger.Create("test",
+		&logger.FileStream{
+			Path:        fmt.Sprintf("./log/test-%s.log", strings.ToLower(suite.Name)),
+			Unbuffered:  true,
+			FilterLevel: logger.TRACE,
+		},
+	).Child("test", "test")
+	suite.Logger.Infof("Suite Start: %s %s", suite.Name, strings.Repeat("=", 80-14-len(suite.Name)))
+	suite.IntegrationIDs = []uuid.UUID{
+		uuid.MustParse("4d4d0d8a-0b5c-4c6e-8f27-5f4c6a3e9a01"),
+		uuid.MustParse("4d4d0d8a-0b5c-4c6e-8f27-5f4c6a3e9a02"),
+	}
+	suite.Recorder = NewRequestRecorder(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method != http.MethodGet || r.URL.Path != "/api/v2/conversations/messaging/integrations/open" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"status": 404, "code": "not.found", "message": "not found"}`))
+			return
+		}
+		if r.URL.Query().Get("pageNumber") == "2" {
+			_, _ = w.Write([]byte(fmt.Sprintf(`{"entities": [{"id": "%s", "name": "Second", "createStatus": "Completed"}], "pageNumber": 2, "pageCount": 2}`, suite.IntegrationIDs[1])))
+			return
+		}
+		_, _ = w.Write([]byte(fmt.Sprintf(`{"entities": [{"id": "%s", "name": "First (renamed)", "createStatus": "Completed"}], "pageNumber": 1, "pageCount": 2}`, suite.IntegrationIDs[0])))
+	})
+	suite.Server = httptest.NewServer(suite.Recorder)
+	suite.Client = CreateTestClient(suite.Server.URL, suite.Logger)
+}
+
+func (suite *OpenMessagingRouterSuite) TearDownSuite() {
+	suite.Server.Close()
+	if suite.T().Failed() {
+		suite.Logger.Warnf("At least one test failed, we are not cleaning")
+		suite.T().Log("At least one test failed, we are not cleaning")
+	} else {
+		suite.Logger.Infof("All tests succeeded, we are cleaning")
+	}
+	suite.Logger.Infof("Suite End: %s %s", suite.Name, strings.Repeat("=", 80-12-len(suite.Name)))
+	suite.Logger.Close()
+}
+
+func (suite *OpenMessagingRouterSuite) BeforeTest(suiteName, testName string) {
+	suite.Logger.Infof("Test Start: %s %s", testName, strings.Repeat("-", 80-13-len(testName)))
+	suite.Start = time.Now()
+	suite.Recorder.Reset()
+}
+
+func (suite *OpenMessagingRouterSuite) AfterTest(suiteName, testName string) {
+	duration := time.Since(suite.Start)
+	suite.Logger.Record("duration", duration.String()).Infof("Test End: %s %s", testName, strings.Repeat("-", 80-11-len(testName)))
+}
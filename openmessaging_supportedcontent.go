@@ -0,0 +1,142 @@
+package gcloudcx
+
+import (
+	"strings"
+
+	"github.com/gildas/go-errors"
+	"github.com/google/uuid"
+)
+
+// SupportedContent describes a Supported Content profile of messaging integrations
+//
+// A profile tells which media types can be sent and received by the integrations that use it
+//
+// See https://developer.genesys.cloud/api/rest/v2/conversations/#get-api-v2-conversations-messaging-supportedcontent
+type SupportedContent struct {
+	ID         uuid.UUID                   `json:"id"`
+	Name       string                      `json:"name"`
+	MediaTypes *SupportedContentMediaTypes `json:"mediaTypes,omitempty"`
+	SelfURI    URI                         `json:"selfUri,omitempty"`
+}
+
+// SupportedContentMediaTypes describes the media types allowed by a SupportedContent profile
+type SupportedContentMediaTypes struct {
+	Allow struct {
+		Inbound  []SupportedContentMediaType `json:"inbound"`
+		Outbound []SupportedContentMediaType `json:"outbound"`
+	} `json:"allow"`
+}
+
+// SupportedContentMediaType describes a media type (e.g.: image/*, video/mpeg)
+type SupportedContentMediaType struct {
+	Type string `json:"type"`
+}
+
+// FetchSupportedContents fetches all the Supported Content profiles
+func (client *Client) FetchSupportedContents() ([]*SupportedContent, error) {
+	contents := []*SupportedContent{}
+	page := 1
+	for {
+		response := struct {
+			Entities  []*SupportedContent `json:"entities"`
+			PageCount int                 `json:"pageCount"`
+		}{}
+		if err := client.Get(NewURI("/conversations/messaging/supportedcontent?pageSize=100&pageNumber=%d", page), &response); err != nil {
+			return nil, err
+		}
+		contents = append(contents, response.Entities...)
+		if page >= response.PageCount {
+			break
+		}
+		page++
+	}
+	return contents, nil
+}
+
+// FetchSupportedContent fetches a Supported Content profile by its ID or its name (case insensitive)
+func (client *Client) FetchSupportedContent(parameters ...interface{}) (*SupportedContent, error) {
+	var id uuid.UUID
+	var name string
+	for _, parameter := range parameters {
+		switch object := parameter.(type) {
+		case uuid.UUID:
+			id = object
+		case string:
+			name = object
+		}
+	}
+	if id != uuid.Nil {
+		content := &SupportedContent{}
+		if err := client.Get(NewURI("/conversations/messaging/supportedcontent/%s", id), &content); err != nil {
+			return nil, err
+		}
+		return content, nil
+	}
+	if len(name) == 0 {
+		return nil, errors.ArgumentMissing.With("name").WithStack()
+	}
+	contents, err := client.FetchSupportedContents()
+	if err != nil {
+		return nil, err
+	}
+	for _, content := range contents {
+		if strings.EqualFold(content.Name, name) {
+			return content, nil
+		}
+	}
+	return nil, errors.NotFound.With("name", name).WithStack()
+}
+
+// FetchDefaultSupportedContent fetches the default Supported Content profile of the organization
+func (client *Client) FetchDefaultSupportedContent() (*SupportedContent, error) {
+	content := &SupportedContent{}
+	if err := client.Get("/conversations/messaging/supportedcontent/default", &content); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// AllowsInbound tells if the given media type (e.g.: image/png) can be received
+func (content SupportedContent) AllowsInbound(mediaType string) bool {
+	if content.MediaTypes == nil {
+		return false
+	}
+	return matchesMediaType(mediaType, content.MediaTypes.Allow.Inbound)
+}
+
+// AllowsOutbound tells if the given media type (e.g.: image/png) can be sent
+func (content SupportedContent) AllowsOutbound(mediaType string) bool {
+	if content.MediaTypes == nil {
+		return false
+	}
+	return matchesMediaType(mediaType, content.MediaTypes.Allow.Outbound)
+}
+
+// GetID gets the identifier of this
+//
+//   implements Identifiable
+func (content SupportedContent) GetID() uuid.UUID {
+	return content.ID
+}
+
+// String gets a string version
+//
+//   implements the fmt.Stringer interface
+func (content SupportedContent) String() string {
+	if len(content.Name) > 0 {
+		return content.Name
+	}
+	return content.ID.String()
+}
+
+func matchesMediaType(mediaType string, allowed []SupportedContentMediaType) bool {
+	for _, item := range allowed {
+		if item.Type == "*/*" || strings.EqualFold(item.Type, mediaType) {
+			return true
+		}
+		if strings.HasSuffix(item.Type, "/*") && strings.HasPrefix(strings.ToLower(mediaType), strings.ToLower(strings.TrimSuffix(item.Type, "*"))) {
+			return true
+		}
+	}
+	return false
+}
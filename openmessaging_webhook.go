@@ -0,0 +1,189 @@
+package gcloudcx
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gildas/go-core"
+	"github.com/gildas/go-errors"
+	"github.com/gildas/go-logger"
+)
+
+// OpenMessageHandlerFunc is called when an OpenMessage is received from GCloud
+//
+// If an error is returned, GCloud receives an HTTP 500 and will retry the delivery,
+// unless the error is an errors.NotFound or errors.HTTPNotFound, in which case GCloud receives an HTTP 404
+type OpenMessageHandlerFunc func(ctx context.Context, message *OpenMessage) error
+
+// OpenMessagingWebhook is an http.Handler that receives the outbound messages of an OpenMessagingIntegration
+//
+// The X-Hub-Signature-256 header of each request is verified against the integration's WebhookToken.
+//
+// Status codes:
+//   - 200 when the message was processed (or ignored if there is no callback for its type)
+//   - 400 when the payload is not a valid OpenMessage
+//   - 403 when the signature is missing or invalid
+//   - 404 when a callback returned an errors.NotFound or errors.HTTPNotFound (e.g. the recipient is unknown)
+//   - 405 when the method is not POST
+//   - 500 when a callback returned any other error
+//
+// See https://developer.genesys.cloud/api/digital/openmessaging/outboundMessages
+type OpenMessagingWebhook struct {
+	Integration *OpenMessagingIntegration
+	OnText      OpenMessageHandlerFunc      // Text and Structured messages
+	OnReceipt   OpenMessageHandlerFunc      // Receipt messages
+	OnTyping    OpenMessageHandlerFunc      // Event messages with a Typing event
+	OnEvent     OpenMessageHandlerFunc      // Event messages without a Typing event
+	OnMessage   OpenMessageHandlerFunc      // Messages that were not handled by the other callbacks
+	Tracker     *OpenMessageDeliveryTracker // If set, Text and Structured messages are tracked before being dispatched
+	Logger      *logger.Logger
+	mutex       sync.RWMutex
+}
+
+// NewWebhook creates a new http.Handler that receives the outbound messages of this integration
+func (integration *OpenMessagingIntegration) NewWebhook() *OpenMessagingWebhook {
+	return &OpenMessagingWebhook{
+		Integration: integration,
+		Logger:      logger.CreateIfNil(integration.Logger, "gcloudcx").Child("webhook", "webhook"),
+	}
+}
+
+// GetIntegration gets the integration of this webhook
+//
+// Use this instead of reading Integration when the integration can be replaced while the webhook serves requests
+func (webhook *OpenMessagingWebhook) GetIntegration() *OpenMessagingIntegration {
+	webhook.mutex.RLock()
+	defer webhook.mutex.RUnlock()
+	return webhook.Integration
+}
+
+// SetIntegration replaces the integration of this webhook
+//
+// The requests being served keep using the previous integration
+func (webhook *OpenMessagingWebhook) SetIntegration(integration *OpenMessagingIntegration) {
+	webhook.mutex.Lock()
+	defer webhook.mutex.Unlock()
+	webhook.Integration = integration
+}
+
+// VerifyOpenMessagingSignature verifies the X-Hub-Signature-256 signature of an Open Messaging payload with the given token
+//
+// The signature is expected to be "sha256=" followed by the base64 encoded HMAC-SHA256 of the payload
+func VerifyOpenMessagingSignature(payload []byte, signature, token string) bool {
+	if len(signature) == 0 || len(token) == 0 {
+		return false
+	}
+	expected, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil {
+		return false
+	}
+	crypto := hmac.New(sha256.New, []byte(token))
+	_, _ = crypto.Write(payload)
+	return hmac.Equal(expected, crypto.Sum(nil))
+}
+
+// SignOpenMessagingPayload computes the X-Hub-Signature-256 signature of an Open Messaging payload with the given token
+func SignOpenMessagingPayload(payload []byte, token string) string {
+	crypto := hmac.New(sha256.New, []byte(token))
+	_, _ = crypto.Write(payload)
+	return "sha256=" + base64.StdEncoding.EncodeToString(crypto.Sum(nil))
+}
+
+// ServeHTTP processes a request sent by GCloud
+//   implements http.Handler
+func (webhook *OpenMessagingWebhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log := webhook.Logger.Scope("receive")
+
+	if r.Method != http.MethodPost {
+		log.Errorf("Method %s is not allowed", r.Method)
+		core.RespondWithError(w, http.StatusMethodNotAllowed, errors.HTTPMethodNotAllowed.WithStack())
+		return
+	}
+	defer r.Body.Close()
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		log.Errorf("Failed to read the request body", err)
+		core.RespondWithError(w, http.StatusBadRequest, errors.HTTPBadRequest.Wrap(err))
+		return
+	}
+	log.Tracef("Received body (%d bytes): %s", len(body), string(body))
+
+	signature := r.Header.Get("X-Hub-Signature-256")
+	if len(signature) == 0 {
+		log.Errorf("Request is missing [X-Hub-Signature-256] header")
+		core.RespondWithError(w, http.StatusForbidden, errors.ArgumentMissing.With("X-Hub-Signature-256").WithStack())
+		return
+	}
+	integration := webhook.GetIntegration()
+	if integration == nil || !VerifyOpenMessagingSignature(body, signature, integration.WebhookToken) {
+		log.Errorf("Signature %s does not match the Integration Token, rejecting", signature)
+		core.RespondWithError(w, http.StatusForbidden, errors.ArgumentInvalid.With("X-Hub-Signature-256", signature).WithStack())
+		return
+	}
+
+	message := &OpenMessage{}
+	if err = json.Unmarshal(body, &message); err != nil {
+		log.Errorf("Failed to unmarshal message", err)
+		core.RespondWithError(w, http.StatusBadRequest, errors.JSONUnmarshalError.Wrap(err))
+		return
+	}
+	if message.Channel == nil || len(message.Type) == 0 {
+		log.Errorf("Message is missing its channel or type")
+		core.RespondWithError(w, http.StatusBadRequest, errors.JSONPropertyMissing.With("channel").WithStack())
+		return
+	}
+	log = log.Record("message", message.ID)
+
+	if webhook.Tracker != nil && (message.Type == "Text" || message.Type == "Structured") {
+		if err = webhook.Tracker.Track(message); err != nil {
+			log.Warnf("Failed to track message %s: %s", message.ID, err)
+		}
+	}
+
+	if err = webhook.dispatch(r.Context(), message); err != nil {
+		if errors.Is(err, errors.NotFound) || errors.Is(err, errors.HTTPNotFound) {
+			log.Warnf("Failed to process message %s: %s", message.ID, err)
+			core.RespondWithError(w, http.StatusNotFound, err)
+			return
+		}
+		log.Errorf("Failed to process message %s", message.ID, err)
+		core.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+	core.RespondWithJSON(w, http.StatusOK, struct{}{})
+}
+
+// dispatch sends the message to the callback that matches its type
+func (webhook *OpenMessagingWebhook) dispatch(ctx context.Context, message *OpenMessage) error {
+	var handler OpenMessageHandlerFunc
+
+	switch message.Type {
+	case "Text", "Structured":
+		handler = webhook.OnText
+	case "Receipt":
+		handler = webhook.OnReceipt
+	case "Event":
+		handler = webhook.OnEvent
+		for _, event := range message.Events {
+			if event.Type == "Typing" {
+				handler = webhook.OnTyping
+				break
+			}
+		}
+	}
+	if handler == nil {
+		handler = webhook.OnMessage
+	}
+	if handler == nil {
+		webhook.Logger.Scope("dispatch").Debugf("No handler for message type %s, ignoring", message.Type)
+		return nil
+	}
+	return handler(ctx, message)
+}
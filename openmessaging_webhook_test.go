@@ -0,0 +1,180 @@
+package gcloudcx_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gildas/go-errors"
+	"github.com/gildas/go-gcloudcx"
+	"github.com/gildas/go-logger"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+type OpenMessagingWebhookSuite struct {
+	suite.Suite
+	Name   string
+	Logger *logger.Logger
+	Start  time.Time
+
+	Integration *gcloudcx.OpenMessagingIntegration
+}
+
+func TestOpenMessagingWebhookSuite(t *testing.T) {
+	suite.Run(t, new(OpenMessagingWebhookSuite))
+}
+
+func (suite *OpenMessagingWebhookSuite) TestCanSignAndVerify() {
+	payload := []byte(`{"hello": "world"}`)
+	signature := gcloudcx.SignOpenMessagingPayload(payload, "DEADBEEF")
+	suite.Assert().True(strings.HasPrefix(signature, "sha256="))
+	suite.Assert().True(gcloudcx.VerifyOpenMessagingSignature(payload, signature, "DEADBEEF"))
+	suite.Assert().False(gcloudcx.VerifyOpenMessagingSignature(payload, signature, "BADC0FFEE"))
+	suite.Assert().False(gcloudcx.VerifyOpenMessagingSignature([]byte(`{"hello": "moon"}`), signature, "DEADBEEF"))
+	suite.Assert().False(gcloudcx.VerifyOpenMessagingSignature(payload, "sha256=not base64!", "DEADBEEF"))
+}
+
+func (suite *OpenMessagingWebhookSuite) TestCanReceiveTextMessage() {
+	var received *gcloudcx.OpenMessage
+	webhook := suite.Integration.NewWebhook()
+	webhook.OnText = func(ctx context.Context, message *gcloudcx.OpenMessage) error {
+		received = message
+		return nil
+	}
+	payload, err := LoadFile("openmessaging-message.json")
+	suite.Require().Nilf(err, "Failed to Load Data. %s", err)
+
+	response := suite.send(webhook, payload, gcloudcx.SignOpenMessagingPayload(payload, suite.Integration.WebhookToken))
+	suite.Assert().Equal(http.StatusOK, response.Code)
+	suite.Require().NotNil(received, "OnText was not called")
+	suite.Assert().Equal("Hello From GCloud via the middleware", received.Text)
+	suite.Assert().Equal("gildas@kkt", received.Channel.To.ID)
+}
+
+func (suite *OpenMessagingWebhookSuite) TestCanReceiveTypingEvent() {
+	typing, other := 0, 0
+	webhook := suite.Integration.NewWebhook()
+	webhook.OnTyping = func(ctx context.Context, message *gcloudcx.OpenMessage) error {
+		typing++
+		return nil
+	}
+	webhook.OnMessage = func(ctx context.Context, message *gcloudcx.OpenMessage) error {
+		other++
+		return nil
+	}
+	payload := []byte(`{"id": "1234", "channel": {"platform": "Open", "to": {"id": "abcd"}}, "direction": "Outbound", "type": "Event", "events": [{"eventType": "Typing", "typing": {"type": "On"}}]}`)
+	response := suite.send(webhook, payload, gcloudcx.SignOpenMessagingPayload(payload, suite.Integration.WebhookToken))
+	suite.Assert().Equal(http.StatusOK, response.Code)
+	suite.Assert().Equal(1, typing)
+
+	payload = []byte(`{"id": "1235", "channel": {"platform": "Open", "to": {"id": "abcd"}}, "direction": "Outbound", "type": "Receipt", "text": ""}`)
+	response = suite.send(webhook, payload, gcloudcx.SignOpenMessagingPayload(payload, suite.Integration.WebhookToken))
+	suite.Assert().Equal(http.StatusOK, response.Code)
+	suite.Assert().Equal(1, other, "Receipts should fall back to OnMessage")
+}
+
+func (suite *OpenMessagingWebhookSuite) TestShouldRejectMissingSignature() {
+	payload, _ := LoadFile("openmessaging-message.json")
+	response := suite.send(suite.Integration.NewWebhook(), payload, "")
+	suite.Assert().Equal(http.StatusForbidden, response.Code)
+}
+
+func (suite *OpenMessagingWebhookSuite) TestShouldRejectInvalidSignature() {
+	payload, _ := LoadFile("openmessaging-message.json")
+	response := suite.send(suite.Integration.NewWebhook(), payload, gcloudcx.SignOpenMessagingPayload(payload, "BADC0FFEE"))
+	suite.Assert().Equal(http.StatusForbidden, response.Code)
+}
+
+func (suite *OpenMessagingWebhookSuite) TestShouldRejectMalformedPayload() {
+	payload := []byte(`{"id": 12, "type": `)
+	response := suite.send(suite.Integration.NewWebhook(), payload, gcloudcx.SignOpenMessagingPayload(payload, suite.Integration.WebhookToken))
+	suite.Assert().Equal(http.StatusBadRequest, response.Code)
+}
+
+func (suite *OpenMessagingWebhookSuite) TestShouldRejectOtherMethods() {
+	webhook := suite.Integration.NewWebhook()
+	response := httptest.NewRecorder()
+	webhook.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/hook", nil))
+	suite.Assert().Equal(http.StatusMethodNotAllowed, response.Code)
+}
+
+func (suite *OpenMessagingWebhookSuite) TestShouldFailWhenHandlerFails() {
+	webhook := suite.Integration.NewWebhook()
+	webhook.OnText = func(ctx context.Context, message *gcloudcx.OpenMessage) error {
+		return errors.JSONMarshalError.Wrap(errors.New("oops"))
+	}
+	payload, _ := LoadFile("openmessaging-message.json")
+	response := suite.send(webhook, payload, gcloudcx.SignOpenMessagingPayload(payload, suite.Integration.WebhookToken))
+	suite.Assert().Equal(http.StatusInternalServerError, response.Code)
+}
+
+func (suite *OpenMessagingWebhookSuite) TestShouldRespondNotFoundWhenHandlerCannotFindRecipient() {
+	payload, _ := LoadFile("openmessaging-message.json")
+	for _, notFound := range []error{errors.NotFound.With("chat", "gildas@kkt"), errors.HTTPNotFound.With("user", "gildas@kkt")} {
+		webhook := suite.Integration.NewWebhook()
+		webhook.OnText = func(ctx context.Context, message *gcloudcx.OpenMessage) error {
+			return notFound
+		}
+		response := suite.send(webhook, payload, gcloudcx.SignOpenMessagingPayload(payload, suite.Integration.WebhookToken))
+		suite.Assert().Equalf(http.StatusNotFound, response.Code, "Error %s should give a 404", notFound)
+	}
+}
+
+func (suite *OpenMessagingWebhookSuite) send(handler http.Handler, payload []byte, signature string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodPost, "/hook", bytes.NewReader(payload))
+	request.Header.Set("Content-Type", "application/json")
+	if len(signature) > 0 {
+		request.Header.Set("X-Hub-Signature-256", signature)
+	}
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+	return response
+}
+
+// Suite Tools
+
+func (suite *OpenMessagingWebhookSuite) SetupSuite() {
+	suite.Name = strings.TrimSuffix(reflect.TypeOf(*suite).Name(), "Suite")
+	suite.Logger = logger.Create("test",
+		&logger.FileStream{
+			Path:        fmt.Sprintf("./log/test-%s.log", strings.ToLower(suite.Name)),
+			Unbuffered:  true,
+			FilterLevel: logger.TRACE,
+		},
+	).Child("test", "test")
+	suite.Logger.Infof("Suite Start: %s %s", suite.Name, strings.Repeat("=", 80-14-len(suite.Name)))
+
+	suite.Integration = &gcloudcx.OpenMessagingIntegration{}
+	err := suite.Integration.Initialize(gcloudcx.NewClient(&gcloudcx.ClientOptions{Logger: suite.Logger}))
+	suite.Require().Nilf(err, "Failed to initialize OpenMessagingIntegration. %s", err)
+	suite.Integration.ID = uuid.MustParse("34071108-1569-4cb0-9137-a326b8a9e815")
+	suite.Integration.WebhookToken = "DEADBEEF"
+}
+
+func (suite *OpenMessagingWebhookSuite) TearDownSuite() {
+	if suite.T().Failed() {
+		suite.Logger.Warnf("At least one test failed, we are not cleaning")
+		suite.T().Log("At least one test failed, we are not cleaning")
+	} else {
+		suite.Logger.Infof("All tests succeeded, we are cleaning")
+	}
+	suite.Logger.Infof("Suite End: %s %s", suite.Name, strings.Repeat("=", 80-12-len(suite.Name)))
+	suite.Logger.Close()
+}
+
+func (suite *OpenMessagingWebhookSuite) BeforeTest(suiteName, testName string) {
+	suite.Logger.Infof("Test Start: %s %s", testName, strings.Repeat("-", 80-13-len(testName)))
+	suite.Start = time.Now()
+}
+
+func (suite *OpenMessagingWebhookSuite) AfterTest(suiteName, testName string) {
+	duration := time.Since(suite.Start)
+	suite.Logger.Record("duration", duration.String()).Infof("Test End: %s %s", testName, strings.Repeat("-", 80-11-len(testName)))
+}
@@ -0,0 +1,653 @@
+package gcloudcx
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gildas/go-errors"
+	"github.com/google/uuid"
+)
+
+// ProvisioningSpec describes the desired state of a user to provision
+//
+// Roles are given as "Role" (granted in the division of the user) or "Role@Division".
+// Skills and Languages map their names to a proficiency (0 to 5).
+type ProvisioningSpec struct {
+	Name       string             `json:"name"`
+	Email      string             `json:"email"`
+	Department string             `json:"department,omitempty"`
+	Title      string             `json:"title,omitempty"`
+	Division   string             `json:"division,omitempty"`
+	Roles      []string           `json:"roles,omitempty"`
+	Groups     []string           `json:"groups,omitempty"`
+	Queues     []string           `json:"queues,omitempty"`
+	Skills     map[string]float64 `json:"skills,omitempty"`
+	Languages  map[string]float64 `json:"languages,omitempty"`
+	Station    string             `json:"station,omitempty"`
+}
+
+// ProvisioningPlan describes what has to be done to provision users
+//
+// Computing the plan does not change anything, use Apply to perform it
+type ProvisioningPlan struct {
+	Rows        []*ProvisioningRowPlan
+	Concurrency int           // Default: 4
+	MaxAttempts int           // Default: 5, attempts of a request that is rate-limited
+	MinBackoff  time.Duration // Default: 1 second, doubled after each rate-limited attempt, with jitter, unless GCloud sends a longer Retry-After
+	Client      *Client
+}
+
+// ProvisioningRowPlan describes what has to be done to provision one user
+//
+// If User is nil, the user will be created.
+// Otherwise, Update contains the Name, Department, and Title of the spec that differ from the user
+// and Move tells if the user has to be moved to Division. Empty properties of the spec are left untouched.
+// If Error is not nil, the row cannot be provisioned and will be skipped.
+type ProvisioningRowPlan struct {
+	Row       int
+	Spec      *ProvisioningSpec
+	User      *User
+	Update    *UserUpdate
+	Move      bool
+	Division  *Division
+	Grants    []ProvisioningGrant
+	Groups    []*Group
+	Queues    []*Queue
+	Skills    []*UserRoutingSkill
+	Languages []*UserRoutingLanguage
+	Station   *UserStation
+	Error     error
+}
+
+// ProvisioningGrant describes a Role to grant in a Division
+type ProvisioningGrant struct {
+	Role     *DomainRole
+	Division *Division
+}
+
+// ProvisioningResult describes the result of the provisioning of one user
+type ProvisioningResult struct {
+	Row     int
+	Email   string
+	User    *User
+	Created bool
+	Actions []string
+	Error   error
+}
+
+// ReadProvisioningSpecsJSON reads ProvisioningSpecs from a JSON array
+func ReadProvisioningSpecsJSON(reader io.Reader) ([]*ProvisioningSpec, error) {
+	specs := []*ProvisioningSpec{}
+	if err := json.NewDecoder(reader).Decode(&specs); err != nil {
+		return nil, errors.JSONUnmarshalError.Wrap(err)
+	}
+	return specs, nil
+}
+
+// ReadProvisioningSpecsCSV reads ProvisioningSpecs from a CSV source
+//
+// The header gives the columns, in any order: name, email, department, title, division, roles, groups, queues, skills, languages, station.
+// Lists are separated by semicolons, skills and languages are given as name:proficiency.
+//
+//	name,email,division,roles,queues,skills
+//	John Doe,john.doe@acme.com,Sales,Employee;Agent@Home,Sales;Support,Sales:3;Support:1
+func ReadProvisioningSpecsCSV(reader io.Reader) ([]*ProvisioningSpec, error) {
+	records, err := csv.NewReader(reader).ReadAll()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if len(records) == 0 {
+		return nil, errors.ArgumentMissing.With("header").WithStack()
+	}
+	columns := map[string]int{}
+	for index, column := range records[0] {
+		column = strings.ToLower(strings.TrimSpace(column))
+		switch column {
+		case "name", "email", "department", "title", "division", "roles", "groups", "queues", "skills", "languages", "station":
+			columns[column] = index
+		default:
+			return nil, errors.ArgumentInvalid.With("column", column).WithStack()
+		}
+	}
+	specs := make([]*ProvisioningSpec, 0, len(records)-1)
+	for line, record := range records[1:] {
+		cell := func(column string) string {
+			if index, found := columns[column]; found {
+				return strings.TrimSpace(record[index])
+			}
+			return ""
+		}
+		spec := &ProvisioningSpec{
+			Name:       cell("name"),
+			Email:      cell("email"),
+			Department: cell("department"),
+			Title:      cell("title"),
+			Division:   cell("division"),
+			Roles:      splitProvisioningList(cell("roles")),
+			Groups:     splitProvisioningList(cell("groups")),
+			Queues:     splitProvisioningList(cell("queues")),
+			Station:    cell("station"),
+		}
+		if spec.Skills, err = parseProvisioningProficiencies(cell("skills")); err != nil {
+			return nil, errors.WithMessage(err, fmt.Sprintf("line %d", line+2))
+		}
+		if spec.Languages, err = parseProvisioningProficiencies(cell("languages")); err != nil {
+			return nil, errors.WithMessage(err, fmt.Sprintf("line %d", line+2))
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+func splitProvisioningList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ";") {
+		if item = strings.TrimSpace(item); len(item) > 0 {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func parseProvisioningProficiencies(value string) (map[string]float64, error) {
+	proficiencies := map[string]float64{}
+	for _, item := range splitProvisioningList(value) {
+		separator := strings.LastIndex(item, ":")
+		if separator < 0 {
+			return nil, errors.ArgumentInvalid.With("proficiency", item).WithStack()
+		}
+		proficiency, err := strconv.ParseFloat(strings.TrimSpace(item[separator+1:]), 64)
+		if err != nil || proficiency < 0 || proficiency > 5 {
+			return nil, errors.ArgumentInvalid.With("proficiency", item).WithStack()
+		}
+		proficiencies[strings.TrimSpace(item[:separator])] = proficiency
+	}
+	return proficiencies, nil
+}
+
+// provisioningCatalog contains the organization objects referenced by the specs, indexed by their lowercased names
+type provisioningCatalog struct {
+	divisions map[string]*Division
+	home      *Division
+	roles     map[string]*DomainRole
+	groups    map[string]*Group
+	queues    map[string]*Queue
+	skills    map[string]string
+	languages map[string]string
+	stations  map[string]*UserStation
+}
+
+// PlanProvisioning computes the plan to provision the given users
+//
+// The organization objects referenced by the specs are fetched once, the users are looked up by their email.
+// Rows that reference unknown objects get an Error and will be skipped by Apply.
+func (client *Client) PlanProvisioning(specs []*ProvisioningSpec) (*ProvisioningPlan, error) {
+	catalog, err := client.fetchProvisioningCatalog(specs)
+	if err != nil {
+		return nil, err
+	}
+	plan := &ProvisioningPlan{
+		Rows:        make([]*ProvisioningRowPlan, 0, len(specs)),
+		Concurrency: 4,
+		MaxAttempts: 5,
+		MinBackoff:  1 * time.Second,
+		Client:      client,
+	}
+	emails := map[string]bool{}
+	for index, spec := range specs {
+		row := &ProvisioningRowPlan{Row: index + 1, Spec: spec}
+		if len(spec.Email) > 0 && emails[strings.ToLower(spec.Email)] {
+			row.Error = errors.ArgumentInvalid.With("email", spec.Email).WithStack()
+		} else {
+			emails[strings.ToLower(spec.Email)] = true
+			row.Error = plan.planRow(row, catalog)
+		}
+		plan.Rows = append(plan.Rows, row)
+	}
+	return plan, nil
+}
+
+func (client *Client) fetchProvisioningCatalog(specs []*ProvisioningSpec) (catalog *provisioningCatalog, err error) {
+	catalog = &provisioningCatalog{
+		divisions: map[string]*Division{},
+		roles:     map[string]*DomainRole{},
+		groups:    map[string]*Group{},
+		queues:    map[string]*Queue{},
+		skills:    map[string]string{},
+		languages: map[string]string{},
+		stations:  map[string]*UserStation{},
+	}
+	var needDivisions, needRoles, needGroups, needQueues, needSkills, needLanguages bool
+	for _, spec := range specs {
+		needDivisions = needDivisions || len(spec.Division) > 0 || len(spec.Roles) > 0
+		needRoles = needRoles || len(spec.Roles) > 0
+		needGroups = needGroups || len(spec.Groups) > 0
+		needQueues = needQueues || len(spec.Queues) > 0
+		needSkills = needSkills || len(spec.Skills) > 0
+		needLanguages = needLanguages || len(spec.Languages) > 0
+	}
+	if needDivisions {
+		divisions, err := client.FetchDivisions()
+		if err != nil {
+			return nil, err
+		}
+		for _, division := range divisions {
+			catalog.divisions[strings.ToLower(division.Name)] = division
+		}
+		if catalog.home, err = client.FetchHomeDivision(); err != nil {
+			return nil, err
+		}
+	}
+	if needRoles {
+		roles, err := client.FetchAuthorizationRoles()
+		if err != nil {
+			return nil, err
+		}
+		for _, role := range roles {
+			catalog.roles[strings.ToLower(role.Name)] = role
+		}
+	}
+	if needGroups {
+		groups, err := client.FetchGroups()
+		if err != nil {
+			return nil, err
+		}
+		for _, group := range groups {
+			catalog.groups[strings.ToLower(group.Name)] = group
+		}
+	}
+	if needQueues {
+		queues, err := client.FetchQueues()
+		if err != nil {
+			return nil, err
+		}
+		for _, queue := range queues {
+			catalog.queues[strings.ToLower(queue.Name)] = queue
+		}
+	}
+	if needSkills {
+		skills, err := client.FetchRoutingSkills()
+		if err != nil {
+			return nil, err
+		}
+		catalog.skills = routingSkillIDs(skills)
+	}
+	if needLanguages {
+		languages, err := client.FetchRoutingLanguages()
+		if err != nil {
+			return nil, err
+		}
+		catalog.languages = routingLanguageIDs(languages)
+	}
+	return catalog, nil
+}
+
+// planRow computes the plan of a row, the users and stations are looked up with retries as there is one request per row
+func (plan *ProvisioningPlan) planRow(row *ProvisioningRowPlan, catalog *provisioningCatalog) error {
+	spec := row.Spec
+	if len(spec.Email) == 0 {
+		return errors.ArgumentMissing.With("email").WithStack()
+	}
+	if len(spec.Division) > 0 {
+		if row.Division = catalog.divisions[strings.ToLower(spec.Division)]; row.Division == nil {
+			return errors.ArgumentInvalid.With("division", spec.Division).WithStack()
+		}
+	}
+	for _, name := range spec.Roles {
+		grant := ProvisioningGrant{Division: row.Division}
+		if separator := strings.LastIndex(name, "@"); separator >= 0 {
+			division := strings.TrimSpace(name[separator+1:])
+			if grant.Division = catalog.divisions[strings.ToLower(division)]; grant.Division == nil {
+				return errors.ArgumentInvalid.With("division", division).WithStack()
+			}
+			name = strings.TrimSpace(name[:separator])
+		}
+		if grant.Division == nil {
+			grant.Division = catalog.home
+		}
+		if grant.Role = catalog.roles[strings.ToLower(name)]; grant.Role == nil {
+			return errors.ArgumentInvalid.With("role", name).WithStack()
+		}
+		row.Grants = append(row.Grants, grant)
+	}
+	for _, name := range spec.Groups {
+		group := catalog.groups[strings.ToLower(name)]
+		if group == nil {
+			return errors.ArgumentInvalid.With("group", name).WithStack()
+		}
+		row.Groups = append(row.Groups, group)
+	}
+	for _, name := range spec.Queues {
+		queue := catalog.queues[strings.ToLower(name)]
+		if queue == nil {
+			return errors.ArgumentInvalid.With("queue", name).WithStack()
+		}
+		row.Queues = append(row.Queues, queue)
+	}
+	for _, name := range sortedProvisioningKeys(spec.Skills) {
+		id, found := catalog.skills[strings.ToLower(name)]
+		if !found {
+			return errors.ArgumentInvalid.With("skill", name).WithStack()
+		}
+		row.Skills = append(row.Skills, &UserRoutingSkill{ID: id, Name: name, Proficiency: spec.Skills[name]})
+	}
+	for _, name := range sortedProvisioningKeys(spec.Languages) {
+		id, found := catalog.languages[strings.ToLower(name)]
+		if !found {
+			return errors.ArgumentInvalid.With("language", name).WithStack()
+		}
+		row.Languages = append(row.Languages, &UserRoutingLanguage{ID: id, Name: name, Proficiency: spec.Languages[name]})
+	}
+	if len(spec.Station) > 0 {
+		station, found := catalog.stations[strings.ToLower(spec.Station)]
+		if !found {
+			err := plan.retry(context.Background(), false, func() (err error) {
+				station, err = plan.Client.FindStationByName(spec.Station)
+				return
+			})
+			if err != nil {
+				if errors.Is(err, errors.NotFound) {
+					return errors.ArgumentInvalid.With("station", spec.Station).WithStack()
+				}
+				return err
+			}
+			catalog.stations[strings.ToLower(spec.Station)] = station
+		}
+		row.Station = station
+	}
+	var user *User
+	err := plan.retry(context.Background(), false, func() (err error) {
+		user, err = plan.Client.FindUserByEmail(spec.Email)
+		return
+	})
+	if err != nil && !errors.Is(err, errors.NotFound) {
+		return err
+	}
+	if user == nil {
+		if len(spec.Name) == 0 {
+			return errors.ArgumentMissing.With("name").WithStack()
+		}
+		return nil
+	}
+	row.User = user
+	update := UserUpdate{Version: user.Version}
+	if len(spec.Name) > 0 && spec.Name != user.Name {
+		update.Name = spec.Name
+	}
+	if len(spec.Department) > 0 && spec.Department != user.Department {
+		update.Department = spec.Department
+	}
+	if len(spec.Title) > 0 && spec.Title != user.Title {
+		update.Title = spec.Title
+	}
+	if len(update.Name) > 0 || len(update.Department) > 0 || len(update.Title) > 0 {
+		row.Update = &update
+	}
+	row.Move = row.Division != nil && (user.Division == nil || user.Division.ID != row.Division.ID)
+	return nil
+}
+
+// updatedProperties gets the names of the properties this row updates on an existing user
+func (row ProvisioningRowPlan) updatedProperties() []string {
+	properties := []string{}
+	if row.Update == nil {
+		return properties
+	}
+	if len(row.Update.Name) > 0 {
+		properties = append(properties, "name")
+	}
+	if len(row.Update.Department) > 0 {
+		properties = append(properties, "department")
+	}
+	if len(row.Update.Title) > 0 {
+		properties = append(properties, "title")
+	}
+	return properties
+}
+
+func sortedProvisioningKeys(values map[string]float64) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Apply provisions the users of this plan
+//
+// Up to Concurrency users are provisioned at the same time, rate-limited requests (HTTP 429) are retried with a backoff.
+// The steps of a row stop at its first error, each row gets its own result.
+// Rows that are not started when the context is cancelled get the context error.
+func (plan *ProvisioningPlan) Apply(ctx context.Context) []ProvisioningResult {
+	results := make([]ProvisioningResult, len(plan.Rows))
+	concurrency := plan.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for worker := 0; worker < concurrency; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				results[index] = plan.applyRow(ctx, plan.Rows[index])
+			}
+		}()
+	}
+	for index, row := range plan.Rows {
+		select {
+		case indexes <- index:
+		case <-ctx.Done():
+			results[index] = ProvisioningResult{Row: row.Row, Email: row.Spec.Email, Error: errors.WithStack(ctx.Err())}
+		}
+	}
+	close(indexes)
+	wg.Wait()
+	return results
+}
+
+func (plan *ProvisioningPlan) applyRow(ctx context.Context, row *ProvisioningRowPlan) (result ProvisioningResult) {
+	result = ProvisioningResult{Row: row.Row, Email: row.Spec.Email, User: row.User, Actions: []string{}}
+	if row.Error != nil {
+		result.Error = row.Error
+		return
+	}
+	step := func(action string, conflicts bool, perform func() error) bool {
+		if result.Error = plan.retry(ctx, conflicts, perform); result.Error != nil {
+			result.Error = errors.WithMessage(result.Error, action)
+			return false
+		}
+		result.Actions = append(result.Actions, action)
+		return true
+	}
+
+	if result.User == nil {
+		create := UserCreate{Name: row.Spec.Name, Email: row.Spec.Email, Department: row.Spec.Department, Title: row.Spec.Title}
+		if row.Division != nil {
+			create.DivisionID = row.Division.ID.String()
+		}
+		if !step("create user", false, func() (err error) {
+			result.User, err = plan.Client.CreateUser(create)
+			return
+		}) {
+			return
+		}
+		result.Created = true
+	} else {
+		if result.User.Client == nil {
+			result.User.Client = plan.Client
+		}
+		if row.Update != nil {
+			update := *row.Update
+			if !step(fmt.Sprintf("update user %s", strings.Join(row.updatedProperties(), "/")), false, func() error { return result.User.Update(update) }) {
+				return
+			}
+		}
+		if row.Move {
+			if !step(fmt.Sprintf("move to division %s", row.Division), false, func() error { return result.User.MoveToDivision(row.Division) }) {
+				return
+			}
+		}
+	}
+	user := result.User
+	for _, grant := range row.Grants {
+		grant := grant
+		if !step(fmt.Sprintf("grant %s@%s", grant.Role, grant.Division), false, func() error { return user.GrantRole(grant.Role, grant.Division) }) {
+			return
+		}
+	}
+	for _, group := range row.Groups {
+		group := group
+		if !step(fmt.Sprintf("add to group %s", group), true, func() error { return group.AddMembers(user) }) {
+			return
+		}
+	}
+	for _, queue := range row.Queues {
+		queue := queue
+		if !step(fmt.Sprintf("add to queue %s", queue), false, func() error { return queue.AddMembers(user) }) {
+			return
+		}
+	}
+	if len(row.Skills) > 0 {
+		if !step("set skills", false, func() error { _, err := user.PatchRoutingSkills(row.Skills...); return err }) {
+			return
+		}
+	}
+	if len(row.Languages) > 0 {
+		if !step("set languages", false, func() error { _, err := user.PatchRoutingLanguages(row.Languages...); return err }) {
+			return
+		}
+	}
+	if row.Station != nil {
+		if !step(fmt.Sprintf("set station %s", row.Station.Name), false, func() error { return user.SetDefaultStation(row.Station) }) {
+			return
+		}
+	}
+	return
+}
+
+// retry performs the given function until it succeeds, fails with an error that is not a rate limit, or MaxAttempts is reached
+//
+// The backoff is jittered so concurrent rows do not retry together, and GCloud's Retry-After is honored when it is longer.
+// If conflicts is true, conflicts (HTTP 409) are retried too (e.g.: the version of a group changed)
+func (plan *ProvisioningPlan) retry(ctx context.Context, conflicts bool, perform func() error) (err error) {
+	backoff := plan.MinBackoff
+	if backoff <= 0 {
+		backoff = 1 * time.Second
+	}
+	for attempt := 1; ; attempt++ {
+		if err = perform(); err == nil {
+			return nil
+		}
+		var apiError APIError
+		if !errors.As(err, &apiError) || attempt >= plan.MaxAttempts {
+			return err
+		}
+		if apiError.Status != 429 && !(conflicts && apiError.Status == 409) {
+			return err
+		}
+		delay := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		if apiError.RetryAfter > delay {
+			delay = apiError.RetryAfter
+		}
+		plan.Client.Logger.Warnf("Request was rejected with status %d, retrying in %s (attempt %d/%d)", apiError.Status, delay, attempt, plan.MaxAttempts)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return errors.WithStack(ctx.Err())
+		}
+		backoff *= 2
+	}
+}
+
+// String gets a string version, one line per row
+//
+// This is the dry-run report of the ProvisioningPlan
+//
+//   implements the fmt.Stringer interface
+func (plan ProvisioningPlan) String() string {
+	var builder strings.Builder
+	for _, row := range plan.Rows {
+		builder.WriteString(row.String())
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
+
+// String gets a string version
+//
+//   implements the fmt.Stringer interface
+func (row ProvisioningRowPlan) String() string {
+	if row.Error != nil {
+		return fmt.Sprintf("row %d %s: error: %s", row.Row, row.Spec.Email, row.Error)
+	}
+	actions := []string{}
+	if row.User == nil {
+		actions = append(actions, "create user")
+	} else {
+		if row.Update != nil {
+			actions = append(actions, fmt.Sprintf("update user %s (%s)", row.User.ID, strings.Join(row.updatedProperties(), ", ")))
+		} else {
+			actions = append(actions, fmt.Sprintf("existing user %s", row.User.ID))
+		}
+		if row.Move {
+			actions = append(actions, fmt.Sprintf("move to division %s", row.Division))
+		}
+	}
+	for _, grant := range row.Grants {
+		actions = append(actions, fmt.Sprintf("grant %s@%s", grant.Role, grant.Division))
+	}
+	for _, group := range row.Groups {
+		actions = append(actions, fmt.Sprintf("add to group %s", group))
+	}
+	for _, queue := range row.Queues {
+		actions = append(actions, fmt.Sprintf("add to queue %s", queue))
+	}
+	for _, skill := range row.Skills {
+		actions = append(actions, fmt.Sprintf("set skill %s (%g)", skill.Name, skill.Proficiency))
+	}
+	for _, language := range row.Languages {
+		actions = append(actions, fmt.Sprintf("set language %s (%g)", language.Name, language.Proficiency))
+	}
+	if row.Station != nil {
+		actions = append(actions, fmt.Sprintf("set station %s", row.Station.Name))
+	}
+	return fmt.Sprintf("row %d %s: %s", row.Row, row.Spec.Email, strings.Join(actions, ", "))
+}
+
+// WriteProvisioningResultsCSV writes the results of a provisioning as CSV
+//
+// The columns are: row, email, userId, created, actions, error
+func WriteProvisioningResultsCSV(writer io.Writer, results []ProvisioningResult) error {
+	output := csv.NewWriter(writer)
+	if err := output.Write([]string{"row", "email", "userId", "created", "actions", "error"}); err != nil {
+		return errors.WithStack(err)
+	}
+	for _, result := range results {
+		userID := ""
+		if result.User != nil && result.User.ID != uuid.Nil {
+			userID = result.User.ID.String()
+		}
+		message := ""
+		if result.Error != nil {
+			message = result.Error.Error()
+		}
+		record := []string{strconv.Itoa(result.Row), result.Email, userID, strconv.FormatBool(result.Created), strings.Join(result.Actions, ";"), message}
+		if err := output.Write(record); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	output.Flush()
+	return errors.WithStack(output.Error())
+}
@@ -0,0 +1,328 @@
+package gcloudcx_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gildas/go-errors"
+	"github.com/gildas/go-gcloudcx"
+	"github.com/gildas/go-logger"
+	"github.com/stretchr/testify/suite"
+)
+
+type ProvisioningSuite struct {
+	suite.Suite
+	Name   string
+	Logger *logger.Logger
+	Start  time.Time
+
+	Recorder *provisioningRecorder
+	Server   *httptest.Server
+	Client   *gcloudcx.Client
+}
+
+type provisioningRecorder struct {
+	requests   []string
+	bodies     map[string]string
+	rateLimits map[string]int // number of times the requests starting with the key are rate-limited
+	retryAfter string
+	mutex      sync.Mutex
+}
+
+func (recorder *provisioningRecorder) record(request, body string) (rateLimit bool, retryAfter string) {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	recorder.requests = append(recorder.requests, request)
+	recorder.bodies[request] = body
+	for prefix, count := range recorder.rateLimits {
+		if strings.HasPrefix(request, prefix) && count > 0 {
+			recorder.rateLimits[prefix] = count - 1
+			return true, recorder.retryAfter
+		}
+	}
+	return false, ""
+}
+
+func (recorder *provisioningRecorder) reset() {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	recorder.requests = []string{}
+	recorder.bodies = map[string]string{}
+	recorder.rateLimits = map[string]int{"POST /api/v2/routing/queues/": 1}
+	recorder.retryAfter = ""
+}
+
+func (recorder *provisioningRecorder) rateLimit(prefix string, count int, retryAfter string) {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	recorder.rateLimits[prefix] = count
+	recorder.retryAfter = retryAfter
+}
+
+func (recorder *provisioningRecorder) body(request string) string {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	return recorder.bodies[request]
+}
+
+func (recorder *provisioningRecorder) count(request string) (count int) {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	for _, recorded := range recorder.requests {
+		if recorded == request {
+			count++
+		}
+	}
+	return
+}
+
+func (recorder *provisioningRecorder) writes() []string {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	writes := []string{}
+	for _, request := range recorder.requests {
+		if !strings.HasPrefix(request, "GET ") {
+			writes = append(writes, request)
+		}
+	}
+	sort.Strings(writes)
+	return writes
+}
+
+func TestProvisioningSuite(t *testing.T) {
+	suite.Run(t, new(ProvisioningSuite))
+}
+
+func (suite *ProvisioningSuite) TestCanReadCSV() {
+	specs, err := gcloudcx.ReadProvisioningSpecsCSV(strings.NewReader("name,email,division,roles,queues,skills\nJohn Doe,john.doe@acme.com,Sales,Employee;Agent@Home,Sales;Support,Sales:3;Support:1\n"))
+	suite.Require().Nilf(err, "Failed to read specs. %s", err)
+	suite.Require().Len(specs, 1)
+	suite.Assert().Equal("John Doe", specs[0].Name)
+	suite.Assert().Equal("Sales", specs[0].Division)
+	suite.Assert().Equal([]string{"Employee", "Agent@Home"}, specs[0].Roles)
+	suite.Assert().Equal([]string{"Sales", "Support"}, specs[0].Queues)
+	suite.Assert().Equal(map[string]float64{"Sales": 3, "Support": 1}, specs[0].Skills)
+	suite.Assert().Empty(specs[0].Groups)
+}
+
+func (suite *ProvisioningSuite) TestShouldNotReadCSVWithUnknownColumn() {
+	_, err := gcloudcx.ReadProvisioningSpecsCSV(strings.NewReader("name,email,shoesize\nJohn Doe,john.doe@acme.com,42\n"))
+	suite.Require().NotNil(err, "Specs should not have been read")
+	suite.Assert().True(errors.Is(err, errors.ArgumentInvalid), "Error should be an ArgumentInvalid")
+}
+
+func (suite *ProvisioningSuite) TestCanReadJSON() {
+	specs, err := gcloudcx.ReadProvisioningSpecsJSON(strings.NewReader(`[{"name": "John Doe", "email": "john.doe@acme.com", "groups": ["Sales Team"], "skills": {"Sales": 3}}]`))
+	suite.Require().Nilf(err, "Failed to read specs. %s", err)
+	suite.Require().Len(specs, 1)
+	suite.Assert().Equal([]string{"Sales Team"}, specs[0].Groups)
+	suite.Assert().Equal(map[string]float64{"Sales": 3}, specs[0].Skills)
+}
+
+func (suite *ProvisioningSuite) TestCanPlanAndApply() {
+	specs := []*gcloudcx.ProvisioningSpec{
+		{Name: "John Doe", Email: "john.doe@acme.com", Division: "Sales", Roles: []string{"Agent"}, Groups: []string{"Sales Team"}, Queues: []string{"Sales"}, Skills: map[string]float64{"Sales": 3}},
+		{Name: "Jane Doe", Email: "jane.doe@acme.com", Roles: []string{"Agent@Sales"}},
+		{Name: "Bob Smith", Email: "bob.smith@acme.com", Queues: []string{"Nowhere"}},
+	}
+	plan, err := suite.Client.PlanProvisioning(specs)
+	suite.Require().Nilf(err, "Failed to plan. %s", err)
+	suite.Logger.Infof("Plan:\n%s", plan)
+	suite.Require().Len(plan.Rows, 3)
+	suite.Assert().Equal("row 1 john.doe@acme.com: create user, grant Agent@Sales, add to group Sales Team, add to queue Sales, set skill Sales (3)", plan.Rows[0].String())
+	suite.Assert().Equal("row 2 jane.doe@acme.com: existing user 06ffcd2e-1ada-412e-a5f5-30d7853246dd, grant Agent@Sales", plan.Rows[1].String())
+	suite.Require().NotNil(plan.Rows[2].Error)
+	suite.Assert().True(errors.Is(plan.Rows[2].Error, errors.ArgumentInvalid), "Error should be an ArgumentInvalid")
+
+	plan.MinBackoff = 10 * time.Millisecond
+	suite.Recorder.reset()
+	results := plan.Apply(context.Background())
+	suite.Require().Len(results, 3)
+	suite.Assert().Nil(results[0].Error)
+	suite.Assert().True(results[0].Created)
+	suite.Assert().Equal([]string{"create user", "grant Agent@Sales", "add to group Sales Team", "add to queue Sales", "set skills"}, results[0].Actions)
+	suite.Assert().Nil(results[1].Error)
+	suite.Assert().False(results[1].Created)
+	suite.Assert().NotNil(results[2].Error)
+	suite.Assert().Equal([]string{
+		"PATCH /api/v2/users/11111111-1ada-412e-a5f5-30d7853246dd/routingskills/bulk",
+		"POST /api/v2/authorization/subjects/06ffcd2e-1ada-412e-a5f5-30d7853246dd/divisions/5d5d0d8a-0b5c-4c6e-8f27-5f4c6a3e9a01/roles/agent",
+		"POST /api/v2/authorization/subjects/11111111-1ada-412e-a5f5-30d7853246dd/divisions/5d5d0d8a-0b5c-4c6e-8f27-5f4c6a3e9a01/roles/agent",
+		"POST /api/v2/groups/7e7e0d8a-0b5c-4c6e-8f27-5f4c6a3e9a02/members",
+		"POST /api/v2/routing/queues/8f8f0d8a-0b5c-4c6e-8f27-5f4c6a3e9a03/members",
+		"POST /api/v2/routing/queues/8f8f0d8a-0b5c-4c6e-8f27-5f4c6a3e9a03/members",
+		"POST /api/v2/users",
+	}, suite.Recorder.writes(), "The rate-limited queue request should have been retried")
+
+	report := bytes.Buffer{}
+	err = gcloudcx.WriteProvisioningResultsCSV(&report, results)
+	suite.Require().Nilf(err, "Failed to write report. %s", err)
+	lines := strings.Split(strings.TrimSpace(report.String()), "\n")
+	suite.Require().Len(lines, 4)
+	suite.Assert().Equal("row,email,userId,created,actions,error", lines[0])
+	suite.Assert().Equal("1,john.doe@acme.com,11111111-1ada-412e-a5f5-30d7853246dd,true,create user;grant Agent@Sales;add to group Sales Team;add to queue Sales;set skills,", lines[1])
+}
+
+func (suite *ProvisioningSuite) TestCanUpdateExistingUser() {
+	specs := []*gcloudcx.ProvisioningSpec{
+		{Name: "Mary Major", Email: "mary.major@acme.com", Department: "Support", Title: "Manager", Division: "Sales"},
+	}
+	plan, err := suite.Client.PlanProvisioning(specs)
+	suite.Require().Nilf(err, "Failed to plan. %s", err)
+	suite.Require().Len(plan.Rows, 1)
+	suite.Require().Nil(plan.Rows[0].Error)
+	suite.Assert().Equal("row 1 mary.major@acme.com: update user 22222222-1ada-412e-a5f5-30d7853246dd (title), move to division Sales", plan.Rows[0].String())
+
+	suite.Recorder.reset()
+	results := plan.Apply(context.Background())
+	suite.Require().Len(results, 1)
+	suite.Require().Nilf(results[0].Error, "Failed to apply. %s", results[0].Error)
+	suite.Assert().False(results[0].Created)
+	suite.Assert().Equal([]string{"update user title", "move to division Sales"}, results[0].Actions)
+	suite.Assert().Equal([]string{
+		"PATCH /api/v2/users/22222222-1ada-412e-a5f5-30d7853246dd",
+		"POST /api/v2/authorization/divisions/5d5d0d8a-0b5c-4c6e-8f27-5f4c6a3e9a01/objects/USER",
+	}, suite.Recorder.writes())
+	suite.Assert().JSONEq(`{"title": "Manager", "version": 4}`, suite.Recorder.body("PATCH /api/v2/users/22222222-1ada-412e-a5f5-30d7853246dd"))
+	suite.Assert().JSONEq(`["22222222-1ada-412e-a5f5-30d7853246dd"]`, suite.Recorder.body("POST /api/v2/authorization/divisions/5d5d0d8a-0b5c-4c6e-8f27-5f4c6a3e9a01/objects/USER"))
+	suite.Assert().Equal("Manager", results[0].User.Title)
+	suite.Assert().Equal(5, results[0].User.Version)
+}
+
+func (suite *ProvisioningSuite) TestShouldNotUpdateUnchangedUser() {
+	specs := []*gcloudcx.ProvisioningSpec{
+		{Name: "Mary Major", Email: "mary.major@acme.com", Department: "Support", Division: "Home"},
+	}
+	plan, err := suite.Client.PlanProvisioning(specs)
+	suite.Require().Nilf(err, "Failed to plan. %s", err)
+	suite.Assert().Equal("row 1 mary.major@acme.com: existing user 22222222-1ada-412e-a5f5-30d7853246dd", plan.Rows[0].String())
+	suite.Assert().Nil(plan.Rows[0].Update)
+	suite.Assert().False(plan.Rows[0].Move)
+
+	suite.Recorder.reset()
+	results := plan.Apply(context.Background())
+	suite.Require().Nil(results[0].Error)
+	suite.Assert().Empty(results[0].Actions)
+	suite.Assert().Empty(suite.Recorder.writes())
+}
+
+func (suite *ProvisioningSuite) TestShouldRetryUserLookupWhilePlanning() {
+	suite.Recorder.rateLimit("POST /api/v2/users/search", 1, "")
+	plan, err := suite.Client.PlanProvisioning([]*gcloudcx.ProvisioningSpec{{Email: "jane.doe@acme.com"}})
+	suite.Require().Nilf(err, "Failed to plan. %s", err)
+	suite.Require().Nilf(plan.Rows[0].Error, "The rate-limited lookup should have been retried. %s", plan.Rows[0].Error)
+	suite.Require().NotNil(plan.Rows[0].User)
+	suite.Assert().Equal(2, suite.Recorder.count("POST /api/v2/users/search"))
+}
+
+func (suite *ProvisioningSuite) TestShouldHonorRetryAfter() {
+	plan, err := suite.Client.PlanProvisioning([]*gcloudcx.ProvisioningSpec{{Name: "John Doe", Email: "john.doe@acme.com"}})
+	suite.Require().Nilf(err, "Failed to plan. %s", err)
+	plan.MinBackoff = 10 * time.Millisecond
+	suite.Recorder.reset()
+	suite.Recorder.rateLimit("POST /api/v2/users", 1, "1")
+
+	start := time.Now()
+	results := plan.Apply(context.Background())
+	suite.Require().Nilf(results[0].Error, "Failed to apply. %s", results[0].Error)
+	suite.Assert().GreaterOrEqual(time.Since(start), time.Second, "The retry should wait for Retry-After")
+	suite.Assert().Equal(2, suite.Recorder.count("POST /api/v2/users"))
+}
+
+// Suite Tools
+
+func (suite *ProvisioningSuite) SetupSuite() {
+	suite.Name = strings.TrimSuffix(reflect.TypeOf(*suite).Name(), "Suite")
+	suite.Logger = logger.Create("test",
+		&logger.FileStream{
+			Path:        fmt.Sprintf("./log/test-%s.log", strings.ToLower(suite.Name)),
+			Unbuffered:  true,
+			FilterLevel: logger.TRACE,
+		},
+	).Child("test", "test")
+	suite.Logger.Infof("Suite Start: %s %s", suite.Name, strings.Repeat("=", 80-14-len(suite.Name)))
+	suite.Recorder = &provisioningRecorder{}
+	suite.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		body, _ := ioutil.ReadAll(r.Body)
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		if rateLimit, retryAfter := suite.Recorder.record(r.Method+" "+r.URL.Path, string(body)); rateLimit {
+			if len(retryAfter) > 0 {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"status": 429, "code": "too.many.requests", "message": "Rate limit exceeded"}`))
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v2/authorization/divisions":
+			_, _ = w.Write([]byte(`{"entities": [{"id": "5d5d0d8a-0b5c-4c6e-8f27-5f4c6a3e9a01", "name": "Sales"}, {"id": "5d5d0d8a-0b5c-4c6e-8f27-5f4c6a3e9a00", "name": "Home"}], "pageCount": 1}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v2/authorization/divisions/home":
+			_, _ = w.Write([]byte(`{"id": "5d5d0d8a-0b5c-4c6e-8f27-5f4c6a3e9a00", "name": "Home"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v2/authorization/roles":
+			_, _ = w.Write([]byte(`{"entities": [{"id": "agent", "name": "Agent"}], "pageCount": 1}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v2/groups":
+			_, _ = w.Write([]byte(`{"entities": [{"id": "7e7e0d8a-0b5c-4c6e-8f27-5f4c6a3e9a02", "name": "Sales Team", "version": 3}], "pageCount": 1}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v2/groups/7e7e0d8a-0b5c-4c6e-8f27-5f4c6a3e9a02":
+			_, _ = w.Write([]byte(`{"id": "7e7e0d8a-0b5c-4c6e-8f27-5f4c6a3e9a02", "name": "Sales Team", "version": 3}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v2/routing/queues":
+			_, _ = w.Write([]byte(`{"entities": [{"id": "8f8f0d8a-0b5c-4c6e-8f27-5f4c6a3e9a03", "name": "Sales"}], "pageCount": 1}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v2/routing/skills":
+			_, _ = w.Write([]byte(`{"entities": [{"id": "sales", "name": "Sales"}], "pageCount": 1}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v2/users/search":
+			search := gcloudcx.UserSearchQuery{}
+			_ = json.NewDecoder(r.Body).Decode(&search)
+			if search.Query[0].Value == "jane.doe@acme.com" {
+				_, _ = w.Write([]byte(`{"results": [{"id": "06ffcd2e-1ada-412e-a5f5-30d7853246dd", "name": "Jane Doe"}], "total": 1, "pageCount": 1}`))
+			} else if search.Query[0].Value == "mary.major@acme.com" {
+				_, _ = w.Write([]byte(`{"results": [{"id": "22222222-1ada-412e-a5f5-30d7853246dd", "name": "Mary Major", "department": "Support", "title": "Agent", "version": 4, "division": {"id": "5d5d0d8a-0b5c-4c6e-8f27-5f4c6a3e9a00", "name": "Home"}}], "total": 1, "pageCount": 1}`))
+			} else {
+				_, _ = w.Write([]byte(`{"results": [], "total": 0, "pageCount": 0}`))
+			}
+		case r.Method == http.MethodPatch && r.URL.Path == "/api/v2/users/22222222-1ada-412e-a5f5-30d7853246dd":
+			_, _ = w.Write([]byte(`{"id": "22222222-1ada-412e-a5f5-30d7853246dd", "name": "Mary Major", "department": "Support", "title": "Manager", "version": 5}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v2/users":
+			_, _ = w.Write([]byte(`{"id": "11111111-1ada-412e-a5f5-30d7853246dd", "name": "John Doe"}`))
+		case r.Method == http.MethodGet:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"status": 404, "code": "not.found", "message": "Not Found"}`))
+		default:
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	suite.Client = CreateTestClient(suite.Server.URL, suite.Logger)
+}
+
+func (suite *ProvisioningSuite) TearDownSuite() {
+	suite.Server.Close()
+	if suite.T().Failed() {
+		suite.Logger.Warnf("At least one test failed, we are not cleaning")
+		suite.T().Log("At least one test failed, we are not cleaning")
+	} else {
+		suite.Logger.Infof("All tests succeeded, we are cleaning")
+	}
+	suite.Logger.Infof("Suite End: %s %s", suite.Name, strings.Repeat("=", 80-12-len(suite.Name)))
+	suite.Logger.Close()
+}
+
+func (suite *ProvisioningSuite) BeforeTest(suiteName, testName string) {
+	suite.Logger.Infof("Test Start: %s %s", testName, strings.Repeat("-", 80-13-len(testName)))
+	suite.Start = time.Now()
+	suite.Recorder.reset()
+}
+
+func (suite *ProvisioningSuite) AfterTest(suiteName, testName string) {
+	duration := time.Since(suite.Start)
+	suite.Logger.Record("duration", duration.String()).Infof("Test End: %s %s", testName, strings.Repeat("-", 80-11-len(testName)))
+}
@@ -14,6 +14,7 @@ import (
 type Queue struct {
 	ID                    uuid.UUID      `json:"id"`
 	Name                  string         `json:"name"`
+	Description           string         `json:"description,omitempty"`
 	CreatedBy             *User          `json:"-"`
 	ModifiedBy            string         `json:"modifiedBy"`
 	DateCreated           time.Time      `json:"dateCreated"`
@@ -22,7 +23,7 @@ type Queue struct {
 	MediaSettings         MediaSettings  `json:"mediaSettings"`
 	ACWSettings           ACWSettings    `json:"acwSettings"`
 	SkillEvaluationMethod string         `json:"skillEvaluationMethod"`
-	AutoAnswerOnly        bool           `json:"true"`
+	AutoAnswerOnly        bool           `json:"autoAnswerOnly"`
 	DefaultScripts        interface{}    `json:"defaultScripts"`
 	SelfURI               string         `json:"selfUri"`
 	Client                *Client        `json:"-"`
@@ -35,6 +36,155 @@ type RoutingTarget struct {
 	Address string `json:"targetAddress,omitempty"`
 }
 
+// queueRequest is the payload sent to GENESYS Cloud to create or update a Queue
+type queueRequest struct {
+	Name                  string        `json:"name"`
+	Description           string        `json:"description,omitempty"`
+	Division              *queueEntity  `json:"division,omitempty"`
+	MediaSettings         MediaSettings `json:"mediaSettings,omitempty"`
+	ACWSettings           *ACWSettings  `json:"acwSettings,omitempty"`
+	SkillEvaluationMethod string        `json:"skillEvaluationMethod,omitempty"`
+	AutoAnswerOnly        bool          `json:"autoAnswerOnly"`
+}
+
+// queueEntity is the reference to an entity in a queueRequest
+type queueEntity struct {
+	ID uuid.UUID `json:"id"`
+}
+
+func newQueueRequest(queue *Queue) queueRequest {
+	request := queueRequest{
+		Name:                  queue.Name,
+		Description:           queue.Description,
+		MediaSettings:         queue.MediaSettings,
+		SkillEvaluationMethod: queue.SkillEvaluationMethod,
+		AutoAnswerOnly:        queue.AutoAnswerOnly,
+	}
+	if queue.Division != nil && queue.Division.ID != uuid.Nil {
+		request.Division = &queueEntity{ID: queue.Division.ID}
+	}
+	if len(queue.ACWSettings.WrapupPrompt) > 0 {
+		request.ACWSettings = &queue.ACWSettings
+	}
+	return request
+}
+
+// Initialize initializes this from the given Client
+//   implements Initializable
+//   if the queue ID is given in queue, the queue is fetched
+func (queue *Queue) Initialize(parameters ...interface{}) error {
+	client, logger, id, err := parseParameters(queue, parameters...)
+	if err != nil {
+		return err
+	}
+	if id != uuid.Nil {
+		if err := client.Get(NewURI("/routing/queues/%s", id), &queue); err != nil {
+			return err
+		}
+	}
+	queue.Client = client
+	queue.Logger = logger.Child("queue", "queue", "queue", queue.ID)
+	return nil
+}
+
+// CreateQueue creates a new Queue from the given Queue
+//   Name, Description, Division, MediaSettings, ACWSettings, SkillEvaluationMethod, and AutoAnswerOnly are used
+//   see https://developer.genesys.cloud/api/rest/v2/routing/#post-api-v2-routing-queues
+func (client *Client) CreateQueue(queue *Queue) (*Queue, error) {
+	if queue == nil || len(queue.Name) == 0 {
+		return nil, errors.ArgumentMissing.With("name").WithStack()
+	}
+	created := &Queue{}
+	if err := client.Post("/routing/queues", newQueueRequest(queue), &created); err != nil {
+		return nil, err
+	}
+	created.Client = client
+	created.Logger = client.Logger.Child("queue", "queue", "queue", created.ID)
+	return created, nil
+}
+
+// Update updates this Queue in GENESYS Cloud with its current Name, Description, Division, MediaSettings, ACWSettings, SkillEvaluationMethod, and AutoAnswerOnly
+//   As GENESYS Cloud replaces the whole queue, the queue is fetched first and these properties are merged in it,
+//   so the other properties (flows, routing rules, etc) and the media settings this library does not know are kept.
+//   Empty Description, Division, and ACWSettings are not changed.
+//   see https://developer.genesys.cloud/api/rest/v2/routing/#put-api-v2-routing-queues--queueId-
+func (queue *Queue) Update() error {
+	if len(queue.Name) == 0 {
+		return errors.ArgumentMissing.With("name").WithStack()
+	}
+	current := map[string]json.RawMessage{}
+	if err := queue.Client.Get(NewURI("/routing/queues/%s", queue.ID), &current); err != nil {
+		return err
+	}
+	payload, err := mergeQueueRequest(current, newQueueRequest(queue))
+	if err != nil {
+		return err
+	}
+	updated := &Queue{}
+	if err := queue.Client.Put(NewURI("/routing/queues/%s", queue.ID), payload, &updated); err != nil {
+		return err
+	}
+	updated.Client = queue.Client
+	updated.Logger = queue.Logger
+	*queue = *updated
+	return nil
+}
+
+// mergeQueueRequest merges a queueRequest in the JSON of a queue
+//   the media settings are merged per media type
+//   the result is JSON as go-request would send a map as a form
+func mergeQueueRequest(current map[string]json.RawMessage, request queueRequest) (json.RawMessage, error) {
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return nil, errors.JSONMarshalError.Wrap(err)
+	}
+	changes := map[string]json.RawMessage{}
+	if err = json.Unmarshal(payload, &changes); err != nil {
+		return nil, errors.JSONUnmarshalError.Wrap(err)
+	}
+	for key, value := range changes {
+		if key == "mediaSettings" {
+			merged, err := mergeQueueMediaSettings(current[key], value)
+			if err != nil {
+				return nil, err
+			}
+			value = merged
+		}
+		current[key] = value
+	}
+	merged, err := json.Marshal(current)
+	return merged, errors.JSONMarshalError.Wrap(err)
+}
+
+func mergeQueueMediaSettings(current, changes json.RawMessage) (json.RawMessage, error) {
+	settings := map[string]map[string]json.RawMessage{}
+	if len(current) > 0 {
+		if err := json.Unmarshal(current, &settings); err != nil {
+			return nil, errors.JSONUnmarshalError.Wrap(err)
+		}
+	}
+	changed := map[string]map[string]json.RawMessage{}
+	if err := json.Unmarshal(changes, &changed); err != nil {
+		return nil, errors.JSONUnmarshalError.Wrap(err)
+	}
+	for media, setting := range changed {
+		if settings[media] == nil {
+			settings[media] = map[string]json.RawMessage{}
+		}
+		for key, value := range setting {
+			settings[media][key] = value
+		}
+	}
+	payload, err := json.Marshal(settings)
+	return payload, errors.JSONMarshalError.Wrap(err)
+}
+
+// Delete deletes this Queue
+//   if force is true, the queue is deleted even if it has members or is used by flows
+func (queue *Queue) Delete(force ...bool) error {
+	return queue.Client.Delete(NewURI("/routing/queues/%s?forceDelete=%t", queue.ID, len(force) > 0 && force[0]), nil)
+}
+
 // FindQueueByName finds a Queue by its name
 func (client *Client) FindQueueByName(name string) (*Queue, error) {
 	response := struct {
@@ -67,6 +217,32 @@ func (client *Client) FindQueueByName(name string) (*Queue, error) {
 	return nil, errors.NotFound.With("queue", name).WithStack()
 }
 
+// FetchQueues fetches all the Queues of the organization
+//   see https://developer.genesys.cloud/api/rest/v2/routing/#get-api-v2-routing-queues
+func (client *Client) FetchQueues() ([]*Queue, error) {
+	queues := []*Queue{}
+	page := 1
+	for {
+		response := struct {
+			Entities  []*Queue `json:"entities"`
+			PageCount int      `json:"pageCount"`
+		}{}
+		if err := client.Get(NewURI("/routing/queues?pageSize=100&pageNumber=%d", page), &response); err != nil {
+			return nil, err
+		}
+		for _, queue := range response.Entities {
+			queue.Client = client
+			queue.Logger = client.Logger.Child("queue", "queue", "queue", queue.ID)
+		}
+		queues = append(queues, response.Entities...)
+		if page >= response.PageCount {
+			break
+		}
+		page++
+	}
+	return queues, nil
+}
+
 // GetID gets the identifier of this
 //   implements Identifiable
 func (queue Queue) GetID() uuid.UUID {
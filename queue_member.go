@@ -0,0 +1,120 @@
+package gcloudcx
+
+import (
+	"github.com/gildas/go-errors"
+	"github.com/google/uuid"
+)
+
+// QueueMember describes a member of a Queue
+type QueueMember struct {
+	ID            uuid.UUID      `json:"id"`
+	Name          string         `json:"name"`
+	User          *User          `json:"user,omitempty"`
+	RingNumber    int            `json:"ringNumber,omitempty"`
+	Joined        bool           `json:"joined"`
+	MemberBy      string         `json:"memberBy,omitempty"` // user, group
+	RoutingStatus *RoutingStatus `json:"routingStatus,omitempty"`
+	SelfURI       string         `json:"selfUri,omitempty"`
+}
+
+// FetchMembers fetches all the members of this Queue
+//
+// See https://developer.genesys.cloud/api/rest/v2/routing/#get-api-v2-routing-queues--queueId--members
+func (queue *Queue) FetchMembers() ([]*QueueMember, error) {
+	return queue.fetchMembers("")
+}
+
+// FetchJoinedMembers fetches the members of this Queue that joined it
+func (queue *Queue) FetchJoinedMembers() ([]*QueueMember, error) {
+	return queue.fetchMembers("&joined=true")
+}
+
+func (queue *Queue) fetchMembers(filter string) ([]*QueueMember, error) {
+	members := []*QueueMember{}
+	page := 1
+	for {
+		response := struct {
+			Entities  []*QueueMember `json:"entities"`
+			PageCount int            `json:"pageCount"`
+		}{}
+		if err := queue.Client.Get(NewURI("/routing/queues/%s/members?pageSize=100&pageNumber=%d%s", queue.ID, page, filter), &response); err != nil {
+			return nil, err
+		}
+		for _, member := range response.Entities {
+			if member.User != nil {
+				member.User.Client = queue.Client
+				member.User.Logger = queue.Client.Logger.Child("user", "user", "user", member.User.ID)
+			}
+		}
+		members = append(members, response.Entities...)
+		if page >= response.PageCount {
+			break
+		}
+		page++
+	}
+	return members, nil
+}
+
+// AddMembers adds the given users to this Queue
+//
+// GENESYS Cloud accepts up to 100 members per request, bigger lists are sent in several requests
+//
+// See https://developer.genesys.cloud/api/rest/v2/routing/#post-api-v2-routing-queues--queueId--members
+func (queue *Queue) AddMembers(members ...Identifiable) error {
+	return queue.sendMembers(false, members)
+}
+
+// RemoveMembers removes the given users from this Queue
+func (queue *Queue) RemoveMembers(members ...Identifiable) error {
+	return queue.sendMembers(true, members)
+}
+
+func (queue *Queue) sendMembers(remove bool, members []Identifiable) error {
+	for start := 0; start < len(members); start += 100 {
+		end := start + 100
+		if end > len(members) {
+			end = len(members)
+		}
+		payload := make([]queueEntity, 0, end-start)
+		for _, member := range members[start:end] {
+			payload = append(payload, queueEntity{ID: member.GetID()})
+		}
+		if err := queue.Client.Post(NewURI("/routing/queues/%s/members?delete=%t", queue.ID, remove), payload, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetMemberJoined joins or unjoins a member of this Queue
+//
+// See https://developer.genesys.cloud/api/rest/v2/routing/#patch-api-v2-routing-queues--queueId--members--memberId-
+func (queue *Queue) SetMemberJoined(member Identifiable, joined bool) error {
+	if member == nil || member.GetID() == uuid.Nil {
+		return errors.ArgumentMissing.With("member").WithStack()
+	}
+	return queue.Client.Patch(
+		NewURI("/routing/queues/%s/members/%s", queue.ID, member.GetID()),
+		struct {
+			Joined bool `json:"joined"`
+		}{Joined: joined},
+		nil,
+	)
+}
+
+// GetID gets the identifier of this
+//
+//   implements Identifiable
+func (member QueueMember) GetID() uuid.UUID {
+	return member.ID
+}
+
+// String gets a string version
+//
+//   implements the fmt.Stringer interface
+func (member QueueMember) String() string {
+	if len(member.Name) > 0 {
+		return member.Name
+	}
+	return member.ID.String()
+}
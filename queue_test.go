@@ -0,0 +1,285 @@
+package gcloudcx_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gildas/go-errors"
+	"github.com/gildas/go-gcloudcx"
+	"github.com/gildas/go-logger"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+type QueueSuite struct {
+	suite.Suite
+	Name   string
+	Logger *logger.Logger
+	Start  time.Time
+
+	QueueID  uuid.UUID
+	Recorder *RequestRecorder
+	Server   *httptest.Server
+	Client   *gcloudcx.Client
+}
+
+func TestQueueSuite(t *testing.T) {
+	suite.Run(t, new(QueueSuite))
+}
+
+func (suite *QueueSuite) TestCanCreateQueue() {
+	queue, err := suite.Client.CreateQueue(&gcloudcx.Queue{
+		Name:     "Sales",
+		Division: &gcloudcx.Division{ID: uuid.MustParse("5d5d0d8a-0b5c-4c6e-8f27-5f4c6a3e9a01"), Name: "Sales"},
+		MediaSettings: gcloudcx.MediaSettings{
+			"chat": gcloudcx.MediaSetting{AlertingTimeout: 30 * time.Second, ServiceLevel: gcloudcx.ServiceLevel{Percentage: 0.8, Duration: 20 * time.Second}},
+		},
+		SkillEvaluationMethod: "BEST",
+	})
+	suite.Require().Nilf(err, "Failed to create queue. %s", err)
+	suite.Assert().Equal(suite.QueueID, queue.ID)
+	suite.Assert().Equal([]string{"POST /api/v2/routing/queues"}, suite.Recorder.Requests())
+	payload := map[string]interface{}{}
+	suite.Require().Nil(json.Unmarshal([]byte(suite.Recorder.Bodies()[0]), &payload))
+	suite.Assert().Equal("Sales", payload["name"])
+	suite.Assert().Equal(map[string]interface{}{"id": "5d5d0d8a-0b5c-4c6e-8f27-5f4c6a3e9a01"}, payload["division"])
+	suite.Assert().NotContains(payload, "acwSettings", "Empty ACW settings should not be sent")
+	chat := payload["mediaSettings"].(map[string]interface{})["chat"].(map[string]interface{})
+	suite.Assert().Equal(float64(30), chat["alertingTimeoutSeconds"])
+	suite.Assert().Equal(map[string]interface{}{"percentage": 0.8, "durationMs": float64(20000)}, chat["serviceLevel"])
+}
+
+func (suite *QueueSuite) TestShouldNotCreateQueueWithoutName() {
+	_, err := suite.Client.CreateQueue(&gcloudcx.Queue{})
+	suite.Require().NotNil(err, "Queue should not have been created")
+	suite.Assert().True(errors.Is(err, errors.ArgumentMissing), "Error should be an ArgumentMissing")
+}
+
+func (suite *QueueSuite) TestCanUpdateAndDeleteQueue() {
+	queue := &gcloudcx.Queue{ID: suite.QueueID}
+	err := queue.Initialize(suite.Client)
+	suite.Require().Nilf(err, "Failed to initialize queue. %s", err)
+	suite.Assert().Equal("Sales", queue.Name)
+	suite.Assert().True(queue.AutoAnswerOnly)
+	suite.Assert().Equal(8*time.Second, queue.MediaSettings["chat"].AlertingTimeout)
+	queue.Description = "The Sales Queue"
+	queue.MediaSettings["chat"] = gcloudcx.MediaSetting{AlertingTimeout: 20 * time.Second, ServiceLevel: queue.MediaSettings["chat"].ServiceLevel}
+	err = queue.Update()
+	suite.Require().Nilf(err, "Failed to update queue. %s", err)
+	err = queue.Delete(true)
+	suite.Require().Nilf(err, "Failed to delete queue. %s", err)
+	suite.Assert().Equal([]string{
+		"GET /api/v2/routing/queues/" + suite.QueueID.String(),
+		"GET /api/v2/routing/queues/" + suite.QueueID.String(),
+		"PUT /api/v2/routing/queues/" + suite.QueueID.String(),
+		"DELETE /api/v2/routing/queues/" + suite.QueueID.String() + "?forceDelete=true",
+	}, suite.Recorder.Requests())
+	payload := map[string]interface{}{}
+	suite.Require().Nil(json.Unmarshal([]byte(suite.Recorder.Bodies()[2]), &payload))
+	suite.Assert().Equal("The Sales Queue", payload["description"])
+	suite.Assert().Equal(map[string]interface{}{"id": "5e5e0d8a-0b5c-4c6e-8f27-5f4c6a3e9a05", "name": "Sales Flow"}, payload["queueFlow"], "Unmanaged properties should be kept")
+	chat := payload["mediaSettings"].(map[string]interface{})["chat"].(map[string]interface{})
+	suite.Assert().Equal(float64(20), chat["alertingTimeoutSeconds"])
+	suite.Assert().Equal(true, chat["enableAutoAnswer"], "Unmanaged media settings should be kept")
+	suite.Assert().Contains(payload["mediaSettings"], "email", "Other media settings should be kept")
+}
+
+func (suite *QueueSuite) TestCanManageMembers() {
+	queue := &gcloudcx.Queue{ID: suite.QueueID, Client: suite.Client}
+	members, err := queue.FetchJoinedMembers()
+	suite.Require().Nilf(err, "Failed to fetch members. %s", err)
+	suite.Require().Len(members, 1)
+	suite.Assert().True(members[0].Joined)
+	suite.Require().NotNil(members[0].User)
+	suite.Assert().Equal("John Doe", members[0].User.Name)
+
+	users := make([]gcloudcx.Identifiable, 150)
+	for i := range users {
+		users[i] = gcloudcx.User{ID: uuid.New()}
+	}
+	suite.Recorder.Reset()
+	err = queue.AddMembers(users...)
+	suite.Require().Nilf(err, "Failed to add members. %s", err)
+	err = queue.RemoveMembers(users[0])
+	suite.Require().Nilf(err, "Failed to remove members. %s", err)
+	err = queue.SetMemberJoined(users[1], false)
+	suite.Require().Nilf(err, "Failed to unjoin member. %s", err)
+	suite.Assert().Equal([]string{
+		"POST /api/v2/routing/queues/" + suite.QueueID.String() + "/members?delete=false",
+		"POST /api/v2/routing/queues/" + suite.QueueID.String() + "/members?delete=false",
+		"POST /api/v2/routing/queues/" + suite.QueueID.String() + "/members?delete=true",
+		"PATCH /api/v2/routing/queues/" + suite.QueueID.String() + "/members/" + users[1].GetID().String(),
+	}, suite.Recorder.Requests())
+	bodies := suite.Recorder.Bodies()
+	suite.Assert().Equal(`{"joined":false}`, bodies[len(bodies)-1])
+}
+
+func (suite *QueueSuite) TestCanManageWrapupCodes() {
+	queue := &gcloudcx.Queue{ID: suite.QueueID, Client: suite.Client}
+	codes, err := queue.FetchWrapupCodes()
+	suite.Require().Nilf(err, "Failed to fetch wrapup codes. %s", err)
+	suite.Require().Len(codes, 1)
+	suite.Assert().Equal("Sale", codes[0].String())
+	err = queue.AddWrapupCodes(codes[0])
+	suite.Require().Nilf(err, "Failed to add wrapup codes. %s", err)
+	err = queue.RemoveWrapupCode(codes[0])
+	suite.Require().Nilf(err, "Failed to remove wrapup code. %s", err)
+	suite.Assert().Equal(fmt.Sprintf(`[{"id":"%s"}]`, codes[0].ID), suite.Recorder.Bodies()[1])
+	suite.Assert().Equal("DELETE /api/v2/routing/queues/"+suite.QueueID.String()+"/wrapupcodes/"+codes[0].ID.String(), suite.Recorder.Requests()[2])
+}
+
+func (suite *QueueSuite) TestCanFetchEstimatedWaitTime() {
+	queue := &gcloudcx.Queue{ID: suite.QueueID, Client: suite.Client}
+	estimate, err := queue.FetchMediaEstimatedWaitTime("chat")
+	suite.Require().Nilf(err, "Failed to fetch estimated wait time. %s", err)
+	suite.Assert().Equal(90*time.Second, estimate.Duration)
+	suite.Assert().Equal("BEST", estimate.Formula)
+	suite.Assert().Equal("GET /api/v2/routing/queues/"+suite.QueueID.String()+"/mediatypes/chat/estimatedwaittime", suite.Recorder.Requests()[0])
+}
+
+func (suite *QueueSuite) TestCanFetchWaitStatus() {
+	queue := &gcloudcx.Queue{ID: suite.QueueID, Client: suite.Client}
+	status, err := queue.FetchWaitStatus("chat")
+	suite.Require().Nilf(err, "Failed to fetch wait status. %s", err)
+	suite.Assert().True(status.HasEstimate)
+	suite.Assert().Equal(90*time.Second, status.EstimatedWait)
+	suite.Assert().Equal(int64(6), status.Waiting)
+	suite.Assert().Equal(int64(2), status.Interacting)
+	suite.Assert().Equal(int64(3), status.OnQueueAgents)
+	suite.Assert().Equal(int64(1), status.IdleAgents)
+	suite.Assert().Contains(suite.Recorder.Bodies()[0], `"chat"`, "The observations should be restricted to chats")
+
+	offer, reason := status.ShouldOfferCallback(gcloudcx.CallbackPolicy{MaxEstimatedWait: 1 * time.Minute})
+	suite.Assert().True(offer, reason)
+	offer, reason = status.ShouldOfferCallback(gcloudcx.CallbackPolicy{MaxEstimatedWait: 2 * time.Minute, MaxWaitingPerAgent: 2.5})
+	suite.Assert().False(offer, reason)
+	offer, reason = status.ShouldOfferCallback(gcloudcx.CallbackPolicy{MaxWaitingPerAgent: 1.5})
+	suite.Assert().True(offer, reason)
+}
+
+func (suite *QueueSuite) TestCanFetchWaitStatusWithoutEstimate() {
+	queue := &gcloudcx.Queue{ID: suite.QueueID, Client: suite.Client}
+	status, err := queue.FetchWaitStatus("email")
+	suite.Require().Nilf(err, "Failed to fetch wait status. %s", err)
+	suite.Assert().False(status.HasEstimate)
+	offer, _ := status.ShouldOfferCallback(gcloudcx.CallbackPolicy{MaxEstimatedWait: 1 * time.Second})
+	suite.Assert().False(offer, "Without an estimate, the wait time rule should not apply")
+}
+
+func (suite *QueueSuite) TestShouldFailFetchingWaitStatusWhenNotAuthorized() {
+	queue := &gcloudcx.Queue{ID: suite.QueueID, Client: suite.Client}
+	_, err := queue.FetchWaitStatus("callback")
+	suite.Require().NotNil(err, "An authorization error should not be reported as a missing estimate")
+	var apiError gcloudcx.APIError
+	suite.Require().True(errors.As(err, &apiError), "Error should be an APIError")
+	suite.Assert().Equal(403, apiError.Status)
+}
+
+func (suite *QueueSuite) TestShouldFailFetchingWaitStatusWithBadRequest() {
+	queue := &gcloudcx.Queue{ID: suite.QueueID, Client: suite.Client}
+	_, err := queue.FetchWaitStatus("message")
+	suite.Require().NotNil(err, "A bad request should not be reported as a missing estimate")
+	var apiError gcloudcx.APIError
+	suite.Require().True(errors.As(err, &apiError), "Error should be an APIError")
+	suite.Assert().Equal(400, apiError.Status)
+}
+
+func (suite *QueueSuite) TestCanFetchWaitStatusWhenEstimateIsNotFound() {
+	queue := &gcloudcx.Queue{ID: suite.QueueID, Client: suite.Client}
+	status, err := queue.FetchWaitStatus("call")
+	suite.Require().Nilf(err, "Failed to fetch wait status. %s", err)
+	suite.Assert().False(status.HasEstimate)
+}
+
+// Suite Tools
+
+func (suite *QueueSuite) SetupSuite() {
+	suite.Name = strings.TrimSuffix(reflect.TypeOf(*suite).Name(), "Suite")
+	suite.Logger = logger.Create("test",
+		&logger.FileStream{
+			Path:        fmt.Sprintf("./log/test-%s.log", strings.ToLower(suite.Name)),
+			Unbuffered:  true,
+			FilterLevel: logger.TRACE,
+		},
+	).Child("test", "test")
+	suite.Logger.Infof("Suite Start: %s %s", suite.Name, strings.Repeat("=", 80-14-len(suite.Name)))
+	suite.QueueID = uuid.MustParse("8f8f0d8a-0b5c-4c6e-8f27-5f4c6a3e9a03")
+	suite.Recorder = NewRequestRecorder(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		queuePath := "/api/v2/routing/queues/" + suite.QueueID.String()
+		switch {
+		case r.URL.Path == "/api/v2/routing/queues" || (r.URL.Path == queuePath && r.Method != http.MethodDelete):
+			_, _ = w.Write([]byte(fmt.Sprintf(`{"id": "%s", "name": "Sales", "autoAnswerOnly": true, "createdBy": "06ffcd2e-1ada-412e-a5f5-30d7853246dd",
+				"queueFlow": {"id": "5e5e0d8a-0b5c-4c6e-8f27-5f4c6a3e9a05", "name": "Sales Flow"},
+				"mediaSettings": {
+					"chat": {"alertingTimeoutSeconds": 8, "enableAutoAnswer": true, "serviceLevel": {"percentage": 0.8, "durationMs": 20000}},
+					"email": {"alertingTimeoutSeconds": 300, "serviceLevel": {"percentage": 0.8, "durationMs": 86400000}}
+				}}`, suite.QueueID)))
+		case r.Method == http.MethodGet && r.URL.Path == queuePath+"/members":
+			if r.URL.Query().Get("joined") != "true" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"status": 400, "code": "bad.request", "message": "joined filter missing"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"entities": [{"id": "06ffcd2e-1ada-412e-a5f5-30d7853246dd", "name": "John Doe", "joined": true, "memberBy": "user", "user": {"id": "06ffcd2e-1ada-412e-a5f5-30d7853246dd", "name": "John Doe"}}], "pageCount": 1}`))
+		case r.Method == http.MethodGet && r.URL.Path == queuePath+"/wrapupcodes":
+			_, _ = w.Write([]byte(`{"entities": [{"id": "9a9a0d8a-0b5c-4c6e-8f27-5f4c6a3e9a04", "name": "Sale"}], "pageCount": 1}`))
+		case r.Method == http.MethodGet && r.URL.Path == queuePath+"/mediatypes/chat/estimatedwaittime":
+			_, _ = w.Write([]byte(`{"results": [{"formula": "BEST", "estimatedWaitTimeSeconds": 90}]}`))
+		case r.Method == http.MethodGet && r.URL.Path == queuePath+"/mediatypes/email/estimatedwaittime":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"status": 400, "code": "bad.request", "message": "Not enough data"}`))
+		case r.Method == http.MethodGet && r.URL.Path == queuePath+"/mediatypes/call/estimatedwaittime":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"status": 404, "code": "not.found", "message": "No estimate"}`))
+		case r.Method == http.MethodGet && r.URL.Path == queuePath+"/mediatypes/callback/estimatedwaittime":
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"status": 403, "code": "missing.permissions", "message": "Missing permission routing:queue:view"}`))
+		case r.Method == http.MethodGet && r.URL.Path == queuePath+"/mediatypes/message/estimatedwaittime":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"status": 400, "code": "bad.request", "message": "Invalid media type"}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v2/analytics/queues/observations/query":
+			_, _ = w.Write([]byte(`{"results": [{"group": {"queueId": "` + suite.QueueID.String() + `", "mediaType": "chat"}, "data": [
+				{"metric": "oWaiting", "stats": {"count": 6}},
+				{"metric": "oInteracting", "stats": {"count": 2}},
+				{"metric": "oOnQueueUsers", "qualifier": "IDLE", "stats": {"count": 1}},
+				{"metric": "oOnQueueUsers", "qualifier": "INTERACTING", "stats": {"count": 2}}
+			]}]}`))
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			_, _ = w.Write([]byte(`{}`))
+		}
+	})
+	suite.Server = httptest.NewServer(suite.Recorder)
+	suite.Client = CreateTestClient(suite.Server.URL, suite.Logger)
+}
+
+func (suite *QueueSuite) TearDownSuite() {
+	suite.Server.Close()
+	if suite.T().Failed() {
+		suite.Logger.Warnf("At least one test failed, we are not cleaning")
+		suite.T().Log("At least one test failed, we are not cleaning")
+	} else {
+		suite.Logger.Infof("All tests succeeded, we are cleaning")
+	}
+	suite.Logger.Infof("Suite End: %s %s", suite.Name, strings.Repeat("=", 80-12-len(suite.Name)))
+	suite.Logger.Close()
+}
+
+func (suite *QueueSuite) BeforeTest(suiteName, testName string) {
+	suite.Logger.Infof("Test Start: %s %s", testName, strings.Repeat("-", 80-13-len(testName)))
+	suite.Start = time.Now()
+	suite.Recorder.Reset()
+}
+
+func (suite *QueueSuite) AfterTest(suiteName, testName string) {
+	duration := time.Since(suite.Start)
+	suite.Logger.Record("duration", duration.String()).Infof("Test End: %s %s", testName, strings.Repeat("-", 80-11-len(testName)))
+}
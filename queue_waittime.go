@@ -0,0 +1,197 @@
+package gcloudcx
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gildas/go-errors"
+)
+
+// EstimatedWaitTime describes the estimated wait time of a Queue
+type EstimatedWaitTime struct {
+	Duration time.Duration
+	Formula  string // ALL, BEST, PREDICTIVE
+	Intent   string
+}
+
+// QueueObservationSnapshot describes the current observations of a Queue
+type QueueObservationSnapshot struct {
+	MediaType     string
+	Waiting       int64
+	Interacting   int64
+	OnQueueAgents int64
+	IdleAgents    int64
+	Time          time.Time
+}
+
+// QueueWaitStatus combines the estimated wait time and the observations of a Queue
+//
+// HasEstimate is false when GENESYS Cloud could not estimate the wait time (e.g.: not enough data)
+type QueueWaitStatus struct {
+	QueueObservationSnapshot
+	EstimatedWait time.Duration
+	HasEstimate   bool
+}
+
+// CallbackPolicy tells when a callback should be offered instead of waiting in a Queue
+//
+// A zero value disables the corresponding rule
+type CallbackPolicy struct {
+	MaxEstimatedWait   time.Duration // offer a callback when the estimated wait time is longer
+	MaxWaitingPerAgent float64       // offer a callback when there are more waiting conversations per on-queue agent
+	WhenNoAgents       bool          // offer a callback when no agent is on queue
+}
+
+// FetchEstimatedWaitTime fetches the estimated wait time of this Queue
+//
+// If a conversation is given, the estimation is for that conversation.
+//
+// See https://developer.genesys.cloud/api/rest/v2/routing/#get-api-v2-routing-queues--queueId--estimatedwaittime
+func (queue *Queue) FetchEstimatedWaitTime(conversation ...Identifiable) (*EstimatedWaitTime, error) {
+	path := NewURI("/routing/queues/%s/estimatedwaittime", queue.ID)
+	if len(conversation) > 0 && conversation[0] != nil {
+		path = NewURI("%s?conversationId=%s", path, conversation[0].GetID())
+	}
+	return queue.fetchEstimatedWaitTime(path)
+}
+
+// FetchMediaEstimatedWaitTime fetches the estimated wait time of this Queue for the given media type (call, chat, email, callback, message)
+//
+// See https://developer.genesys.cloud/api/rest/v2/routing/#get-api-v2-routing-queues--queueId--mediatypes--mediaType--estimatedwaittime
+func (queue *Queue) FetchMediaEstimatedWaitTime(mediaType string) (*EstimatedWaitTime, error) {
+	if len(mediaType) == 0 {
+		return nil, errors.ArgumentMissing.With("mediaType").WithStack()
+	}
+	return queue.fetchEstimatedWaitTime(NewURI("/routing/queues/%s/mediatypes/%s/estimatedwaittime", queue.ID, mediaType))
+}
+
+func (queue *Queue) fetchEstimatedWaitTime(path URI) (*EstimatedWaitTime, error) {
+	response := struct {
+		Results []*EstimatedWaitTime `json:"results"`
+	}{}
+	if err := queue.Client.Get(path, &response); err != nil {
+		return nil, err
+	}
+	if len(response.Results) == 0 {
+		return nil, errors.NotFound.With("estimatedWaitTime", queue.ID.String()).WithStack()
+	}
+	return response.Results[0], nil
+}
+
+// FetchObservations fetches the current observations of this Queue
+//
+// If mediaType is given (voice, chat, email, callback, message), the observations are restricted to it
+func (queue *Queue) FetchObservations(mediaType ...string) (*QueueObservationSnapshot, error) {
+	query := NewQueueObservationQuery([]Identifiable{queue}, "oWaiting", "oInteracting", "oOnQueueUsers")
+	snapshot := &QueueObservationSnapshot{Time: time.Now().UTC()}
+	if len(mediaType) > 0 && len(mediaType[0]) > 0 {
+		query.WithMediaType(mediaType[0])
+		snapshot.MediaType = mediaType[0]
+	}
+	results, err := queue.Client.QueryQueueObservations(query)
+	if err != nil {
+		return nil, err
+	}
+	for _, result := range results {
+		snapshot.Waiting += result.Count("oWaiting", "")
+		snapshot.Interacting += result.Count("oInteracting", "")
+		snapshot.OnQueueAgents += result.Count("oOnQueueUsers", "")
+		snapshot.IdleAgents += result.Count("oOnQueueUsers", RoutingStatusIdle)
+	}
+	return snapshot, nil
+}
+
+// FetchWaitStatus fetches the estimated wait time and the observations of this Queue for the given media type
+//
+// The media type uses the routing names (call, chat, email, callback, message),
+// "call" is translated to "voice" for the observations.
+// If GENESYS Cloud cannot estimate the wait time (no estimate, or not enough data), HasEstimate is false and no error is returned.
+// Other errors, like authorization errors, are returned.
+func (queue *Queue) FetchWaitStatus(mediaType string) (*QueueWaitStatus, error) {
+	observationMediaType := mediaType
+	if mediaType == "call" {
+		observationMediaType = "voice"
+	}
+	snapshot, err := queue.FetchObservations(observationMediaType)
+	if err != nil {
+		return nil, err
+	}
+	status := &QueueWaitStatus{QueueObservationSnapshot: *snapshot}
+	var estimate *EstimatedWaitTime
+	if len(mediaType) > 0 {
+		estimate, err = queue.FetchMediaEstimatedWaitTime(mediaType)
+	} else {
+		estimate, err = queue.FetchEstimatedWaitTime()
+	}
+	if err != nil {
+		if !isEstimateUnavailable(err) {
+			return nil, err
+		}
+		queue.Client.Logger.Warnf("Estimated Wait Time is not available for queue %s: %s", queue, err)
+		return status, nil
+	}
+	status.EstimatedWait = estimate.Duration
+	status.HasEstimate = true
+	return status, nil
+}
+
+// isEstimateUnavailable tells if GENESYS Cloud could not estimate the wait time
+//
+// GENESYS Cloud answers with a 404 or with a 400 "Not enough data" when there is no estimate
+func isEstimateUnavailable(err error) bool {
+	if errors.Is(err, errors.NotFound) {
+		return true
+	}
+	var apiError APIError
+	if !errors.As(err, &apiError) {
+		return false
+	}
+	switch apiError.Status {
+	case 404:
+		return true
+	case 400:
+		return strings.Contains(strings.ToLower(apiError.Message), "not enough data")
+	default:
+		return false
+	}
+}
+
+// ShouldOfferCallback tells if a callback should be offered according to the given policy
+//
+// It also returns the reason of the decision
+func (status QueueWaitStatus) ShouldOfferCallback(policy CallbackPolicy) (bool, string) {
+	if status.OnQueueAgents == 0 {
+		if policy.WhenNoAgents {
+			return true, "no agent is on queue"
+		}
+		return false, "no agent is on queue"
+	}
+	if policy.MaxEstimatedWait > 0 && status.HasEstimate && status.EstimatedWait > policy.MaxEstimatedWait {
+		return true, fmt.Sprintf("estimated wait %s exceeds %s", status.EstimatedWait, policy.MaxEstimatedWait)
+	}
+	if policy.MaxWaitingPerAgent > 0 {
+		ratio := float64(status.Waiting) / float64(status.OnQueueAgents)
+		if ratio > policy.MaxWaitingPerAgent {
+			return true, fmt.Sprintf("%.1f waiting per agent exceeds %.1f", ratio, policy.MaxWaitingPerAgent)
+		}
+	}
+	return false, "wait is acceptable"
+}
+
+// UnmarshalJSON unmarshals JSON into this
+func (estimate *EstimatedWaitTime) UnmarshalJSON(payload []byte) (err error) {
+	var inner struct {
+		Seconds float64 `json:"estimatedWaitTimeSeconds"`
+		Formula string  `json:"formula"`
+		Intent  string  `json:"intent"`
+	}
+	if err = json.Unmarshal(payload, &inner); err != nil {
+		return errors.JSONUnmarshalError.Wrap(err)
+	}
+	estimate.Duration = time.Duration(inner.Seconds * float64(time.Second))
+	estimate.Formula = inner.Formula
+	estimate.Intent = inner.Intent
+	return
+}
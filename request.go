@@ -3,7 +3,9 @@ package gcloudcx
 import (
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gildas/go-errors"
 	"github.com/gildas/go-request"
@@ -34,8 +36,22 @@ func (client *Client) Delete(path URI, results interface{}) error {
 	return client.SendRequest(path, &request.Options{Method: http.MethodDelete}, results)
 }
 
+// Download downloads the content at the given path (or URL) from GCloud
+//
+// The whole content is read in memory before Download returns,
+// the returned ContentReader does not hold any connection and does not need to be closed
+func (client *Client) Download(path URI) (*request.ContentReader, error) {
+	return client.sendRequest(path, &request.Options{}, nil)
+}
+
 // SendRequest sends a REST request to GCloud
 func (client *Client) SendRequest(path URI, options *request.Options, results interface{}) (err error) {
+	_, err = client.sendRequest(path, options, results)
+	return
+}
+
+// sendRequest sends a REST request to GCloud and returns the response content
+func (client *Client) sendRequest(path URI, options *request.Options, results interface{}) (content *request.ContentReader, err error) {
 	log := client.Logger.Child(nil, "request")
 	if options == nil {
 		options = &request.Options{}
@@ -43,24 +59,24 @@ func (client *Client) SendRequest(path URI, options *request.Options, results in
 	if path.HasProtocol() {
 		options.URL, err = path.URL()
 	} else if client.API == nil {
-		return errors.ArgumentMissing.With("Client API").WithStack()
+		return nil, errors.ArgumentMissing.With("Client API").WithStack()
 	} else if !path.HasPrefix("/api") {
 		options.URL, err = client.API.Parse(NewURI("/api/v2/").Join(path).String())
 	} else {
 		options.URL, err = client.API.Parse(path.String())
 	}
 	if err != nil {
-		return errors.WithStack(APIError{Code: "url.parse", Message: err.Error()})
+		return nil, errors.WithStack(APIError{Code: "url.parse", Message: err.Error()})
 	}
 	if len(options.Authorization) == 0 {
 		if client.IsAuthorized() {
 			options.Authorization = client.Grant.AccessToken().String()
 		} else {
 			if err = client.Login(); err != nil {
-				return errors.WithStack(err)
+				return nil, errors.WithStack(err)
 			}
 			if !client.IsAuthorized() {
-				return errors.HTTPUnauthorized.WithMessage("Not Authorized Yet")
+				return nil, errors.HTTPUnauthorized.WithMessage("Not Authorized Yet")
 			}
 			options.Authorization = client.Grant.AccessToken().String()
 		}
@@ -77,31 +93,51 @@ func (client *Client) SendRequest(path URI, options *request.Options, results in
 		urlError := &url.Error{}
 		if errors.As(err, &urlError) {
 			log.Errorf("URL Error", urlError)
-			return err
+			return nil, err
 		}
 		if errors.Is(err, errors.HTTPUnauthorized) && len(client.Grant.AccessToken().String()) > 0 {
 			// This means our token most probably expired, we should try again without it
 			log.Infof("Authorization Token is expired, we need to authenticate again")
 			options.Authorization = ""
 			client.Grant.AccessToken().Reset()
-			return client.SendRequest(path, options, results)
+			return client.sendRequest(path, options, results)
 		}
 		var details *errors.Error
 		if errors.As(err, &details) {
 			apiError := APIError{}
 			if jsonerr := res.UnmarshalContentJSON(&apiError); jsonerr != nil {
-				return errors.Wrap(err, "Failed to extract an error from the response")
+				return nil, errors.Wrap(err, "Failed to extract an error from the response")
 			}
 			apiError.Status = details.Code
 			apiError.Code = details.ID
 			apiError.CorrelationID = res.Headers.Get("Inin-Correlation-Id")
+			apiError.RetryAfter = parseRetryAfter(res.Headers.Get("Retry-After"))
 			if strings.HasPrefix(apiError.Message, "authentication failed") {
 				apiError.Status = errors.HTTPUnauthorized.Code
 				apiError.Code = errors.HTTPUnauthorized.ID
 			}
-			return errors.WithStack(apiError)
+			return nil, errors.WithStack(apiError)
+		}
+		return nil, err
+	}
+	return res, nil
+}
+
+// parseRetryAfter parses the value of a Retry-After header, given in seconds or as an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if len(value) == 0 {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(date); delay > 0 {
+			return delay
 		}
-		return err
 	}
-	return nil
+	return 0
 }
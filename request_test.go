@@ -1,9 +1,11 @@
 package gcloudcx_test
 
 import (
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 	"time"
 
@@ -141,6 +143,80 @@ func (suite *ClientSuite) TestShouldNotSendRequestWithNoAPI() {
 	suite.Assert().Equal("Client API", details.What)
 }
 
+func (suite *ClientSuite) TestCanDownload() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		suite.Assert().Equal(http.MethodGet, r.Method)
+		suite.Assert().Equal("/api/v2/downloads/recording", r.URL.Path)
+		w.Header().Set("Content-Type", "audio/wav")
+		_, _ = w.Write([]byte("RIFF...."))
+	}))
+	defer server.Close()
+
+	client := CreateTestClient(server.URL, suite.Logger)
+	content, err := client.Download("/downloads/recording")
+	suite.Require().Nilf(err, "Failed to download: Error %s", err)
+	suite.Assert().Equal("audio/wav", content.Type)
+	suite.Assert().Equal(int64(8), content.Length)
+	data, err := ioutil.ReadAll(content)
+	suite.Require().Nil(err)
+	suite.Assert().Equal("RIFF....", string(data))
+}
+
+func (suite *ClientSuite) TestShouldGetAPIErrorFromFailedRequest() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Inin-Correlation-Id", "1234-5678")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"status": 404, "code": "not.found", "message": "The requested resource was not found"}`))
+	}))
+	defer server.Close()
+
+	client := CreateTestClient(server.URL, suite.Logger)
+	stuff := struct{}{}
+	err := client.Get("/path/to/resource", &stuff)
+	suite.Require().NotNil(err, "Request should have failed")
+	var apiError gcloudcx.APIError
+	suite.Require().True(errors.As(err, &apiError), "err should contain an APIError")
+	suite.Assert().Equal(http.StatusNotFound, apiError.Status)
+	suite.Assert().Equal("The requested resource was not found", apiError.Message)
+	suite.Assert().Equal("1234-5678", apiError.CorrelationID)
+
+	_, err = client.Download("/path/to/resource")
+	suite.Require().NotNil(err, "Download should have failed")
+	suite.Assert().True(errors.As(err, &apiError), "err should contain an APIError")
+}
+
+func (suite *ClientSuite) TestCanSendRequestAgainWhenTokenIsExpired() {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.URL.Path {
+		case "/oauth/token":
+			core.RespondWithJSON(w, http.StatusOK, map[string]interface{}{"access_token": "N3wT0k3n", "token_type": "bearer", "expires_in": 3600})
+		case "/api/v2/path/to/resource":
+			if !strings.EqualFold(r.Header.Get("Authorization"), "Bearer N3wT0k3n") {
+				core.RespondWithJSON(w, http.StatusUnauthorized, map[string]interface{}{"status": 401, "code": "bad.credentials", "message": "Invalid login credentials."})
+				return
+			}
+			core.RespondWithJSON(w, http.StatusOK, map[string]interface{}{"name": "stuff"})
+		default:
+			core.RespondWithJSON(w, http.StatusOK, struct{}{})
+		}
+	}))
+	defer server.Close()
+
+	client := CreateTestClient(server.URL, suite.Logger)
+	stuff := struct {
+		Name string `json:"name"`
+	}{}
+	err := client.Get("/path/to/resource", &stuff)
+	suite.Require().Nilf(err, "Failed to send GET Request: Error %s", err)
+	suite.Assert().Equal("stuff", stuff.Name)
+	suite.Assert().Equal("N3wT0k3n", client.Grant.AccessToken().Token)
+	suite.Assert().Equal("GET /api/v2/path/to/resource", requests[0])
+	suite.Assert().Equal("POST /oauth/token", requests[1])
+	suite.Assert().Equal("GET /api/v2/path/to/resource", requests[len(requests)-1])
+}
 
 // Tool Stuff
 
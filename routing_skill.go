@@ -0,0 +1,105 @@
+package gcloudcx
+
+import (
+	"strings"
+)
+
+// RoutingSkill describes a Routing Skill of the organization
+type RoutingSkill struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	State   string `json:"state,omitempty"`
+	SelfURI string `json:"selfUri,omitempty"`
+}
+
+// RoutingLanguage describes a Routing Language of the organization
+type RoutingLanguage struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	State   string `json:"state,omitempty"`
+	SelfURI string `json:"selfUri,omitempty"`
+}
+
+// FetchRoutingSkills fetches all the Routing Skills of the organization
+//
+// See https://developer.genesys.cloud/api/rest/v2/routing/#get-api-v2-routing-skills
+func (client *Client) FetchRoutingSkills() ([]*RoutingSkill, error) {
+	skills := []*RoutingSkill{}
+	page := 1
+	for {
+		response := struct {
+			Entities  []*RoutingSkill `json:"entities"`
+			PageCount int             `json:"pageCount"`
+		}{}
+		if err := client.Get(NewURI("/routing/skills?pageSize=100&pageNumber=%d", page), &response); err != nil {
+			return nil, err
+		}
+		skills = append(skills, response.Entities...)
+		if page >= response.PageCount {
+			break
+		}
+		page++
+	}
+	return skills, nil
+}
+
+// FetchRoutingLanguages fetches all the Routing Languages of the organization
+//
+// See https://developer.genesys.cloud/api/rest/v2/routing/#get-api-v2-routing-languages
+func (client *Client) FetchRoutingLanguages() ([]*RoutingLanguage, error) {
+	languages := []*RoutingLanguage{}
+	page := 1
+	for {
+		response := struct {
+			Entities  []*RoutingLanguage `json:"entities"`
+			PageCount int                `json:"pageCount"`
+		}{}
+		if err := client.Get(NewURI("/routing/languages?pageSize=100&pageNumber=%d", page), &response); err != nil {
+			return nil, err
+		}
+		languages = append(languages, response.Entities...)
+		if page >= response.PageCount {
+			break
+		}
+		page++
+	}
+	return languages, nil
+}
+
+// String gets a string version
+//
+//   implements the fmt.Stringer interface
+func (skill RoutingSkill) String() string {
+	if len(skill.Name) > 0 {
+		return skill.Name
+	}
+	return skill.ID
+}
+
+// String gets a string version
+//
+//   implements the fmt.Stringer interface
+func (language RoutingLanguage) String() string {
+	if len(language.Name) > 0 {
+		return language.Name
+	}
+	return language.ID
+}
+
+// routingSkillIDs maps the lowercased names of the skills to their identifiers
+func routingSkillIDs(skills []*RoutingSkill) map[string]string {
+	ids := map[string]string{}
+	for _, skill := range skills {
+		ids[strings.ToLower(skill.Name)] = skill.ID
+	}
+	return ids
+}
+
+// routingLanguageIDs maps the lowercased names of the languages to their identifiers
+func routingLanguageIDs(languages []*RoutingLanguage) map[string]string {
+	ids := map[string]string{}
+	for _, language := range languages {
+		ids[strings.ToLower(language.Name)] = language.ID
+	}
+	return ids
+}
@@ -2,6 +2,8 @@ package gcloudcx
 
 import (
 	"time"
+
+	"github.com/gildas/go-errors"
 )
 
 // RoutingStatus describes a Routing Status
@@ -10,3 +12,40 @@ type RoutingStatus struct {
 	Status    string    `json:"status"` // OFF_QUEUE, IDLE, INTERACTING, NOT_RESPONDING, COMMUNICATING
 	StartTime time.Time `json:"startTime"`
 }
+
+// Routing Statuses
+const (
+	RoutingStatusOffQueue      = "OFF_QUEUE"
+	RoutingStatusIdle          = "IDLE"
+	RoutingStatusInteracting   = "INTERACTING"
+	RoutingStatusNotResponding = "NOT_RESPONDING"
+	RoutingStatusCommunicating = "COMMUNICATING"
+)
+
+// FetchRoutingStatus fetches the Routing Status of this User
+//   see https://developer.genesys.cloud/api/rest/v2/users/#get-api-v2-users--userId--routingstatus
+func (user *User) FetchRoutingStatus() (*RoutingStatus, error) {
+	status := &RoutingStatus{}
+	if err := user.Client.Get(NewURI("/users/%s/routingstatus", user.ID), &status); err != nil {
+		return nil, err
+	}
+	user.RoutingStatus = status
+	return status, nil
+}
+
+// SetRoutingStatus sets the Routing Status of this User
+//   GENESYS Cloud only allows IDLE and OFF_QUEUE
+//   see https://developer.genesys.cloud/api/rest/v2/users/#put-api-v2-users--userId--routingstatus
+func (user *User) SetRoutingStatus(status string) error {
+	if status != RoutingStatusIdle && status != RoutingStatusOffQueue {
+		return errors.ArgumentInvalid.With("status", status).WithStack()
+	}
+	updated := &RoutingStatus{}
+	if err := user.Client.Put(NewURI("/users/%s/routingstatus", user.ID), struct {
+		Status string `json:"status"`
+	}{Status: status}, &updated); err != nil {
+		return err
+	}
+	user.RoutingStatus = updated
+	return nil
+}
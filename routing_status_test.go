@@ -0,0 +1,123 @@
+package gcloudcx_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gildas/go-errors"
+	"github.com/gildas/go-gcloudcx"
+	"github.com/gildas/go-logger"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+type RoutingStatusSuite struct {
+	suite.Suite
+	Name   string
+	Logger *logger.Logger
+	Start  time.Time
+
+	Server   *httptest.Server
+	Recorder *RequestRecorder
+	User     *gcloudcx.User
+}
+
+func TestRoutingStatusSuite(t *testing.T) {
+	suite.Run(t, new(RoutingStatusSuite))
+}
+
+func (suite *RoutingStatusSuite) TestCanFetchRoutingStatus() {
+	status, err := suite.User.FetchRoutingStatus()
+	suite.Require().Nilf(err, "Failed to fetch routing status. %s", err)
+	suite.Assert().Equal(gcloudcx.RoutingStatusInteracting, status.Status)
+	suite.Assert().Equal(suite.User.ID.String(), status.UserID)
+	suite.Assert().Equal(time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC), status.StartTime)
+	suite.Assert().Same(status, suite.User.RoutingStatus)
+}
+
+func (suite *RoutingStatusSuite) TestCanSetRoutingStatus() {
+	for _, status := range []string{gcloudcx.RoutingStatusIdle, gcloudcx.RoutingStatusOffQueue} {
+		suite.Recorder.Reset()
+		err := suite.User.SetRoutingStatus(status)
+		suite.Require().Nilf(err, "Failed to set routing status %s. %s", status, err)
+		suite.Assert().Equal([]string{fmt.Sprintf("PUT /api/v2/users/%s/routingstatus", suite.User.ID)}, suite.Recorder.Requests())
+		suite.Assert().JSONEq(fmt.Sprintf(`{"status": "%s"}`, status), suite.Recorder.Bodies()[0])
+		suite.Require().NotNil(suite.User.RoutingStatus)
+		suite.Assert().Equal(status, suite.User.RoutingStatus.Status)
+	}
+}
+
+func (suite *RoutingStatusSuite) TestShouldNotSetInvalidRoutingStatus() {
+	for _, status := range []string{gcloudcx.RoutingStatusInteracting, gcloudcx.RoutingStatusNotResponding, gcloudcx.RoutingStatusCommunicating, "idle", ""} {
+		err := suite.User.SetRoutingStatus(status)
+		suite.Require().NotNilf(err, "Should not set routing status %s", status)
+		suite.Assert().True(errors.Is(err, errors.ArgumentInvalid), "Error should be an ArgumentInvalid, got %s", err)
+	}
+	suite.Assert().Empty(suite.Recorder.Requests(), "No request should be sent")
+	suite.Assert().Nil(suite.User.RoutingStatus)
+}
+
+func (suite *RoutingStatusSuite) handler(w http.ResponseWriter, r *http.Request) {
+	statusPath := fmt.Sprintf("/api/v2/users/%s/routingstatus", suite.User.ID)
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == statusPath:
+		_, _ = w.Write([]byte(fmt.Sprintf(`{"userId": "%s", "status": "INTERACTING", "startTime": "2021-06-01T10:00:00Z"}`, suite.User.ID)))
+	case r.Method == http.MethodPut && r.URL.Path == statusPath:
+		request := struct {
+			Status string `json:"status"`
+		}{}
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		_, _ = w.Write([]byte(fmt.Sprintf(`{"userId": "%s", "status": "%s", "startTime": "2021-06-01T10:00:00Z"}`, suite.User.ID, request.Status)))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"status": 404, "code": "not.found", "message": "Not Found"}`))
+	}
+}
+
+// Suite Tools
+
+func (suite *RoutingStatusSuite) SetupSuite() {
+	suite.Name = strings.TrimSuffix(reflect.TypeOf(*suite).Name(), "Suite")
+	suite.Logger = logger.Create("test",
+		&logger.FileStream{
+			Path:        fmt.Sprintf("./log/test-%s.log", strings.ToLower(suite.Name)),
+			Unbuffered:  true,
+			FilterLevel: logger.TRACE,
+		},
+	).Child("test", "test")
+	suite.Logger.Infof("Suite Start: %s %s", suite.Name, strings.Repeat("=", 80-14-len(suite.Name)))
+	suite.Recorder = NewRequestRecorder(suite.handler)
+	suite.Server = httptest.NewServer(suite.Recorder)
+	suite.User = &gcloudcx.User{ID: uuid.New(), Client: CreateTestClient(suite.Server.URL, suite.Logger)}
+}
+
+func (suite *RoutingStatusSuite) TearDownSuite() {
+	suite.Server.Close()
+	if suite.T().Failed() {
+		suite.Logger.Warnf("At least one test failed, we are not cleaning")
+		suite.T().Log("At least one test failed, we are not cleaning")
+	} else {
+		suite.Logger.Infof("All tests succeeded, we are cleaning")
+	}
+	suite.Logger.Infof("Suite End: %s %s", suite.Name, strings.Repeat("=", 80-12-len(suite.Name)))
+	suite.Logger.Close()
+}
+
+func (suite *RoutingStatusSuite) BeforeTest(suiteName, testName string) {
+	suite.Logger.Infof("Test Start: %s %s", testName, strings.Repeat("-", 80-13-len(testName)))
+	suite.Start = time.Now()
+	suite.Recorder.Reset()
+	suite.User.RoutingStatus = nil
+}
+
+func (suite *RoutingStatusSuite) AfterTest(suiteName, testName string) {
+	duration := time.Since(suite.Start)
+	suite.Logger.Record("duration", duration.String()).Infof("Test End: %s %s", testName, strings.Repeat("-", 80-11-len(testName)))
+}
@@ -39,3 +39,48 @@ func (serviceLevel *ServiceLevel) UnmarshalJSON(payload []byte) (err error) {
 	serviceLevel.Duration = time.Duration(inner.Duration) * time.Millisecond
 	return
 }
+
+// ServiceLevelResult describes the evaluation of a ServiceLevel against Analytics metrics
+type ServiceLevelResult struct {
+	Target   float64
+	Achieved float64
+	Answered int64
+	Met      bool
+}
+
+// Evaluate evaluates this ServiceLevel against aggregated conversation metrics
+//
+// The achieved service level is read from the oServiceLevel metric if present,
+// otherwise it is computed from the nOverSla and tAnswered metrics.
+//
+// An error is returned if the data does not contain the necessary metrics
+func (serviceLevel ServiceLevel) Evaluate(data *AggregateData) (*ServiceLevelResult, error) {
+	if data == nil {
+		return nil, errors.ArgumentMissing.With("data").WithStack()
+	}
+	result := &ServiceLevelResult{Target: serviceLevel.Percentage}
+	if answered, found := data.Metric("tAnswered", ""); found {
+		result.Answered = answered.Count
+	}
+	if stats, found := data.Metric("oServiceLevel", ""); found {
+		result.Achieved = stats.Ratio
+		if stats.Denominator > 0 {
+			result.Achieved = stats.Numerator / stats.Denominator
+		}
+	} else if overSla, found := data.Metric("nOverSla", ""); found {
+		if result.Answered == 0 {
+			return nil, errors.ArgumentMissing.With("tAnswered").WithStack()
+		}
+		result.Achieved = 1 - float64(overSla.Count)/float64(result.Answered)
+	} else {
+		return nil, errors.ArgumentMissing.With("oServiceLevel").WithStack()
+	}
+	result.Met = result.Achieved >= serviceLevel.Percentage
+	return result, nil
+}
+
+// IsMet tells if this ServiceLevel is met by the given aggregated conversation metrics
+func (serviceLevel ServiceLevel) IsMet(data *AggregateData) bool {
+	result, err := serviceLevel.Evaluate(data)
+	return err == nil && result.Met
+}
@@ -0,0 +1,295 @@
+package gcloudcx
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/gildas/go-errors"
+)
+
+// SkillMatrix describes the desired Routing Skills and Languages of users
+//
+// Only the skills and languages listed in Skills and Languages are managed,
+// the other skills and languages of the users are left untouched.
+type SkillMatrix struct {
+	Skills    []string
+	Languages []string
+	Rows      []*SkillMatrixRow
+}
+
+// SkillMatrixRow describes the desired proficiencies of a user
+//
+// A managed skill or language that is not in the maps should be removed from the user
+type SkillMatrixRow struct {
+	Email     string
+	Skills    map[string]float64
+	Languages map[string]float64
+}
+
+// SkillChange describes a change to apply to a user to match a SkillMatrix
+type SkillChange struct {
+	Email  string
+	User   *User
+	Kind   string // skill, language
+	Name   string
+	ID     string
+	Action string // add, update, remove
+	From   float64
+	To     float64
+	Error  error
+}
+
+// SkillMatrixError describes a user that could not be compared to a SkillMatrix
+type SkillMatrixError struct {
+	Email string
+	Error error
+}
+
+// SkillMatrixReport describes the changes needed to match a SkillMatrix
+//
+// Computing the report does not change anything, use Apply to perform the changes
+type SkillMatrixReport struct {
+	Changes []*SkillChange
+	Errors  []SkillMatrixError
+}
+
+// Skill Change kinds and actions
+const (
+	SkillChangeKindSkill    = "skill"
+	SkillChangeKindLanguage = "language"
+	SkillChangeActionAdd    = "add"
+	SkillChangeActionUpdate = "update"
+	SkillChangeActionRemove = "remove"
+)
+
+// ReadSkillMatrix reads a SkillMatrix from a CSV source
+//
+// The first column of the header must be "email", the other columns are skill names.
+// Columns prefixed with "language:" are languages, the optional "skill:" prefix is ignored.
+// Each row gives the email of a user and the proficiency (0 to 5) for each column, an empty cell means the user should not have that skill or language.
+//
+//	email,Sales,Support,language:French
+//	john.doe@acme.com,3,,5
+func ReadSkillMatrix(reader io.Reader) (*SkillMatrix, error) {
+	records, err := csv.NewReader(reader).ReadAll()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if len(records) == 0 {
+		return nil, errors.ArgumentMissing.With("header").WithStack()
+	}
+	header := records[0]
+	if !strings.EqualFold(strings.TrimSpace(header[0]), "email") {
+		return nil, errors.ArgumentInvalid.With("header", header[0]).WithStack()
+	}
+	matrix := &SkillMatrix{Skills: []string{}, Languages: []string{}, Rows: []*SkillMatrixRow{}}
+	for _, column := range header[1:] {
+		column = strings.TrimSpace(column)
+		if strings.HasPrefix(strings.ToLower(column), "language:") {
+			matrix.Languages = append(matrix.Languages, strings.TrimSpace(column[len("language:"):]))
+		} else if strings.HasPrefix(strings.ToLower(column), "skill:") {
+			matrix.Skills = append(matrix.Skills, strings.TrimSpace(column[len("skill:"):]))
+		} else {
+			matrix.Skills = append(matrix.Skills, column)
+		}
+	}
+	for line, record := range records[1:] {
+		email := strings.TrimSpace(record[0])
+		if len(email) == 0 {
+			return nil, errors.ArgumentMissing.With(fmt.Sprintf("email (line %d)", line+2)).WithStack()
+		}
+		row := &SkillMatrixRow{Email: email, Skills: map[string]float64{}, Languages: map[string]float64{}}
+		for i, cell := range record[1:] {
+			cell = strings.TrimSpace(cell)
+			if len(cell) == 0 {
+				continue
+			}
+			proficiency, err := strconv.ParseFloat(cell, 64)
+			if err != nil || proficiency < 0 || proficiency > 5 {
+				return nil, errors.ArgumentInvalid.With(fmt.Sprintf("proficiency (line %d)", line+2), cell).WithStack()
+			}
+			if i < len(matrix.Skills) {
+				row.Skills[matrix.Skills[i]] = proficiency
+			} else {
+				row.Languages[matrix.Languages[i-len(matrix.Skills)]] = proficiency
+			}
+		}
+		matrix.Rows = append(matrix.Rows, row)
+	}
+	return matrix, nil
+}
+
+// DiffSkillMatrix compares the given SkillMatrix to the current skills and languages of the users
+//
+// Users that cannot be found or fetched are reported in the Errors of the report.
+// An error is returned if the matrix references skills or languages that do not exist in the organization.
+func (client *Client) DiffSkillMatrix(matrix *SkillMatrix) (*SkillMatrixReport, error) {
+	if matrix == nil {
+		return nil, errors.ArgumentMissing.With("matrix").WithStack()
+	}
+	skillIDs := map[string]string{}
+	if len(matrix.Skills) > 0 {
+		skills, err := client.FetchRoutingSkills()
+		if err != nil {
+			return nil, err
+		}
+		skillIDs = routingSkillIDs(skills)
+		for _, name := range matrix.Skills {
+			if _, found := skillIDs[strings.ToLower(name)]; !found {
+				return nil, errors.ArgumentInvalid.With("skill", name).WithStack()
+			}
+		}
+	}
+	languageIDs := map[string]string{}
+	if len(matrix.Languages) > 0 {
+		languages, err := client.FetchRoutingLanguages()
+		if err != nil {
+			return nil, err
+		}
+		languageIDs = routingLanguageIDs(languages)
+		for _, name := range matrix.Languages {
+			if _, found := languageIDs[strings.ToLower(name)]; !found {
+				return nil, errors.ArgumentInvalid.With("language", name).WithStack()
+			}
+		}
+	}
+
+	report := &SkillMatrixReport{Changes: []*SkillChange{}, Errors: []SkillMatrixError{}}
+	for _, row := range matrix.Rows {
+		user, err := client.FindUserByEmail(row.Email)
+		if err != nil {
+			report.Errors = append(report.Errors, SkillMatrixError{Email: row.Email, Error: err})
+			continue
+		}
+		current := map[string]float64{}
+		if len(matrix.Skills) > 0 {
+			skills, err := user.FetchRoutingSkills()
+			if err != nil {
+				report.Errors = append(report.Errors, SkillMatrixError{Email: row.Email, Error: err})
+				continue
+			}
+			for _, skill := range skills {
+				current[skill.ID] = skill.Proficiency
+			}
+		}
+		for _, name := range matrix.Skills {
+			desired, wanted := row.Skills[name]
+			report.diff(row.Email, user, SkillChangeKindSkill, name, skillIDs[strings.ToLower(name)], current, desired, wanted)
+		}
+
+		current = map[string]float64{}
+		if len(matrix.Languages) > 0 {
+			languages, err := user.FetchRoutingLanguages()
+			if err != nil {
+				report.Errors = append(report.Errors, SkillMatrixError{Email: row.Email, Error: err})
+				continue
+			}
+			for _, language := range languages {
+				current[language.ID] = language.Proficiency
+			}
+		}
+		for _, name := range matrix.Languages {
+			desired, wanted := row.Languages[name]
+			report.diff(row.Email, user, SkillChangeKindLanguage, name, languageIDs[strings.ToLower(name)], current, desired, wanted)
+		}
+	}
+	return report, nil
+}
+
+func (report *SkillMatrixReport) diff(email string, user *User, kind, name, id string, current map[string]float64, desired float64, wanted bool) {
+	proficiency, has := current[id]
+	change := &SkillChange{Email: email, User: user, Kind: kind, Name: name, ID: id, From: proficiency, To: desired}
+	switch {
+	case wanted && !has:
+		change.Action = SkillChangeActionAdd
+	case wanted && has && proficiency != desired:
+		change.Action = SkillChangeActionUpdate
+	case !wanted && has:
+		change.Action = SkillChangeActionRemove
+		change.To = 0
+	default:
+		return
+	}
+	report.Changes = append(report.Changes, change)
+}
+
+// HasChanges tells if the users need to be changed to match the SkillMatrix
+func (report SkillMatrixReport) HasChanges() bool {
+	return len(report.Changes) > 0
+}
+
+// Apply performs the changes of this report
+//
+// Each change gets its own error, the failed changes are returned
+func (report *SkillMatrixReport) Apply() []*SkillChange {
+	failed := []*SkillChange{}
+	for _, change := range report.Changes {
+		if change.Error = change.apply(); change.Error != nil {
+			failed = append(failed, change)
+		}
+	}
+	return failed
+}
+
+func (change *SkillChange) apply() error {
+	if change.Kind == SkillChangeKindLanguage {
+		language := &UserRoutingLanguage{ID: change.ID, Proficiency: change.To}
+		switch change.Action {
+		case SkillChangeActionAdd:
+			return change.User.AddRoutingLanguage(language)
+		case SkillChangeActionUpdate:
+			return change.User.UpdateRoutingLanguage(language)
+		case SkillChangeActionRemove:
+			return change.User.RemoveRoutingLanguage(language)
+		}
+		return errors.ArgumentInvalid.With("action", change.Action).WithStack()
+	}
+	skill := &UserRoutingSkill{ID: change.ID, Proficiency: change.To}
+	switch change.Action {
+	case SkillChangeActionAdd:
+		return change.User.AddRoutingSkill(skill)
+	case SkillChangeActionUpdate:
+		return change.User.UpdateRoutingSkill(skill)
+	case SkillChangeActionRemove:
+		return change.User.RemoveRoutingSkill(skill)
+	}
+	return errors.ArgumentInvalid.With("action", change.Action).WithStack()
+}
+
+// String gets a string version
+//
+//   implements the fmt.Stringer interface
+func (change SkillChange) String() string {
+	switch change.Action {
+	case SkillChangeActionAdd:
+		return fmt.Sprintf("%s: add %s %s (%g)", change.Email, change.Kind, change.Name, change.To)
+	case SkillChangeActionUpdate:
+		return fmt.Sprintf("%s: update %s %s (%g -> %g)", change.Email, change.Kind, change.Name, change.From, change.To)
+	default:
+		return fmt.Sprintf("%s: %s %s %s", change.Email, change.Action, change.Kind, change.Name)
+	}
+}
+
+// String gets a string version, one line per change and per error
+//
+// This is the dry-run report of the SkillMatrix
+//
+//   implements the fmt.Stringer interface
+func (report SkillMatrixReport) String() string {
+	var builder strings.Builder
+	for _, change := range report.Changes {
+		builder.WriteString(change.String())
+		if change.Error != nil {
+			builder.WriteString(" FAILED: ")
+			builder.WriteString(change.Error.Error())
+		}
+		builder.WriteString("\n")
+	}
+	for _, failure := range report.Errors {
+		builder.WriteString(fmt.Sprintf("%s: error: %s\n", failure.Email, failure.Error))
+	}
+	return builder.String()
+}
@@ -0,0 +1,154 @@
+package gcloudcx_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gildas/go-errors"
+	"github.com/gildas/go-gcloudcx"
+	"github.com/gildas/go-logger"
+	"github.com/stretchr/testify/suite"
+)
+
+type SkillMatrixSuite struct {
+	suite.Suite
+	Name   string
+	Logger *logger.Logger
+	Start  time.Time
+
+	Recorder *RequestRecorder
+	Server   *httptest.Server
+	Client   *gcloudcx.Client
+}
+
+func TestSkillMatrixSuite(t *testing.T) {
+	suite.Run(t, new(SkillMatrixSuite))
+}
+
+func (suite *SkillMatrixSuite) TestCanReadSkillMatrix() {
+	matrix, err := gcloudcx.ReadSkillMatrix(strings.NewReader("email,Sales,skill:Support,language:French\njohn.doe@acme.com,3,,5\n"))
+	suite.Require().Nilf(err, "Failed to read matrix. %s", err)
+	suite.Assert().Equal([]string{"Sales", "Support"}, matrix.Skills)
+	suite.Assert().Equal([]string{"French"}, matrix.Languages)
+	suite.Require().Len(matrix.Rows, 1)
+	suite.Assert().Equal("john.doe@acme.com", matrix.Rows[0].Email)
+	suite.Assert().Equal(map[string]float64{"Sales": 3}, matrix.Rows[0].Skills)
+	suite.Assert().Equal(map[string]float64{"French": 5}, matrix.Rows[0].Languages)
+}
+
+func (suite *SkillMatrixSuite) TestShouldNotReadSkillMatrixWithInvalidProficiency() {
+	_, err := gcloudcx.ReadSkillMatrix(strings.NewReader("email,Sales\njohn.doe@acme.com,7\n"))
+	suite.Require().NotNil(err, "Matrix should not have been read")
+	suite.Assert().True(errors.Is(err, errors.ArgumentInvalid), "Error should be an ArgumentInvalid")
+}
+
+func (suite *SkillMatrixSuite) TestShouldNotReadSkillMatrixWithoutEmail() {
+	_, err := gcloudcx.ReadSkillMatrix(strings.NewReader("name,Sales\nJohn Doe,3\n"))
+	suite.Require().NotNil(err, "Matrix should not have been read")
+	suite.Assert().True(errors.Is(err, errors.ArgumentInvalid), "Error should be an ArgumentInvalid")
+}
+
+func (suite *SkillMatrixSuite) TestCanDiffAndApplySkillMatrix() {
+	matrix, err := gcloudcx.ReadSkillMatrix(strings.NewReader("email,Sales,Support,Billing,language:French\njohn.doe@acme.com,3,,4,5\nnobody@acme.com,1,,,\n"))
+	suite.Require().Nilf(err, "Failed to read matrix. %s", err)
+
+	report, err := suite.Client.DiffSkillMatrix(matrix)
+	suite.Require().Nilf(err, "Failed to diff matrix. %s", err)
+	suite.Logger.Infof("Report:\n%s", report)
+	suite.Require().Len(report.Changes, 4)
+	suite.Assert().Equal("john.doe@acme.com: update skill Sales (2 -> 3)", report.Changes[0].String())
+	suite.Assert().Equal("john.doe@acme.com: remove skill Support", report.Changes[1].String())
+	suite.Assert().Equal("john.doe@acme.com: add skill Billing (4)", report.Changes[2].String())
+	suite.Assert().Equal("john.doe@acme.com: add language French (5)", report.Changes[3].String())
+	suite.Require().Len(report.Errors, 1)
+	suite.Assert().Equal("nobody@acme.com", report.Errors[0].Email)
+	suite.Assert().True(errors.Is(report.Errors[0].Error, errors.NotFound), "Error should be a NotFound")
+
+	suite.Recorder.Reset()
+	failed := report.Apply()
+	suite.Assert().Empty(failed)
+	suite.Assert().Equal([]string{
+		"PUT /api/v2/users/06ffcd2e-1ada-412e-a5f5-30d7853246dd/routingskills/sales",
+		"DELETE /api/v2/users/06ffcd2e-1ada-412e-a5f5-30d7853246dd/routingskills/support",
+		"POST /api/v2/users/06ffcd2e-1ada-412e-a5f5-30d7853246dd/routingskills",
+		"POST /api/v2/users/06ffcd2e-1ada-412e-a5f5-30d7853246dd/routinglanguages",
+	}, suite.Recorder.Requests())
+}
+
+func (suite *SkillMatrixSuite) TestShouldNotDiffUnknownSkill() {
+	matrix, err := gcloudcx.ReadSkillMatrix(strings.NewReader("email,Juggling\njohn.doe@acme.com,3\n"))
+	suite.Require().Nilf(err, "Failed to read matrix. %s", err)
+	_, err = suite.Client.DiffSkillMatrix(matrix)
+	suite.Require().NotNil(err, "Diff should have failed")
+	suite.Assert().True(errors.Is(err, errors.ArgumentInvalid), "Error should be an ArgumentInvalid")
+}
+
+// Suite Tools
+
+func (suite *SkillMatrixSuite) SetupSuite() {
+	suite.Name = strings.TrimSuffix(reflect.TypeOf(*suite).Name(), "Suite")
+	suite.Logger = logger.Create("test",
+		&logger.FileStream{
+			Path:        fmt.Sprintf("./log/test-%s.log", strings.ToLower(suite.Name)),
+			Unbuffered:  true,
+			FilterLevel: logger.TRACE,
+		},
+	).Child("test", "test")
+	suite.Logger.Infof("Suite Start: %s %s", suite.Name, strings.Repeat("=", 80-14-len(suite.Name)))
+	userID := "06ffcd2e-1ada-412e-a5f5-30d7853246dd"
+	suite.Recorder = NewRequestRecorder(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v2/routing/skills":
+			_, _ = w.Write([]byte(`{"entities": [{"id": "sales", "name": "Sales"}, {"id": "support", "name": "Support"}, {"id": "billing", "name": "Billing"}], "pageCount": 1}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v2/routing/languages":
+			_, _ = w.Write([]byte(`{"entities": [{"id": "french", "name": "French"}], "pageCount": 1}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v2/users/search":
+			search := gcloudcx.UserSearchQuery{}
+			_ = json.NewDecoder(r.Body).Decode(&search)
+			if search.Query[0].Value == "john.doe@acme.com" {
+				_, _ = w.Write([]byte(fmt.Sprintf(`{"results": [{"id": "%s", "name": "John Doe"}], "total": 1, "pageCount": 1}`, userID)))
+			} else {
+				_, _ = w.Write([]byte(`{"results": [], "total": 0, "pageCount": 0}`))
+			}
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v2/users/"+userID+"/routingskills":
+			_, _ = w.Write([]byte(`{"entities": [{"id": "sales", "name": "Sales", "proficiency": 2}, {"id": "support", "name": "Support", "proficiency": 1}, {"id": "other", "name": "Other", "proficiency": 1}], "pageCount": 1}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v2/users/"+userID+"/routinglanguages":
+			_, _ = w.Write([]byte(`{"entities": [], "pageCount": 1}`))
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			_, _ = w.Write([]byte(`{"id": "whatever"}`))
+		}
+	})
+	suite.Server = httptest.NewServer(suite.Recorder)
+	suite.Client = CreateTestClient(suite.Server.URL, suite.Logger)
+}
+
+func (suite *SkillMatrixSuite) TearDownSuite() {
+	suite.Server.Close()
+	if suite.T().Failed() {
+		suite.Logger.Warnf("At least one test failed, we are not cleaning")
+		suite.T().Log("At least one test failed, we are not cleaning")
+	} else {
+		suite.Logger.Infof("All tests succeeded, we are cleaning")
+	}
+	suite.Logger.Infof("Suite End: %s %s", suite.Name, strings.Repeat("=", 80-12-len(suite.Name)))
+	suite.Logger.Close()
+}
+
+func (suite *SkillMatrixSuite) BeforeTest(suiteName, testName string) {
+	suite.Logger.Infof("Test Start: %s %s", testName, strings.Repeat("-", 80-13-len(testName)))
+	suite.Start = time.Now()
+}
+
+func (suite *SkillMatrixSuite) AfterTest(suiteName, testName string) {
+	duration := time.Since(suite.Start)
+	suite.Logger.Record("duration", duration.String()).Infof("Test End: %s %s", testName, strings.Repeat("-", 80-11-len(testName)))
+}
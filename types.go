@@ -61,6 +61,11 @@ type ErrorDetail struct {
 }
 
 // Error returns a string representation of this error
+//
+// If the error has no MessageWithParams, its Message is used
 func (err ErrorBody) Error() string {
+	if len(err.MessageWithParams) == 0 {
+		return err.Message
+	}
 	return err.MessageWithParams
 }
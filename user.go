@@ -4,6 +4,7 @@ import (
 	"net/url"
 	"strings"
 
+	"github.com/gildas/go-errors"
 	"github.com/gildas/go-logger"
 	"github.com/google/uuid"
 )
@@ -109,3 +110,78 @@ func (user User) String() string {
 	}
 	return user.ID.String()
 }
+
+// UserCreate describes the properties of a User to create
+type UserCreate struct {
+	Name       string `json:"name"`
+	Email      string `json:"email"`
+	Department string `json:"department,omitempty"`
+	Title      string `json:"title,omitempty"`
+	DivisionID string `json:"divisionId,omitempty"`
+	Password   string `json:"password,omitempty"`
+	State      string `json:"state,omitempty"`
+}
+
+// CreateUser creates a new User
+//   see https://developer.genesys.cloud/api/rest/v2/users/#post-api-v2-users
+func (client *Client) CreateUser(create UserCreate) (*User, error) {
+	if len(create.Name) == 0 {
+		return nil, errors.ArgumentMissing.With("name").WithStack()
+	}
+	if len(create.Email) == 0 {
+		return nil, errors.ArgumentMissing.With("email").WithStack()
+	}
+	user := &User{}
+	if err := client.Post("/users", create, &user); err != nil {
+		return nil, err
+	}
+	user.Client = client
+	user.Logger = client.Logger.Child("user", "user", "user", user.ID)
+	return user, nil
+}
+
+// UserUpdate describes the properties of a User to update
+//
+// Empty properties are not changed
+type UserUpdate struct {
+	Name       string `json:"name,omitempty"`
+	Department string `json:"department,omitempty"`
+	Title      string `json:"title,omitempty"`
+	Version    int    `json:"version"`
+}
+
+// Update updates the properties of this User
+//   if the version of the update is not set, the version of this User is used
+//   see https://developer.genesys.cloud/api/rest/v2/users/#patch-api-v2-users--userId-
+func (user *User) Update(update UserUpdate) error {
+	if update.Version == 0 {
+		update.Version = user.Version
+	}
+	updated := &User{}
+	if err := user.Client.Patch(NewURI("/users/%s", user.ID), update, &updated); err != nil {
+		return err
+	}
+	user.Name = updated.Name
+	user.Department = updated.Department
+	user.Title = updated.Title
+	user.Version = updated.Version
+	return nil
+}
+
+// MoveToDivision moves this User to the given Division
+//   see https://developer.genesys.cloud/api/rest/v2/authorization/#post-api-v2-authorization-divisions--divisionId--objects--objectType-
+func (user *User) MoveToDivision(division *Division) error {
+	if division == nil {
+		return errors.ArgumentMissing.With("division").WithStack()
+	}
+	if err := user.Client.Post(NewURI("/authorization/divisions/%s/objects/USER", division.ID), []string{user.ID.String()}, nil); err != nil {
+		return err
+	}
+	user.Division = division
+	return nil
+}
+
+// Delete deletes this User
+func (user *User) Delete() error {
+	return user.Client.Delete(NewURI("/users/%s", user.ID), nil)
+}
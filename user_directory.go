@@ -0,0 +1,137 @@
+package gcloudcx
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserDirectory is an in-memory cache of users
+//
+// Users are fetched on demand and kept for TTL.
+// Feed the notification topics to Handle so cached users are invalidated when they change.
+type UserDirectory struct {
+	Client     *Client
+	TTL        time.Duration
+	Properties []string
+
+	entries map[uuid.UUID]*userDirectoryEntry
+	mutex   sync.RWMutex
+}
+
+type userDirectoryEntry struct {
+	User      *User
+	ExpiresOn time.Time
+}
+
+// NewUserDirectory creates a new UserDirectory
+//
+// properties is one of more properties that should be expanded when fetching users
+func (client *Client) NewUserDirectory(ttl time.Duration, properties ...string) *UserDirectory {
+	return &UserDirectory{
+		Client:     client,
+		TTL:        ttl,
+		Properties: properties,
+		entries:    map[uuid.UUID]*userDirectoryEntry{},
+	}
+}
+
+// Get gets the user with the given identifier
+//
+// If the user is not cached or has expired, it is fetched from Genesys Cloud
+func (directory *UserDirectory) Get(identifiable Identifiable) (*User, error) {
+	id := identifiable.GetID()
+	directory.mutex.RLock()
+	entry, found := directory.entries[id]
+	directory.mutex.RUnlock()
+	if found && time.Now().Before(entry.ExpiresOn) {
+		return entry.User, nil
+	}
+	user, err := directory.Client.FetchUser(id, directory.Properties...)
+	if err != nil {
+		return nil, err
+	}
+	directory.Set(user)
+	return user, nil
+}
+
+// GetName gets the name of the user with the given identifier
+//
+// If the user cannot be fetched, the identifier is returned
+func (directory *UserDirectory) GetName(identifiable Identifiable) string {
+	user, err := directory.Get(identifiable)
+	if err != nil {
+		directory.Client.Logger.Warnf("Failed to fetch user %s: %s", identifiable.GetID(), err)
+		return identifiable.GetID().String()
+	}
+	return user.String()
+}
+
+// Set adds or replaces a user in the cache
+func (directory *UserDirectory) Set(user *User) {
+	if user == nil || user.ID == uuid.Nil {
+		return
+	}
+	directory.mutex.Lock()
+	defer directory.mutex.Unlock()
+	if directory.entries == nil {
+		directory.entries = map[uuid.UUID]*userDirectoryEntry{}
+	}
+	directory.entries[user.ID] = &userDirectoryEntry{User: user, ExpiresOn: time.Now().Add(directory.TTL)}
+}
+
+// Load fetches all the users of the organization and caches them
+func (directory *UserDirectory) Load() error {
+	users, err := directory.Client.FetchAllUsers(directory.Properties...)
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		directory.Set(user)
+	}
+	return nil
+}
+
+// Invalidate removes the given users from the cache
+func (directory *UserDirectory) Invalidate(identifiables ...Identifiable) {
+	directory.mutex.Lock()
+	defer directory.mutex.Unlock()
+	for _, identifiable := range identifiables {
+		delete(directory.entries, identifiable.GetID())
+	}
+}
+
+// Clear removes all users from the cache
+func (directory *UserDirectory) Clear() {
+	directory.mutex.Lock()
+	defer directory.mutex.Unlock()
+	directory.entries = map[uuid.UUID]*userDirectoryEntry{}
+}
+
+// Len gets the number of cached users, including the expired ones
+func (directory *UserDirectory) Len() int {
+	directory.mutex.RLock()
+	defer directory.mutex.RUnlock()
+	return len(directory.entries)
+}
+
+// Handle invalidates the user of the given topic if it is a user topic
+//
+// It returns true if the topic was about a user
+func (directory *UserDirectory) Handle(topic NotificationTopic) bool {
+	var user *User
+	switch userTopic := topic.(type) {
+	case *UserPresenceTopic:
+		user = userTopic.User
+	case *UserActivityTopic:
+		user = userTopic.User
+	default:
+		return false
+	}
+	if user == nil {
+		return false
+	}
+	directory.Invalidate(user)
+	return true
+}
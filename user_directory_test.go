@@ -0,0 +1,146 @@
+package gcloudcx_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gildas/go-errors"
+	"github.com/gildas/go-gcloudcx"
+	"github.com/gildas/go-logger"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+type UserDirectorySuite struct {
+	suite.Suite
+	Name   string
+	Logger *logger.Logger
+	Start  time.Time
+
+	UserID  uuid.UUID
+	Fetches int32
+	Search  gcloudcx.UserSearchQuery
+	Server  *httptest.Server
+	Client  *gcloudcx.Client
+}
+
+func TestUserDirectorySuite(t *testing.T) {
+	suite.Run(t, new(UserDirectorySuite))
+}
+
+func (suite *UserDirectorySuite) TestCanSearchUsers() {
+	query := gcloudcx.NewUserSearchQuery().WithEmail("john.doe@acme.com").WithExpand("presence")
+	results, err := suite.Client.SearchUsers(query)
+	suite.Require().Nilf(err, "Failed to search users. %s", err)
+	suite.Require().Len(results.Users, 1)
+	suite.Assert().Equal(suite.UserID, results.Users[0].ID)
+	suite.Assert().Equal(1, results.Total)
+	suite.Require().Len(suite.Search.Query, 1)
+	suite.Assert().Equal("EXACT", suite.Search.Query[0].Type)
+	suite.Assert().Equal([]string{"email"}, suite.Search.Query[0].Fields)
+	suite.Assert().Equal("john.doe@acme.com", suite.Search.Query[0].Value)
+	suite.Assert().Equal([]string{"presence"}, suite.Search.Expand)
+}
+
+func (suite *UserDirectorySuite) TestShouldNotSearchWithoutCriteria() {
+	_, err := suite.Client.SearchUsers(gcloudcx.NewUserSearchQuery())
+	suite.Require().NotNil(err, "Search should have failed")
+	suite.Assert().True(errors.Is(err, errors.ArgumentMissing), "Error should be an ArgumentMissing")
+}
+
+func (suite *UserDirectorySuite) TestCanFetchAllUsers() {
+	users, err := suite.Client.FetchAllUsers()
+	suite.Require().Nilf(err, "Failed to fetch users. %s", err)
+	suite.Assert().Len(users, 3)
+}
+
+func (suite *UserDirectorySuite) TestCanCacheUsers() {
+	directory := suite.Client.NewUserDirectory(1 * time.Minute)
+	user, err := directory.Get(gcloudcx.User{ID: suite.UserID})
+	suite.Require().Nilf(err, "Failed to get user. %s", err)
+	suite.Assert().Equal("John Doe", user.Name)
+	suite.Assert().Equal("John Doe", directory.GetName(gcloudcx.User{ID: suite.UserID}))
+	suite.Assert().Equal(int32(1), atomic.LoadInt32(&suite.Fetches), "The user should have been fetched once")
+
+	handled := directory.Handle(&gcloudcx.UserPresenceTopic{User: &gcloudcx.User{ID: suite.UserID}})
+	suite.Assert().True(handled, "The presence topic should have been handled")
+	suite.Assert().Equal(0, directory.Len())
+	_, err = directory.Get(gcloudcx.User{ID: suite.UserID})
+	suite.Require().Nilf(err, "Failed to get user. %s", err)
+	suite.Assert().Equal(int32(2), atomic.LoadInt32(&suite.Fetches), "The user should have been fetched again")
+}
+
+func (suite *UserDirectorySuite) TestShouldRefetchExpiredUsers() {
+	directory := suite.Client.NewUserDirectory(0)
+	_, err := directory.Get(gcloudcx.User{ID: suite.UserID})
+	suite.Require().Nilf(err, "Failed to get user. %s", err)
+	_, err = directory.Get(gcloudcx.User{ID: suite.UserID})
+	suite.Require().Nilf(err, "Failed to get user. %s", err)
+	suite.Assert().Equal(int32(2), atomic.LoadInt32(&suite.Fetches))
+}
+
+// Suite Tools
+
+func (suite *UserDirectorySuite) SetupSuite() {
+	suite.Name = strings.TrimSuffix(reflect.TypeOf(*suite).Name(), "Suite")
+	suite.Logger = logger.Create("test",
+		&logger.FileStream{
+			Path:        fmt.Sprintf("./log/test-%s.log", strings.ToLower(suite.Name)),
+			Unbuffered:  true,
+			FilterLevel: logger.TRACE,
+		},
+	).Child("test", "test")
+	suite.Logger.Infof("Suite Start: %s %s", suite.Name, strings.Repeat("=", 80-14-len(suite.Name)))
+	suite.UserID = uuid.MustParse("06ffcd2e-1ada-412e-a5f5-30d7853246dd")
+	suite.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v2/users/search":
+			_ = json.NewDecoder(r.Body).Decode(&suite.Search)
+			_, _ = w.Write([]byte(fmt.Sprintf(`{"results": [{"id": "%s", "name": "John Doe"}], "total": 1, "pageSize": 25, "pageNumber": 1, "pageCount": 1}`, suite.UserID)))
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v2/users":
+			if r.URL.Query().Get("pageNumber") == "1" {
+				_, _ = w.Write([]byte(fmt.Sprintf(`{"entities": [{"id": "%s"}, {"id": "%s"}], "pageCount": 2}`, uuid.New(), uuid.New())))
+			} else {
+				_, _ = w.Write([]byte(fmt.Sprintf(`{"entities": [{"id": "%s"}], "pageCount": 2}`, uuid.New())))
+			}
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v2/users/"+suite.UserID.String():
+			atomic.AddInt32(&suite.Fetches, 1)
+			_, _ = w.Write([]byte(fmt.Sprintf(`{"id": "%s", "name": "John Doe"}`, suite.UserID)))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"status": 404, "code": "not.found", "message": "Not Found"}`))
+		}
+	}))
+	suite.Client = CreateTestClient(suite.Server.URL, suite.Logger)
+}
+
+func (suite *UserDirectorySuite) TearDownSuite() {
+	suite.Server.Close()
+	if suite.T().Failed() {
+		suite.Logger.Warnf("At least one test failed, we are not cleaning")
+		suite.T().Log("At least one test failed, we are not cleaning")
+	} else {
+		suite.Logger.Infof("All tests succeeded, we are cleaning")
+	}
+	suite.Logger.Infof("Suite End: %s %s", suite.Name, strings.Repeat("=", 80-12-len(suite.Name)))
+	suite.Logger.Close()
+}
+
+func (suite *UserDirectorySuite) BeforeTest(suiteName, testName string) {
+	suite.Logger.Infof("Test Start: %s %s", testName, strings.Repeat("-", 80-13-len(testName)))
+	suite.Start = time.Now()
+	atomic.StoreInt32(&suite.Fetches, 0)
+}
+
+func (suite *UserDirectorySuite) AfterTest(suiteName, testName string) {
+	duration := time.Since(suite.Start)
+	suite.Logger.Record("duration", duration.String()).Infof("Test End: %s %s", testName, strings.Repeat("-", 80-11-len(testName)))
+}
@@ -2,6 +2,7 @@ package gcloudcx
 
 import (
 	"encoding/json"
+	"strings"
 	"time"
 
 	"github.com/gildas/go-errors"
@@ -21,9 +22,128 @@ type UserPresence struct {
 
 // PresenceDefinition  defines Presence
 type PresenceDefinition struct {
-	ID             string `json:"id"`
-	SystemPresence string `json:"systemPresence"`
-	SelfURI        string `json:"selfUri"`
+	ID             string            `json:"id"`
+	SystemPresence string            `json:"systemPresence"`
+	LanguageLabels map[string]string `json:"languageLabels,omitempty"`
+	Primary        bool              `json:"primary,omitempty"`
+	Deactivated    bool              `json:"deactivated,omitempty"`
+	SelfURI        string            `json:"selfUri"`
+}
+
+// System Presences
+const (
+	SystemPresenceAvailable = "AVAILABLE"
+	SystemPresenceAway      = "AWAY"
+	SystemPresenceBreak     = "BREAK"
+	SystemPresenceBusy      = "BUSY"
+	SystemPresenceIdle      = "IDLE"
+	SystemPresenceMeal      = "MEAL"
+	SystemPresenceMeeting   = "MEETING"
+	SystemPresenceOffline   = "OFFLINE"
+	SystemPresenceOnQueue   = "ON_QUEUE"
+	SystemPresenceTraining  = "TRAINING"
+)
+
+// SystemPresenceNames maps the System Presences to their friendly names
+var SystemPresenceNames = map[string]string{
+	SystemPresenceAvailable: "Available",
+	SystemPresenceAway:      "Away",
+	SystemPresenceBreak:     "Break",
+	SystemPresenceBusy:      "Busy",
+	SystemPresenceIdle:      "Idle",
+	SystemPresenceMeal:      "Meal",
+	SystemPresenceMeeting:   "Meeting",
+	SystemPresenceOffline:   "Offline",
+	SystemPresenceOnQueue:   "On Queue",
+	SystemPresenceTraining:  "Training",
+}
+
+// FetchPresenceDefinitions fetches the Presence Definitions of the organization
+//   see https://developer.genesys.cloud/api/rest/v2/presence/#get-api-v2-presencedefinitions
+func (client *Client) FetchPresenceDefinitions() ([]*PresenceDefinition, error) {
+	definitions := []*PresenceDefinition{}
+	page := 1
+	for {
+		response := struct {
+			Entities  []*PresenceDefinition `json:"entities"`
+			PageCount int                   `json:"pageCount"`
+		}{}
+		if err := client.Get(NewURI("/presencedefinitions?pageSize=100&pageNumber=%d", page), &response); err != nil {
+			return nil, err
+		}
+		definitions = append(definitions, response.Entities...)
+		if page >= response.PageCount {
+			break
+		}
+		page++
+	}
+	return definitions, nil
+}
+
+// FetchPresenceDefinition fetches the Presence Definition with the given name
+//   name can be a System Presence (e.g.: ON_QUEUE), its friendly name (e.g.: "On Queue"),
+//   or the label of an org-defined presence (case insensitive)
+//   When several definitions share the same System Presence, the primary one is returned
+func (client *Client) FetchPresenceDefinition(name string) (*PresenceDefinition, error) {
+	if len(name) == 0 {
+		return nil, errors.ArgumentMissing.With("name").WithStack()
+	}
+	definitions, err := client.FetchPresenceDefinitions()
+	if err != nil {
+		return nil, err
+	}
+	return FindPresenceDefinition(definitions, name)
+}
+
+// FindPresenceDefinition finds the Presence Definition with the given name in a list of definitions
+//   the name is matched like in FetchPresenceDefinition
+func FindPresenceDefinition(definitions []*PresenceDefinition, name string) (*PresenceDefinition, error) {
+	if len(name) == 0 {
+		return nil, errors.ArgumentMissing.With("name").WithStack()
+	}
+	var found *PresenceDefinition
+	for _, definition := range definitions {
+		if definition.Deactivated || !definition.Matches(name) {
+			continue
+		}
+		if definition.Primary {
+			return definition, nil
+		}
+		if found == nil {
+			found = definition
+		}
+	}
+	if found == nil {
+		return nil, errors.NotFound.With("presence", name).WithStack()
+	}
+	return found, nil
+}
+
+// Matches tells if this definition matches the given System Presence, friendly name, or label (case insensitive)
+func (definition PresenceDefinition) Matches(name string) bool {
+	if strings.EqualFold(definition.SystemPresence, name) || strings.EqualFold(SystemPresenceNames[definition.SystemPresence], name) {
+		return true
+	}
+	for _, label := range definition.LanguageLabels {
+		if strings.EqualFold(label, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// FriendlyName gets the friendly name of this definition
+//   if language is given (e.g.: en_US) and the definition has a label for it, that label is returned
+func (definition PresenceDefinition) FriendlyName(language ...string) string {
+	if len(language) > 0 {
+		if label, found := definition.LanguageLabels[language[0]]; found {
+			return label
+		}
+	}
+	if name, found := SystemPresenceNames[definition.SystemPresence]; found {
+		return name
+	}
+	return definition.String()
 }
 
 // GetID gets the identifier of this
@@ -76,3 +196,66 @@ func (presence *UserPresence) UnmarshalJSON(payload []byte) (err error) {
 	}
 	return
 }
+
+// FetchPresence fetches the current GENESYS Cloud presence of this User
+//   see https://developer.genesys.cloud/api/rest/v2/presence/#get-api-v2-users--userId--presences--sourceId-
+func (user *User) FetchPresence() (*UserPresence, error) {
+	presence := &UserPresence{}
+	if err := user.Client.Get(NewURI("/users/%s/presences/PURECLOUD", user.ID), &presence); err != nil {
+		return nil, err
+	}
+	user.Presence = presence
+	return presence, nil
+}
+
+// SetPresence sets the presence of this User with the given Presence Definition
+//   message is optional
+func (user *User) SetPresence(definition *PresenceDefinition, message ...string) error {
+	if definition == nil || len(definition.ID) == 0 {
+		return errors.ArgumentMissing.With("definition").WithStack()
+	}
+	payload := struct {
+		Definition struct {
+			ID string `json:"id"`
+		} `json:"presenceDefinition"`
+		Message string `json:"message,omitempty"`
+	}{}
+	payload.Definition.ID = definition.ID
+	if len(message) > 0 {
+		payload.Message = message[0]
+	}
+	presence := &UserPresence{}
+	if err := user.Client.Patch(NewURI("/users/%s/presences/PURECLOUD", user.ID), payload, &presence); err != nil {
+		return err
+	}
+	user.Presence = presence
+	return nil
+}
+
+// SetSystemPresence sets the presence of this User by System Presence, friendly name, or org-defined label
+//   definitions can be a list fetched earlier with FetchPresenceDefinitions, if nil, they are fetched
+//   message is optional
+func (user *User) SetSystemPresence(name string, definitions []*PresenceDefinition, message ...string) (err error) {
+	if definitions == nil {
+		if definitions, err = user.Client.FetchPresenceDefinitions(); err != nil {
+			return err
+		}
+	}
+	definition, err := FindPresenceDefinition(definitions, name)
+	if err != nil {
+		return err
+	}
+	return user.SetPresence(definition, message...)
+}
+
+// GoOnQueue sets the presence of this User to On Queue
+//   definitions can be given to avoid fetching them
+func (user *User) GoOnQueue(definitions ...*PresenceDefinition) error {
+	return user.SetSystemPresence(SystemPresenceOnQueue, definitions)
+}
+
+// GoOffQueue sets the presence of this User to Available
+//   definitions can be given to avoid fetching them
+func (user *User) GoOffQueue(definitions ...*PresenceDefinition) error {
+	return user.SetSystemPresence(SystemPresenceAvailable, definitions)
+}
@@ -0,0 +1,249 @@
+package gcloudcx_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gildas/go-errors"
+	"github.com/gildas/go-gcloudcx"
+	"github.com/gildas/go-logger"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+type UserPresenceSuite struct {
+	suite.Suite
+	Name   string
+	Logger *logger.Logger
+	Start  time.Time
+
+	Server   *httptest.Server
+	Recorder *RequestRecorder
+	Client   *gcloudcx.Client
+	User     *gcloudcx.User
+}
+
+func TestUserPresenceSuite(t *testing.T) {
+	suite.Run(t, new(UserPresenceSuite))
+}
+
+// presenceDefinitionPages are the Presence Definitions served by the test server, one page per item
+var presenceDefinitionPages = [][]map[string]interface{}{
+	{
+		{"id": "available-secondary", "systemPresence": "AVAILABLE", "languageLabels": map[string]string{"en_US": "Available"}},
+		{"id": "meal", "systemPresence": "MEAL", "languageLabels": map[string]string{"en_US": "Lunch Break"}, "primary": true},
+		{"id": "busy-gone", "systemPresence": "BUSY", "languageLabels": map[string]string{"en_US": "Gone Fishing"}, "deactivated": true},
+	},
+	{
+		{"id": "available", "systemPresence": "AVAILABLE", "languageLabels": map[string]string{"en_US": "Available"}, "primary": true},
+		{"id": "on-queue", "systemPresence": "ON_QUEUE", "languageLabels": map[string]string{"en_US": "On Queue"}, "primary": true},
+	},
+}
+
+func (suite *UserPresenceSuite) TestCanFetchPresenceDefinitions() {
+	definitions, err := suite.Client.FetchPresenceDefinitions()
+	suite.Require().Nilf(err, "Failed to fetch presence definitions. %s", err)
+	suite.Assert().Len(definitions, 5)
+	suite.Assert().Equal([]string{
+		"GET /api/v2/presencedefinitions?pageSize=100&pageNumber=1",
+		"GET /api/v2/presencedefinitions?pageSize=100&pageNumber=2",
+	}, suite.Recorder.Requests())
+}
+
+func (suite *UserPresenceSuite) TestShouldPreferPrimaryDefinition() {
+	definition, err := suite.Client.FetchPresenceDefinition(gcloudcx.SystemPresenceAvailable)
+	suite.Require().Nilf(err, "Failed to fetch presence definition. %s", err)
+	suite.Assert().Equal("available", definition.ID, "The primary definition should win over the first one")
+}
+
+func (suite *UserPresenceSuite) TestCanFetchPresenceDefinitionBySystemPresence() {
+	definition, err := suite.Client.FetchPresenceDefinition("meal")
+	suite.Require().Nilf(err, "Failed to fetch presence definition. %s", err)
+	suite.Assert().Equal("meal", definition.ID)
+}
+
+func (suite *UserPresenceSuite) TestCanFetchPresenceDefinitionByFriendlyName() {
+	definition, err := suite.Client.FetchPresenceDefinition("on queue")
+	suite.Require().Nilf(err, "Failed to fetch presence definition. %s", err)
+	suite.Assert().Equal("on-queue", definition.ID)
+}
+
+func (suite *UserPresenceSuite) TestCanFetchPresenceDefinitionByLabel() {
+	definition, err := suite.Client.FetchPresenceDefinition("LUNCH BREAK")
+	suite.Require().Nilf(err, "Failed to fetch presence definition. %s", err)
+	suite.Assert().Equal("meal", definition.ID)
+	suite.Assert().Equal("Lunch Break", definition.FriendlyName("en_US"))
+	suite.Assert().Equal("Meal", definition.FriendlyName())
+}
+
+func (suite *UserPresenceSuite) TestShouldNotFetchDeactivatedPresenceDefinition() {
+	_, err := suite.Client.FetchPresenceDefinition("Gone Fishing")
+	suite.Require().NotNil(err, "Should not find a deactivated definition")
+	suite.Assert().True(errors.Is(err, errors.NotFound), "Error should be a NotFound, got %s", err)
+}
+
+func (suite *UserPresenceSuite) TestShouldNotFindPresenceDefinitionWithoutName() {
+	_, err := gcloudcx.FindPresenceDefinition([]*gcloudcx.PresenceDefinition{}, "")
+	suite.Require().NotNil(err, "Should not find a definition without a name")
+	suite.Assert().True(errors.Is(err, errors.ArgumentMissing), "Error should be an ArgumentMissing, got %s", err)
+}
+
+func (suite *UserPresenceSuite) TestCanFetchPresence() {
+	presence, err := suite.User.FetchPresence()
+	suite.Require().Nilf(err, "Failed to fetch presence. %s", err)
+	suite.Require().NotNil(presence.Definition)
+	suite.Assert().Equal("available", presence.Definition.ID)
+	suite.Assert().Same(presence, suite.User.Presence)
+}
+
+func (suite *UserPresenceSuite) TestCanSetPresence() {
+	err := suite.User.SetPresence(&gcloudcx.PresenceDefinition{ID: "meal"}, "Back in 30")
+	suite.Require().Nilf(err, "Failed to set presence. %s", err)
+	suite.Assert().Equal([]string{fmt.Sprintf("PATCH /api/v2/users/%s/presences/PURECLOUD", suite.User.ID)}, suite.Recorder.Requests())
+	suite.Assert().JSONEq(`{"presenceDefinition": {"id": "meal"}, "message": "Back in 30"}`, suite.Recorder.Bodies()[0])
+	suite.Require().NotNil(suite.User.Presence)
+	suite.Assert().Equal("meal", suite.User.Presence.Definition.ID)
+	suite.Assert().Equal("Back in 30", suite.User.Presence.Message)
+}
+
+func (suite *UserPresenceSuite) TestShouldNotSetPresenceWithoutDefinition() {
+	err := suite.User.SetPresence(&gcloudcx.PresenceDefinition{})
+	suite.Require().NotNil(err, "Should not set a presence without definition")
+	suite.Assert().True(errors.Is(err, errors.ArgumentMissing), "Error should be an ArgumentMissing, got %s", err)
+	suite.Assert().Empty(suite.Recorder.Requests())
+}
+
+func (suite *UserPresenceSuite) TestCanSetSystemPresence() {
+	err := suite.User.SetSystemPresence("Lunch Break", nil)
+	suite.Require().Nilf(err, "Failed to set presence. %s", err)
+	suite.Assert().Equal([]string{
+		"GET /api/v2/presencedefinitions?pageSize=100&pageNumber=1",
+		"GET /api/v2/presencedefinitions?pageSize=100&pageNumber=2",
+		fmt.Sprintf("PATCH /api/v2/users/%s/presences/PURECLOUD", suite.User.ID),
+	}, suite.Recorder.Requests())
+	suite.Assert().JSONEq(`{"presenceDefinition": {"id": "meal"}}`, suite.Recorder.Bodies()[2])
+}
+
+func (suite *UserPresenceSuite) TestCanSetSystemPresenceWithCachedDefinitions() {
+	definitions, err := suite.Client.FetchPresenceDefinitions()
+	suite.Require().Nilf(err, "Failed to fetch presence definitions. %s", err)
+	suite.Recorder.Reset()
+
+	err = suite.User.SetSystemPresence(gcloudcx.SystemPresenceAvailable, definitions)
+	suite.Require().Nilf(err, "Failed to set presence. %s", err)
+	suite.Assert().Equal([]string{fmt.Sprintf("PATCH /api/v2/users/%s/presences/PURECLOUD", suite.User.ID)}, suite.Recorder.Requests(), "The definitions should not be fetched again")
+	suite.Assert().JSONEq(`{"presenceDefinition": {"id": "available"}}`, suite.Recorder.Bodies()[0])
+}
+
+func (suite *UserPresenceSuite) TestShouldNotSetUnknownSystemPresence() {
+	err := suite.User.SetSystemPresence("Vacation", nil)
+	suite.Require().NotNil(err, "Should not set an unknown presence")
+	suite.Assert().True(errors.Is(err, errors.NotFound), "Error should be a NotFound, got %s", err)
+	for _, request := range suite.Recorder.Requests() {
+		suite.Assert().False(strings.HasPrefix(request, "PATCH"), "The presence should not be set")
+	}
+}
+
+func (suite *UserPresenceSuite) TestCanGoOnQueue() {
+	err := suite.User.GoOnQueue()
+	suite.Require().Nilf(err, "Failed to go on queue. %s", err)
+	requests := suite.Recorder.Requests()
+	suite.Require().Len(requests, 3)
+	suite.Assert().Equal(fmt.Sprintf("PATCH /api/v2/users/%s/presences/PURECLOUD", suite.User.ID), requests[2])
+	suite.Assert().JSONEq(`{"presenceDefinition": {"id": "on-queue"}}`, suite.Recorder.Bodies()[2])
+}
+
+func (suite *UserPresenceSuite) TestCanGoOffQueueWithCachedDefinitions() {
+	definitions, err := suite.Client.FetchPresenceDefinitions()
+	suite.Require().Nilf(err, "Failed to fetch presence definitions. %s", err)
+	suite.Recorder.Reset()
+
+	err = suite.User.GoOffQueue(definitions...)
+	suite.Require().Nilf(err, "Failed to go off queue. %s", err)
+	suite.Assert().Equal([]string{fmt.Sprintf("PATCH /api/v2/users/%s/presences/PURECLOUD", suite.User.ID)}, suite.Recorder.Requests())
+	suite.Assert().JSONEq(`{"presenceDefinition": {"id": "available"}}`, suite.Recorder.Bodies()[0])
+}
+
+func (suite *UserPresenceSuite) handler(w http.ResponseWriter, r *http.Request) {
+	presencePath := fmt.Sprintf("/api/v2/users/%s/presences/PURECLOUD", suite.User.ID)
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v2/presencedefinitions":
+		page := 1
+		_, _ = fmt.Sscanf(r.URL.Query().Get("pageNumber"), "%d", &page)
+		entities := []map[string]interface{}{}
+		if page >= 1 && page <= len(presenceDefinitionPages) {
+			entities = presenceDefinitionPages[page-1]
+		}
+		payload, _ := json.Marshal(map[string]interface{}{"entities": entities, "pageNumber": page, "pageCount": len(presenceDefinitionPages)})
+		_, _ = w.Write(payload)
+	case r.Method == http.MethodGet && r.URL.Path == presencePath:
+		_, _ = w.Write([]byte(`{"source": "PURECLOUD", "primary": true, "presenceDefinition": {"id": "available", "systemPresence": "AVAILABLE"}}`))
+	case r.Method == http.MethodPatch && r.URL.Path == presencePath:
+		request := struct {
+			Definition struct {
+				ID string `json:"id"`
+			} `json:"presenceDefinition"`
+			Message string `json:"message"`
+		}{}
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		payload, _ := json.Marshal(map[string]interface{}{
+			"source":             "PURECLOUD",
+			"primary":            true,
+			"presenceDefinition": map[string]string{"id": request.Definition.ID},
+			"message":            request.Message,
+		})
+		_, _ = w.Write(payload)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"status": 404, "code": "not.found", "message": "Not Found"}`))
+	}
+}
+
+// Suite Tools
+
+func (suite *UserPresenceSuite) SetupSuite() {
+	suite.Name = strings.TrimSuffix(reflect.TypeOf(*suite).Name(), "Suite")
+	suite.Logger = logger.Create("test",
+		&logger.FileStream{
+			Path:        fmt.Sprintf("./log/test-%s.log", strings.ToLower(suite.Name)),
+			Unbuffered:  true,
+			FilterLevel: logger.TRACE,
+		},
+	).Child("test", "test")
+	suite.Logger.Infof("Suite Start: %s %s", suite.Name, strings.Repeat("=", 80-14-len(suite.Name)))
+	suite.Recorder = NewRequestRecorder(suite.handler)
+	suite.Server = httptest.NewServer(suite.Recorder)
+	suite.Client = CreateTestClient(suite.Server.URL, suite.Logger)
+	suite.User = &gcloudcx.User{ID: uuid.New(), Client: suite.Client}
+}
+
+func (suite *UserPresenceSuite) TearDownSuite() {
+	suite.Server.Close()
+	if suite.T().Failed() {
+		suite.Logger.Warnf("At least one test failed, we are not cleaning")
+		suite.T().Log("At least one test failed, we are not cleaning")
+	} else {
+		suite.Logger.Infof("All tests succeeded, we are cleaning")
+	}
+	suite.Logger.Infof("Suite End: %s %s", suite.Name, strings.Repeat("=", 80-12-len(suite.Name)))
+	suite.Logger.Close()
+}
+
+func (suite *UserPresenceSuite) BeforeTest(suiteName, testName string) {
+	suite.Logger.Infof("Test Start: %s %s", testName, strings.Repeat("-", 80-13-len(testName)))
+	suite.Start = time.Now()
+	suite.Recorder.Reset()
+	suite.User.Presence = nil
+}
+
+func (suite *UserPresenceSuite) AfterTest(suiteName, testName string) {
+	duration := time.Since(suite.Start)
+	suite.Logger.Record("duration", duration.String()).Infof("Test End: %s %s", testName, strings.Repeat("-", 80-11-len(testName)))
+}
@@ -1,5 +1,9 @@
 package gcloudcx
 
+import (
+	"github.com/gildas/go-errors"
+)
+
 // UserRoutingLanguage describe a Routing Language for a User
 type UserRoutingLanguage struct {
 	ID          string  `json:"id"`
@@ -9,3 +13,90 @@ type UserRoutingLanguage struct {
 	State       string  `json:"state"`
 	Proficiency float64 `json:"proficiency"`
 }
+
+// userRoutingLanguageRequest is the payload sent to GENESYS Cloud to add or update a language
+type userRoutingLanguageRequest struct {
+	ID          string  `json:"id"`
+	Proficiency float64 `json:"proficiency"`
+	State       string  `json:"state,omitempty"`
+}
+
+func newUserRoutingLanguageRequests(languages []*UserRoutingLanguage) []userRoutingLanguageRequest {
+	requests := make([]userRoutingLanguageRequest, 0, len(languages))
+	for _, language := range languages {
+		requests = append(requests, userRoutingLanguageRequest{ID: language.ID, Proficiency: language.Proficiency, State: language.State})
+	}
+	return requests
+}
+
+// FetchRoutingLanguages fetches the Routing Languages of this User
+//
+// See https://developer.genesys.cloud/api/rest/v2/users/#get-api-v2-users--userId--routinglanguages
+func (user *User) FetchRoutingLanguages() ([]*UserRoutingLanguage, error) {
+	languages := []*UserRoutingLanguage{}
+	page := 1
+	for {
+		response := struct {
+			Entities  []*UserRoutingLanguage `json:"entities"`
+			PageCount int                    `json:"pageCount"`
+		}{}
+		if err := user.Client.Get(NewURI("/users/%s/routinglanguages?pageSize=100&pageNumber=%d", user.ID, page), &response); err != nil {
+			return nil, err
+		}
+		languages = append(languages, response.Entities...)
+		if page >= response.PageCount {
+			break
+		}
+		page++
+	}
+	user.Languages = languages
+	return languages, nil
+}
+
+// AddRoutingLanguage adds a Routing Language to this User
+func (user *User) AddRoutingLanguage(language *UserRoutingLanguage) error {
+	if language == nil || len(language.ID) == 0 {
+		return errors.ArgumentMissing.With("language").WithStack()
+	}
+	added := &UserRoutingLanguage{}
+	if err := user.Client.Post(NewURI("/users/%s/routinglanguages", user.ID), newUserRoutingLanguageRequests([]*UserRoutingLanguage{language})[0], &added); err != nil {
+		return err
+	}
+	*language = *added
+	return nil
+}
+
+// UpdateRoutingLanguage updates the proficiency (and state) of a Routing Language of this User
+func (user *User) UpdateRoutingLanguage(language *UserRoutingLanguage) error {
+	if language == nil || len(language.ID) == 0 {
+		return errors.ArgumentMissing.With("language").WithStack()
+	}
+	updated := &UserRoutingLanguage{}
+	if err := user.Client.Patch(NewURI("/users/%s/routinglanguages/%s", user.ID, language.ID), newUserRoutingLanguageRequests([]*UserRoutingLanguage{language})[0], &updated); err != nil {
+		return err
+	}
+	*language = *updated
+	return nil
+}
+
+// RemoveRoutingLanguage removes a Routing Language from this User
+func (user *User) RemoveRoutingLanguage(language *UserRoutingLanguage) error {
+	if language == nil || len(language.ID) == 0 {
+		return errors.ArgumentMissing.With("language").WithStack()
+	}
+	return user.Client.Delete(NewURI("/users/%s/routinglanguages/%s", user.ID, language.ID), nil)
+}
+
+// PatchRoutingLanguages adds or updates several Routing Languages of this User
+//
+// The other languages of the user are not changed
+func (user *User) PatchRoutingLanguages(languages ...*UserRoutingLanguage) ([]*UserRoutingLanguage, error) {
+	response := struct {
+		Entities []*UserRoutingLanguage `json:"entities"`
+	}{}
+	if err := user.Client.Patch(NewURI("/users/%s/routinglanguages/bulk", user.ID), newUserRoutingLanguageRequests(languages), &response); err != nil {
+		return nil, err
+	}
+	user.Languages = response.Entities
+	return response.Entities, nil
+}
@@ -1,5 +1,9 @@
 package gcloudcx
 
+import (
+	"github.com/gildas/go-errors"
+)
+
 // UserRoutingSkill describe a Routing Skill for a User
 type UserRoutingSkill struct {
 	ID          string  `json:"id"`
@@ -9,3 +13,121 @@ type UserRoutingSkill struct {
 	State       string  `json:"state"`
 	Proficiency float64 `json:"proficiency"`
 }
+
+// UserRoutingSkillsResult describes the result of a bulk skill update for one user
+type UserRoutingSkillsResult struct {
+	User   *User
+	Skills []*UserRoutingSkill
+	Error  error
+}
+
+// userRoutingSkillRequest is the payload sent to GENESYS Cloud to add or update a skill
+type userRoutingSkillRequest struct {
+	ID          string  `json:"id"`
+	Proficiency float64 `json:"proficiency"`
+	State       string  `json:"state,omitempty"`
+}
+
+func newUserRoutingSkillRequests(skills []*UserRoutingSkill) []userRoutingSkillRequest {
+	requests := make([]userRoutingSkillRequest, 0, len(skills))
+	for _, skill := range skills {
+		requests = append(requests, userRoutingSkillRequest{ID: skill.ID, Proficiency: skill.Proficiency, State: skill.State})
+	}
+	return requests
+}
+
+// FetchRoutingSkills fetches the Routing Skills of this User
+//
+// See https://developer.genesys.cloud/api/rest/v2/users/#get-api-v2-users--userId--routingskills
+func (user *User) FetchRoutingSkills() ([]*UserRoutingSkill, error) {
+	skills := []*UserRoutingSkill{}
+	page := 1
+	for {
+		response := struct {
+			Entities  []*UserRoutingSkill `json:"entities"`
+			PageCount int                 `json:"pageCount"`
+		}{}
+		if err := user.Client.Get(NewURI("/users/%s/routingskills?pageSize=100&pageNumber=%d", user.ID, page), &response); err != nil {
+			return nil, err
+		}
+		skills = append(skills, response.Entities...)
+		if page >= response.PageCount {
+			break
+		}
+		page++
+	}
+	user.Skills = skills
+	return skills, nil
+}
+
+// AddRoutingSkill adds a Routing Skill to this User
+func (user *User) AddRoutingSkill(skill *UserRoutingSkill) error {
+	if skill == nil || len(skill.ID) == 0 {
+		return errors.ArgumentMissing.With("skill").WithStack()
+	}
+	added := &UserRoutingSkill{}
+	if err := user.Client.Post(NewURI("/users/%s/routingskills", user.ID), newUserRoutingSkillRequests([]*UserRoutingSkill{skill})[0], &added); err != nil {
+		return err
+	}
+	*skill = *added
+	return nil
+}
+
+// UpdateRoutingSkill updates the proficiency (and state) of a Routing Skill of this User
+func (user *User) UpdateRoutingSkill(skill *UserRoutingSkill) error {
+	if skill == nil || len(skill.ID) == 0 {
+		return errors.ArgumentMissing.With("skill").WithStack()
+	}
+	updated := &UserRoutingSkill{}
+	if err := user.Client.Put(NewURI("/users/%s/routingskills/%s", user.ID, skill.ID), newUserRoutingSkillRequests([]*UserRoutingSkill{skill})[0], &updated); err != nil {
+		return err
+	}
+	*skill = *updated
+	return nil
+}
+
+// RemoveRoutingSkill removes a Routing Skill from this User
+func (user *User) RemoveRoutingSkill(skill *UserRoutingSkill) error {
+	if skill == nil || len(skill.ID) == 0 {
+		return errors.ArgumentMissing.With("skill").WithStack()
+	}
+	return user.Client.Delete(NewURI("/users/%s/routingskills/%s", user.ID, skill.ID), nil)
+}
+
+// PatchRoutingSkills adds or updates several Routing Skills of this User
+//
+// The other skills of the user are not changed
+func (user *User) PatchRoutingSkills(skills ...*UserRoutingSkill) ([]*UserRoutingSkill, error) {
+	return user.bulkRoutingSkills(user.Client.Patch, skills)
+}
+
+// SetRoutingSkills replaces all the Routing Skills of this User
+func (user *User) SetRoutingSkills(skills ...*UserRoutingSkill) ([]*UserRoutingSkill, error) {
+	return user.bulkRoutingSkills(user.Client.Put, skills)
+}
+
+func (user *User) bulkRoutingSkills(send func(URI, interface{}, interface{}) error, skills []*UserRoutingSkill) ([]*UserRoutingSkill, error) {
+	response := struct {
+		Entities []*UserRoutingSkill `json:"entities"`
+	}{}
+	if err := send(NewURI("/users/%s/routingskills/bulk", user.ID), newUserRoutingSkillRequests(skills), &response); err != nil {
+		return nil, err
+	}
+	user.Skills = response.Entities
+	return response.Entities, nil
+}
+
+// ApplyRoutingSkills adds or updates the given Routing Skills to several users
+//
+// The users are updated one after the other, each user gets its own result or error
+func (client *Client) ApplyRoutingSkills(users []*User, skills ...*UserRoutingSkill) []UserRoutingSkillsResult {
+	results := make([]UserRoutingSkillsResult, len(users))
+	for i, user := range users {
+		results[i].User = user
+		if user.Client == nil {
+			user.Client = client
+		}
+		results[i].Skills, results[i].Error = user.PatchRoutingSkills(skills...)
+	}
+	return results
+}
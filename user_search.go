@@ -0,0 +1,244 @@
+package gcloudcx
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gildas/go-errors"
+	"github.com/google/uuid"
+)
+
+// UserSearchQuery describes a query for the /users/search API
+//
+// See https://developer.genesys.cloud/api/rest/v2/users/#post-api-v2-users-search
+type UserSearchQuery struct {
+	Query      []*UserSearchCriteria `json:"query"`
+	Expand     []string              `json:"expand,omitempty"`
+	SortOrder  string                `json:"sortOrder,omitempty"`
+	SortBy     string                `json:"sortBy,omitempty"`
+	PageSize   int                   `json:"pageSize,omitempty"`
+	PageNumber int                   `json:"pageNumber,omitempty"`
+}
+
+// UserSearchCriteria describes a criteria of a UserSearchQuery
+type UserSearchCriteria struct {
+	Type     string   `json:"type"`
+	Fields   []string `json:"fields,omitempty"`
+	Value    string   `json:"value,omitempty"`
+	Values   []string `json:"values,omitempty"`
+	Operator string   `json:"operator,omitempty"`
+}
+
+// UserSearchResults describes the results of a UserSearchQuery
+type UserSearchResults struct {
+	Users      []*User `json:"results"`
+	Total      int     `json:"total"`
+	PageSize   int     `json:"pageSize"`
+	PageNumber int     `json:"pageNumber"`
+	PageCount  int     `json:"pageCount"`
+}
+
+// NewUserSearchQuery creates a new empty UserSearchQuery
+//
+// Add criteria with the With... methods, e.g.:
+//
+//	query := gcloudcx.NewUserSearchQuery().WithDepartment("Sales").WithExpand("presence")
+func NewUserSearchQuery() *UserSearchQuery {
+	return &UserSearchQuery{Query: []*UserSearchCriteria{}}
+}
+
+// WithCriteria adds a criteria to this query
+func (query *UserSearchQuery) WithCriteria(criteria *UserSearchCriteria) *UserSearchQuery {
+	if criteria != nil {
+		query.Query = append(query.Query, criteria)
+	}
+	return query
+}
+
+// WithEmail adds a criteria that matches the given email exactly
+func (query *UserSearchQuery) WithEmail(email string) *UserSearchQuery {
+	return query.WithCriteria(&UserSearchCriteria{Type: "EXACT", Fields: []string{"email"}, Value: email})
+}
+
+// WithName adds a criteria that matches users whose name contains the given value
+func (query *UserSearchQuery) WithName(name string) *UserSearchQuery {
+	return query.WithCriteria(&UserSearchCriteria{Type: "CONTAINS", Fields: []string{"name"}, Value: name})
+}
+
+// WithDepartment adds a criteria that matches the given department exactly
+func (query *UserSearchQuery) WithDepartment(department string) *UserSearchQuery {
+	return query.WithCriteria(&UserSearchCriteria{Type: "EXACT", Fields: []string{"department"}, Value: department})
+}
+
+// WithDivision adds a criteria that matches users of the given divisions
+func (query *UserSearchQuery) WithDivision(divisions ...Identifiable) *UserSearchQuery {
+	values := make([]string, 0, len(divisions))
+	for _, division := range divisions {
+		values = append(values, division.GetID().String())
+	}
+	return query.WithCriteria(&UserSearchCriteria{Type: "EXACT", Fields: []string{"divisionId"}, Values: values})
+}
+
+// WithExpand tells which properties should be expanded in the results (e.g.: presence, routingStatus)
+func (query *UserSearchQuery) WithExpand(properties ...string) *UserSearchQuery {
+	query.Expand = append(query.Expand, properties...)
+	return query
+}
+
+// WithPage sets the page to fetch
+func (query *UserSearchQuery) WithPage(pageNumber, pageSize int) *UserSearchQuery {
+	query.PageNumber = pageNumber
+	query.PageSize = pageSize
+	return query
+}
+
+// Validate validates this query
+func (query UserSearchQuery) Validate() error {
+	if len(query.Query) == 0 {
+		return errors.ArgumentMissing.With("query").WithStack()
+	}
+	for _, criteria := range query.Query {
+		if len(criteria.Type) == 0 {
+			return errors.ArgumentMissing.With("type").WithStack()
+		}
+		if len(criteria.Value) == 0 && len(criteria.Values) == 0 {
+			return errors.ArgumentMissing.With("value").WithStack()
+		}
+	}
+	return nil
+}
+
+// SearchUsers searches users with the given query
+//
+// Only the page given in the query is fetched, use SearchAllUsers to get all the results
+func (client *Client) SearchUsers(query *UserSearchQuery) (*UserSearchResults, error) {
+	if query == nil {
+		return nil, errors.ArgumentMissing.With("query").WithStack()
+	}
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	results := &UserSearchResults{}
+	if err := client.Post("/users/search", query, &results); err != nil {
+		return nil, err
+	}
+	for _, user := range results.Users {
+		user.Client = client
+		user.Logger = client.Logger.Child("user", "user", "user", user.ID)
+	}
+	return results, nil
+}
+
+// SearchAllUsers searches users with the given query and fetches all the result pages
+func (client *Client) SearchAllUsers(query *UserSearchQuery) ([]*User, error) {
+	if query == nil {
+		return nil, errors.ArgumentMissing.With("query").WithStack()
+	}
+	paged := *query
+	if paged.PageSize == 0 {
+		paged.PageSize = 100
+	}
+	paged.PageNumber = 1
+	users := []*User{}
+	for {
+		results, err := client.SearchUsers(&paged)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, results.Users...)
+		if paged.PageNumber >= results.PageCount {
+			break
+		}
+		paged.PageNumber++
+	}
+	return users, nil
+}
+
+// FindUserByEmail finds the user with the given email
+//
+// properties is one of more properties that should be expanded
+func (client *Client) FindUserByEmail(email string, properties ...string) (*User, error) {
+	if len(email) == 0 {
+		return nil, errors.ArgumentMissing.With("email").WithStack()
+	}
+	results, err := client.SearchUsers(NewUserSearchQuery().WithEmail(email).WithExpand(properties...))
+	if err != nil {
+		return nil, err
+	}
+	if len(results.Users) == 0 {
+		return nil, errors.NotFound.With("email", email).WithStack()
+	}
+	return results.Users[0], nil
+}
+
+// FetchUsers fetches a page of the users of the organization
+//
+// properties is one of more properties that should be expanded.
+// It returns the users of the page and the total number of pages.
+//
+// See https://developer.genesys.cloud/api/rest/v2/users/#get-api-v2-users
+func (client *Client) FetchUsers(pageNumber, pageSize int, properties ...string) ([]*User, int, error) {
+	query := url.Values{}
+	if pageNumber > 0 {
+		query.Add("pageNumber", strconv.Itoa(pageNumber))
+	}
+	if pageSize > 0 {
+		query.Add("pageSize", strconv.Itoa(pageSize))
+	}
+	if len(properties) > 0 {
+		query.Add("expand", strings.Join(properties, ","))
+	}
+	response := struct {
+		Entities  []*User `json:"entities"`
+		PageCount int     `json:"pageCount"`
+	}{}
+	if err := client.Get(NewURI("/users?%s", query.Encode()), &response); err != nil {
+		return nil, 0, err
+	}
+	for _, user := range response.Entities {
+		user.Client = client
+		user.Logger = client.Logger.Child("user", "user", "user", user.ID)
+	}
+	return response.Entities, response.PageCount, nil
+}
+
+// FetchAllUsers fetches all the users of the organization
+//
+// properties is one of more properties that should be expanded
+func (client *Client) FetchAllUsers(properties ...string) ([]*User, error) {
+	users := []*User{}
+	page := 1
+	for {
+		entities, pageCount, err := client.FetchUsers(page, 100, properties...)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, entities...)
+		if page >= pageCount {
+			break
+		}
+		page++
+	}
+	return users, nil
+}
+
+// FetchUser fetches the user with the given identifier
+//
+// properties is one of more properties that should be expanded
+func (client *Client) FetchUser(id uuid.UUID, properties ...string) (*User, error) {
+	if id == uuid.Nil {
+		return nil, errors.ArgumentMissing.With("id").WithStack()
+	}
+	query := url.Values{}
+	if len(properties) > 0 {
+		query.Add("expand", strings.Join(properties, ","))
+	}
+	user := &User{}
+	if err := client.Get(NewURI("/users/%s?%s", id, query.Encode()), &user); err != nil {
+		return nil, err
+	}
+	user.Client = client
+	user.Logger = client.Logger.Child("user", "user", "user", user.ID)
+	return user, nil
+}
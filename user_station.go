@@ -1,7 +1,11 @@
 package gcloudcx
 
 import (
+	"net/url"
+	"strings"
 	"time"
+
+	"github.com/gildas/go-errors"
 )
 
 // UserStation describes a User Station
@@ -24,3 +28,34 @@ type UserStations struct {
 	DefaultStation        *UserStation `json:"defaultStation"`
 	EffectiveStation      *UserStation `json:"effectiveStation"`
 }
+
+// FindStationByName finds a Station by its name
+//   see https://developer.genesys.cloud/api/rest/v2/stations/#get-api-v2-stations
+func (client *Client) FindStationByName(name string) (*UserStation, error) {
+	if len(name) == 0 {
+		return nil, errors.ArgumentMissing.With("name").WithStack()
+	}
+	response := struct {
+		Entities []*UserStation `json:"entities"`
+	}{}
+	query := url.Values{}
+	query.Add("name", name)
+	if err := client.Get(NewURI("/stations?%s", query.Encode()), &response); err != nil {
+		return nil, err
+	}
+	for _, station := range response.Entities {
+		if strings.EqualFold(station.Name, name) {
+			return station, nil
+		}
+	}
+	return nil, errors.NotFound.With("station", name).WithStack()
+}
+
+// SetDefaultStation sets the default Station of this User
+//   see https://developer.genesys.cloud/api/rest/v2/users/#put-api-v2-users--userId--station-defaultstation--stationId-
+func (user *User) SetDefaultStation(station *UserStation) error {
+	if station == nil || len(station.ID) == 0 {
+		return errors.ArgumentMissing.With("station").WithStack()
+	}
+	return user.Client.Put(NewURI("/users/%s/station/defaultstation/%s", user.ID, station.ID), nil, nil)
+}
@@ -0,0 +1,458 @@
+package gcloudcx
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gildas/go-errors"
+	"github.com/gildas/go-logger"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+// WebMessagingGuest is a customer-side client of Web Messaging
+//
+// It speaks the Web Messaging websocket protocol and is meant for automated tests and headless bots.
+//
+// Messages sent by agents and bots, typing indicators and session events are sent to EventReceived.
+//
+// If the websocket fails, the guest is disconnected and EventReceived is closed, Connect creates a new EventReceived.
+//
+// See https://developer.genesys.cloud/api/digital/webmessaging/websocketapi
+type WebMessagingGuest struct {
+	DeploymentID  uuid.UUID
+	Token         string   // Identifies the session, reusing a token resumes the session
+	URL           *url.URL // wss://webmessaging.{region}/v1?deploymentId={deploymentId}
+	Socket        *websocket.Conn
+	EventReceived chan *WebMessagingEvent
+	Timeout       time.Duration // How long to wait for the responses of Web Messaging, Default: 10s
+	KeepAlive     time.Duration // How often to ping Web Messaging while connected, 0 disables it, Default: 1 minute
+	Logger        *logger.Logger
+	expired       bool
+	eventsClosed  bool
+	closed        chan struct{}
+	waiters       []*webMessagingWaiter // in the order the requests were sent
+	mutex         sync.Mutex
+	writeMutex    sync.Mutex
+}
+
+// webMessagingWaiter waits for an event of a given class
+type webMessagingWaiter struct {
+	Class   string
+	Request bool // true if the waiter expects the response of a request, and therefore its errors
+	Events  chan *WebMessagingEvent
+}
+
+// NewWebMessagingGuest creates a new Web Messaging guest for the Client's Region and DeploymentID
+//
+// A new session Token is generated
+func (client *Client) NewWebMessagingGuest() (*WebMessagingGuest, error) {
+	if client.DeploymentID == uuid.Nil {
+		return nil, errors.ArgumentMissing.With("DeploymentID").WithStack()
+	}
+	endpoint, err := url.Parse(fmt.Sprintf("wss://webmessaging.%s/v1?deploymentId=%s", client.Region, client.DeploymentID))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	token := uuid.New()
+	return &WebMessagingGuest{
+		DeploymentID:  client.DeploymentID,
+		Token:         token.String(),
+		URL:           endpoint,
+		EventReceived: make(chan *WebMessagingEvent, 100),
+		Timeout:       10 * time.Second,
+		KeepAlive:     1 * time.Minute,
+		Logger:        logger.CreateIfNil(client.Logger, "gcloudcx").Child("webmessaging", "webmessaging", "token", token),
+	}, nil
+}
+
+// Connect connects to the Web Messaging websocket and configures the session
+//
+// If the Token was used before, the existing session is resumed
+func (guest *WebMessagingGuest) Connect(ctx context.Context) error {
+	guest.mutex.Lock()
+	connected := guest.Socket != nil
+	guest.mutex.Unlock()
+	if connected {
+		return nil
+	}
+	if guest.URL == nil {
+		return errors.ArgumentMissing.With("URL").WithStack()
+	}
+	socket, _, err := websocket.DefaultDialer.DialContext(ctx, guest.URL.String(), nil)
+	if err != nil {
+		return errors.NotConnected.Wrap(err)
+	}
+	guest.mutex.Lock()
+	if guest.Socket != nil {
+		// Another Connect won the race
+		guest.mutex.Unlock()
+		_ = socket.Close()
+		return nil
+	}
+	guest.Socket = socket
+	guest.closed = make(chan struct{})
+	if guest.eventsClosed {
+		guest.EventReceived = make(chan *WebMessagingEvent, 100)
+		guest.eventsClosed = false
+	}
+	closed, events := guest.closed, guest.EventReceived
+	guest.mutex.Unlock()
+	go guest.messageLoop(socket, closed, events)
+	if guest.KeepAlive > 0 {
+		go guest.keepAlive(guest.KeepAlive, closed)
+	}
+
+	response, err := guest.request(ctx, "SessionResponse", struct {
+		Action       string `json:"action"`
+		DeploymentID string `json:"deploymentId"`
+		Token        string `json:"token"`
+	}{
+		Action:       "configureSession",
+		DeploymentID: guest.DeploymentID.String(),
+		Token:        guest.Token,
+	})
+	if err != nil {
+		_ = guest.Close()
+		return err
+	}
+	guest.Logger.Scope("connect").Infof("Session configured: %s", string(response.Body))
+	return nil
+}
+
+// Close disconnects the websocket
+func (guest *WebMessagingGuest) Close() error {
+	guest.mutex.Lock()
+	socket := guest.Socket
+	guest.Socket = nil
+	if guest.closed != nil {
+		close(guest.closed)
+		guest.closed = nil
+	}
+	guest.mutex.Unlock()
+	if socket == nil {
+		return nil
+	}
+	if err := socket.Close(); err != nil {
+		return errors.WithMessage(err, "Failed while closing websocket")
+	}
+	return nil
+}
+
+// IsExpired tells if the session expired
+func (guest *WebMessagingGuest) IsExpired() bool {
+	guest.mutex.Lock()
+	defer guest.mutex.Unlock()
+	return guest.expired
+}
+
+// SendText sends a text message, with optional custom attributes
+func (guest *WebMessagingGuest) SendText(text string, customAttributes map[string]string) error {
+	return guest.SendMessage(&WebMessagingMessage{
+		Type:    "Text",
+		Text:    text,
+		Channel: &WebMessagingChannel{CustomAttributes: customAttributes},
+	})
+}
+
+// SendTyping sends a typing indicator
+func (guest *WebMessagingGuest) SendTyping() error {
+	return guest.SendMessage(&WebMessagingMessage{
+		Type:   "Event",
+		Events: []*OpenMessageEvent{NewTypingEvent(0)},
+	})
+}
+
+// SendAttachment sends a message with an attachment that was uploaded with UploadAttachment
+func (guest *WebMessagingGuest) SendAttachment(text, attachmentID string) error {
+	return guest.SendMessage(&WebMessagingMessage{
+		Type:    "Text",
+		Text:    text,
+		Content: []*OpenMessageContent{NewAttachmentContent(&OpenMessageAttachment{ID: attachmentID})},
+	})
+}
+
+// SendMessage sends a message to Web Messaging
+func (guest *WebMessagingGuest) SendMessage(message *WebMessagingMessage) error {
+	if message == nil {
+		return errors.ArgumentMissing.With("message").WithStack()
+	}
+	if guest.IsExpired() {
+		return errors.NotConnected.With("session").WithStack()
+	}
+	return guest.send(struct {
+		Action  string               `json:"action"`
+		Token   string               `json:"token"`
+		Message *WebMessagingMessage `json:"message"`
+	}{
+		Action:  "onMessage",
+		Token:   guest.Token,
+		Message: message,
+	})
+}
+
+// UploadAttachment uploads an attachment and returns its identifier
+//
+// The attachment can then be sent with SendAttachment
+func (guest *WebMessagingGuest) UploadAttachment(ctx context.Context, fileName, mimeType string, content []byte) (string, error) {
+	if len(fileName) == 0 {
+		return "", errors.ArgumentMissing.With("fileName").WithStack()
+	}
+	hash := md5.Sum(content)
+	response, err := guest.request(ctx, "PresignedUrlResponse", struct {
+		Action   string `json:"action"`
+		Token    string `json:"token"`
+		FileName string `json:"fileName"`
+		FileType string `json:"fileType"`
+		FileSize int    `json:"fileSize"`
+		FileMD5  string `json:"fileMd5"`
+	}{
+		Action:   "onAttachment",
+		Token:    guest.Token,
+		FileName: fileName,
+		FileType: mimeType,
+		FileSize: len(content),
+		FileMD5:  hex.EncodeToString(hash[:]),
+	})
+	if err != nil {
+		return "", err
+	}
+	presigned := WebMessagingPresignedURL{}
+	if err = json.Unmarshal(response.Body, &presigned); err != nil {
+		return "", errors.JSONUnmarshalError.Wrap(err)
+	}
+
+	success := guest.wait("UploadSuccessEvent", false)
+	defer guest.unwait(success)
+	request, err := http.NewRequestWithContext(ctx, http.MethodPut, presigned.URL, bytes.NewReader(content))
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	for key, value := range presigned.Headers {
+		request.Header.Set(key, value)
+	}
+	if len(request.Header.Get("Content-Type")) == 0 {
+		request.Header.Set("Content-Type", mimeType)
+	}
+	upload, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	upload.Body.Close()
+	if upload.StatusCode >= 300 {
+		return "", errors.FromHTTPStatusCode(upload.StatusCode)
+	}
+	if _, err = guest.waitFor(ctx, success); err != nil {
+		return "", err
+	}
+	return presigned.AttachmentID, nil
+}
+
+// request sends a payload and waits for the response of the given class
+//
+// The waiter is queued while holding the write lock, so the waiters are in the same order as the requests on the websocket
+func (guest *WebMessagingGuest) request(ctx context.Context, class string, payload interface{}) (*WebMessagingEvent, error) {
+	guest.writeMutex.Lock()
+	waiter := guest.wait(class, true)
+	defer guest.unwait(waiter)
+	err := guest.write(payload)
+	guest.writeMutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return guest.waitFor(ctx, waiter)
+}
+
+func (guest *WebMessagingGuest) send(payload interface{}) error {
+	guest.writeMutex.Lock()
+	defer guest.writeMutex.Unlock()
+	return guest.write(payload)
+}
+
+// write writes a payload to the websocket, the caller must hold the write lock
+func (guest *WebMessagingGuest) write(payload interface{}) error {
+	guest.mutex.Lock()
+	socket := guest.Socket
+	guest.mutex.Unlock()
+	if socket == nil {
+		return errors.NotConnected.With("websocket").WithStack()
+	}
+	if err := socket.WriteJSON(payload); err != nil {
+		return errors.NotConnected.Wrap(err)
+	}
+	return nil
+}
+
+// wait queues a waiter for the next event of the given class
+func (guest *WebMessagingGuest) wait(class string, request bool) *webMessagingWaiter {
+	guest.mutex.Lock()
+	defer guest.mutex.Unlock()
+	waiter := &webMessagingWaiter{Class: class, Request: request, Events: make(chan *WebMessagingEvent, 1)}
+	guest.waiters = append(guest.waiters, waiter)
+	return waiter
+}
+
+// unwait removes a waiter from the queue if it did not get its event
+func (guest *WebMessagingGuest) unwait(waiter *webMessagingWaiter) {
+	guest.mutex.Lock()
+	defer guest.mutex.Unlock()
+	guest.dequeue(waiter)
+}
+
+// dequeue removes a waiter from the queue, the caller must hold the mutex
+func (guest *WebMessagingGuest) dequeue(waiter *webMessagingWaiter) {
+	for index, queued := range guest.waiters {
+		if queued == waiter {
+			guest.waiters = append(guest.waiters[:index], guest.waiters[index+1:]...)
+			return
+		}
+	}
+}
+
+// waiterFor finds and dequeues the waiter of an event
+//
+// Web Messaging answers the requests in order, so an event goes to the oldest waiter of its class.
+// Errors are sent with the class "string", they go to the oldest waiting request.
+func (guest *WebMessagingGuest) waiterFor(event *WebMessagingEvent) (*webMessagingWaiter, bool) {
+	guest.mutex.Lock()
+	defer guest.mutex.Unlock()
+	for _, waiter := range guest.waiters {
+		if waiter.Class == event.Class {
+			guest.dequeue(waiter)
+			return waiter, true
+		}
+	}
+	if event.Type == "response" && event.Code >= 300 {
+		for _, waiter := range guest.waiters {
+			if waiter.Request {
+				guest.dequeue(waiter)
+				return waiter, true
+			}
+		}
+	}
+	return nil, false
+}
+
+func (guest *WebMessagingGuest) waitFor(ctx context.Context, waiter *webMessagingWaiter) (*WebMessagingEvent, error) {
+	timeout := guest.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	select {
+	case event := <-waiter.Events:
+		if event.Code >= 300 {
+			return nil, errors.WithMessage(errors.FromHTTPStatusCode(event.Code), string(event.Body))
+		}
+		return event, nil
+	case <-ctx.Done():
+		return nil, errors.WithStack(ctx.Err())
+	case <-time.After(timeout):
+		return nil, errors.HTTPStatusRequestTimeout.WithStack()
+	}
+}
+
+// disconnect marks the guest as disconnected after its websocket failed and closes EventReceived
+//
+// Nothing is done if the websocket was closed by Close
+func (guest *WebMessagingGuest) disconnect(socket *websocket.Conn, events chan *WebMessagingEvent) {
+	guest.mutex.Lock()
+	if guest.Socket != socket {
+		guest.mutex.Unlock()
+		return
+	}
+	guest.Socket = nil
+	close(guest.closed)
+	guest.closed = nil
+	guest.eventsClosed = true
+	guest.mutex.Unlock()
+	close(events) // only the receive handler sends to events, so nothing can be sent after this
+	_ = socket.Close()
+}
+
+// keepAlive pings Web Messaging at the given interval until the websocket is closed
+func (guest *WebMessagingGuest) keepAlive(interval time.Duration, closed chan struct{}) {
+	log := guest.Logger.Scope("keepalive")
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-closed:
+			return
+		case <-ticker.C:
+			err := guest.send(struct {
+				Action  string               `json:"action"`
+				Token   string               `json:"token"`
+				Message *WebMessagingMessage `json:"message"`
+			}{
+				Action:  "echo",
+				Token:   guest.Token,
+				Message: &WebMessagingMessage{Type: "Text", Text: "ping"},
+			})
+			if err != nil {
+				log.Warnf("Failed to ping Web Messaging: %s", err.Error())
+			}
+		}
+	}
+}
+
+func (guest *WebMessagingGuest) messageLoop(socket *websocket.Conn, closed chan struct{}, events chan *WebMessagingEvent) {
+	log := guest.Logger.Scope("receive")
+
+	for {
+		_, body, err := socket.ReadMessage()
+		if err != nil {
+			select {
+			case <-closed:
+				log.Infof("Websocket was closed, stopping receive handler")
+				return
+			default:
+			}
+			if strings.Contains(err.Error(), "use of closed network connection") || websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				log.Infof("Websocket was closed by Web Messaging, disconnecting")
+			} else {
+				log.Errorf("Failed to read incoming message, disconnecting", err)
+			}
+			guest.disconnect(socket, events)
+			return
+		}
+		log.Tracef("Received %d bytes: %s", len(body), string(body))
+		event := &WebMessagingEvent{}
+		if err = json.Unmarshal(body, event); err != nil {
+			log.Warnf("%s, Body size: %d, Content: %s", err.Error(), len(body), string(body))
+			continue
+		}
+		switch event.Class {
+		case "SessionExpiredEvent", "SessionClearedEvent":
+			guest.mutex.Lock()
+			guest.expired = true
+			guest.mutex.Unlock()
+		case "string":
+			if strings.Contains(string(event.Body), "pong") {
+				continue
+			}
+		case "StructuredMessage":
+			if event.Type == "response" && event.Message != nil && event.Message.Text == "ping" {
+				continue // answer to keepAlive
+			}
+		}
+		if waiter, found := guest.waiterFor(event); found {
+			waiter.Events <- event // the waiter is dequeued, its buffer is free
+			continue
+		}
+		select {
+		case events <- event:
+		case <-closed:
+			log.Infof("Websocket was closed, dropping event %s and stopping receive handler", event.Class)
+			return
+		}
+	}
+}
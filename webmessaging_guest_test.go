@@ -0,0 +1,390 @@
+package gcloudcx_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gildas/go-errors"
+	"github.com/gildas/go-gcloudcx"
+	"github.com/gildas/go-logger"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/suite"
+)
+
+type WebMessagingSuite struct {
+	suite.Suite
+	Name   string
+	Logger *logger.Logger
+	Start  time.Time
+
+	Server  *httptest.Server
+	Uploads *sync.Map // the uploaded attachments per path
+	Pings   int32     // the number of echo requests, use atomic
+}
+
+func TestWebMessagingSuite(t *testing.T) {
+	suite.Run(t, new(WebMessagingSuite))
+}
+
+func (suite *WebMessagingSuite) TestCanChat() {
+	guest := suite.connect()
+	defer guest.Close()
+
+	err := guest.SendText("Hello", map[string]string{"customerId": "1234"})
+	suite.Require().Nilf(err, "Failed to send text. %s", err)
+
+	echo := suite.receive(guest)
+	suite.Require().NotNil(echo.Message, "Event should be a message")
+	suite.Assert().Equal("Inbound", echo.Message.Direction)
+	suite.Assert().Equal("Hello", echo.Message.Text)
+	suite.Assert().Equal("1234", echo.Message.Channel.CustomAttributes["customerId"])
+
+	reply := suite.receive(guest)
+	suite.Require().NotNil(reply.Message, "Event should be a message")
+	suite.Assert().True(reply.Message.IsFromAgent())
+	suite.Assert().Equal("You said: Hello", reply.Message.Text)
+
+	err = guest.SendTyping()
+	suite.Require().Nilf(err, "Failed to send typing. %s", err)
+	typing := suite.receive(guest)
+	suite.Require().NotNil(typing.Message, "Event should be a message")
+	suite.Assert().True(typing.Message.IsTyping())
+}
+
+func (suite *WebMessagingSuite) TestCanUploadAttachment() {
+	guest := suite.connect()
+	defer guest.Close()
+
+	attachmentID, err := guest.UploadAttachment(context.Background(), "hello.txt", "text/plain", []byte("Hello World"))
+	suite.Require().Nilf(err, "Failed to upload attachment. %s", err)
+	suite.Assert().Equal("attachment-1", attachmentID)
+	uploaded, _ := suite.Uploads.Load("/uploads/attachment-1")
+	suite.Assert().Equal("Hello World", string(uploaded.([]byte)))
+
+	err = guest.SendAttachment("Here is my file", attachmentID)
+	suite.Require().Nilf(err, "Failed to send attachment. %s", err)
+	echo := suite.receive(guest)
+	suite.Require().NotNil(echo.Message, "Event should be a message")
+	suite.Require().Len(echo.Message.Content, 1)
+	suite.Assert().Equal("attachment-1", echo.Message.Content[0].Attachment.ID)
+}
+
+func (suite *WebMessagingSuite) TestShouldExpireSession() {
+	guest := suite.connect()
+	defer guest.Close()
+
+	err := guest.SendText("expire", nil)
+	suite.Require().Nilf(err, "Failed to send text. %s", err)
+	for {
+		event := suite.receive(guest)
+		if event.Class == "SessionExpiredEvent" {
+			break
+		}
+	}
+	suite.Assert().True(guest.IsExpired())
+	err = guest.SendText("Hello?", nil)
+	suite.Require().NotNil(err, "Should not send on an expired session")
+	suite.Assert().True(errors.Is(err, errors.NotConnected), "Error should be a NotConnected")
+}
+
+func (suite *WebMessagingSuite) TestCanUploadAttachmentsConcurrently() {
+	guest := suite.connect()
+	defer guest.Close()
+
+	var wg sync.WaitGroup
+	attachmentIDs := make([]string, 2)
+	errs := make([]error, 2)
+	for index := range attachmentIDs {
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			attachmentIDs[index], errs[index] = guest.UploadAttachment(context.Background(), fmt.Sprintf("hello-%d.txt", index), "text/plain", []byte("Hello World"))
+		}(index)
+	}
+	wg.Wait()
+	suite.Require().Nilf(errs[0], "Failed to upload attachment. %s", errs[0])
+	suite.Require().Nilf(errs[1], "Failed to upload attachment. %s", errs[1])
+	suite.Assert().ElementsMatch([]string{"attachment-1", "attachment-2"}, attachmentIDs, "Each upload should get its own response")
+}
+
+func (suite *WebMessagingSuite) TestShouldGiveErrorToItsRequest() {
+	guest := suite.connect()
+	defer guest.Close()
+
+	var wg sync.WaitGroup
+	var failed, succeeded error
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_, failed = guest.UploadAttachment(context.Background(), "fail.txt", "text/plain", []byte("Hello World"))
+	}()
+	go func() {
+		defer wg.Done()
+		_, succeeded = guest.UploadAttachment(context.Background(), "hello.txt", "text/plain", []byte("Hello World"))
+	}()
+	wg.Wait()
+	suite.Require().NotNil(failed, "The upload of fail.txt should fail")
+	suite.Assert().True(errors.Is(failed, errors.HTTPBadRequest), "Error should be a Bad Request, got %s", failed)
+	suite.Assert().Nilf(succeeded, "The upload of hello.txt should succeed. %s", succeeded)
+}
+
+func (suite *WebMessagingSuite) TestShouldStopReceivingWhenClosedWithUnreadEvents() {
+	before := runtime.NumGoroutine()
+	guest := suite.connect()
+
+	err := guest.SendText("flood", nil)
+	suite.Require().Nilf(err, "Failed to send text. %s", err)
+	suite.Require().Eventually(func() bool { return len(guest.EventReceived) == cap(guest.EventReceived) }, 2*time.Second, 10*time.Millisecond, "EventReceived should be full")
+	suite.Require().Nil(guest.Close())
+	suite.Assert().Eventually(func() bool { return runtime.NumGoroutine() <= before }, 2*time.Second, 10*time.Millisecond, "The receive handler should stop")
+}
+
+func (suite *WebMessagingSuite) TestCanConnectConcurrently() {
+	guest := suite.connect()
+	defer guest.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			suite.Assert().Nil(guest.Connect(context.Background()))
+		}()
+	}
+	wg.Wait()
+	suite.Assert().NotNil(guest.Socket)
+}
+
+func (suite *WebMessagingSuite) TestShouldSkipUnknownContent() {
+	payload := []byte(`{"type": "message", "class": "StructuredMessage", "code": 200, "body": {
+		"id": "1234", "type": "Structured", "text": "Pick one", "direction": "Outbound",
+		"content": [
+			{"contentType": "Carousel", "carousel": {"cards": []}},
+			{"contentType": "Attachment", "attachment": {"id": "attachment-1", "mediaType": "Image", "url": "https://example.com/image.png"}}
+		]
+	}}`)
+	event := gcloudcx.WebMessagingEvent{}
+	err := json.Unmarshal(payload, &event)
+	suite.Require().Nilf(err, "Failed to unmarshal event. %s", err)
+	suite.Require().NotNil(event.Message, "Event should have a message")
+	suite.Assert().Equal("Pick one", event.Message.Text)
+	suite.Require().Len(event.Message.Content, 1, "The unknown content should be skipped")
+	suite.Assert().Equal("attachment-1", event.Message.Content[0].Attachment.ID)
+}
+
+func (suite *WebMessagingSuite) TestShouldKeepEventWithInvalidMessage() {
+	payload := []byte(`{"type": "message", "class": "StructuredMessage", "code": 200, "body": {"id": "1234", "type": "Text", "text": 42}}`)
+	event := gcloudcx.WebMessagingEvent{}
+	err := json.Unmarshal(payload, &event)
+	suite.Require().Nilf(err, "Failed to unmarshal event. %s", err)
+	suite.Assert().Equal("StructuredMessage", event.Class)
+	suite.Assert().Nil(event.Message, "The invalid message should not be decoded")
+	suite.Assert().Contains(string(event.Body), `"text": 42`)
+}
+
+func (suite *WebMessagingSuite) TestShouldDisconnectWhenWebsocketFails() {
+	guest := suite.connect()
+	defer guest.Close()
+
+	err := guest.SendText("disconnect", nil)
+	suite.Require().Nilf(err, "Failed to send text. %s", err)
+	events := guest.EventReceived
+	suite.Require().Eventually(func() bool {
+		select {
+		case _, ok := <-events:
+			return !ok
+		default:
+			return false
+		}
+	}, 2*time.Second, 10*time.Millisecond, "EventReceived should be closed")
+	suite.Assert().Nil(guest.Socket, "The guest should be disconnected")
+	err = guest.SendText("Hello?", nil)
+	suite.Assert().True(errors.Is(err, errors.NotConnected), "Error should be a NotConnected")
+
+	err = guest.Connect(context.Background())
+	suite.Require().Nilf(err, "Failed to reconnect. %s", err)
+	err = guest.SendText("Hello", nil)
+	suite.Require().Nilf(err, "Failed to send text. %s", err)
+	echo := suite.receive(guest)
+	suite.Require().NotNil(echo, "EventReceived should be open again")
+	suite.Assert().Equal("Hello", echo.Message.Text)
+}
+
+func (suite *WebMessagingSuite) TestCanKeepAlive() {
+	guest := suite.connect()
+	defer guest.Close()
+	suite.Require().Nil(guest.Close())
+	guest.KeepAlive = 20 * time.Millisecond
+	err := guest.Connect(context.Background())
+	suite.Require().Nilf(err, "Failed to connect. %s", err)
+
+	suite.Assert().Eventually(func() bool { return atomic.LoadInt32(&suite.Pings) >= 3 }, 2*time.Second, 10*time.Millisecond, "The guest should ping Web Messaging")
+	suite.Assert().Empty(guest.EventReceived, "The answers to pings should not be sent to EventReceived")
+	suite.Require().Nil(guest.Close())
+	pings := atomic.LoadInt32(&suite.Pings)
+	time.Sleep(100 * time.Millisecond)
+	suite.Assert().Equal(pings, atomic.LoadInt32(&suite.Pings), "The guest should stop pinging once closed")
+}
+
+func (suite *WebMessagingSuite) connect() *gcloudcx.WebMessagingGuest {
+	client := gcloudcx.NewClient(&gcloudcx.ClientOptions{
+		DeploymentID: uuid.New(),
+		Logger:       suite.Logger,
+	})
+	guest, err := client.NewWebMessagingGuest()
+	suite.Require().Nilf(err, "Failed to create guest. %s", err)
+	guest.URL, _ = url.Parse("ws" + strings.TrimPrefix(suite.Server.URL, "http") + "/v1?deploymentId=" + guest.DeploymentID.String())
+	guest.Timeout = 2 * time.Second
+	err = guest.Connect(context.Background())
+	suite.Require().Nilf(err, "Failed to connect. %s", err)
+	return guest
+}
+
+func (suite *WebMessagingSuite) receive(guest *gcloudcx.WebMessagingGuest) *gcloudcx.WebMessagingEvent {
+	select {
+	case event := <-guest.EventReceived:
+		return event
+	case <-time.After(2 * time.Second):
+		suite.Require().Fail("Timeout while waiting for an event")
+		return nil
+	}
+}
+
+// standIn plays the Web Messaging websocket server
+func (suite *WebMessagingSuite) standIn(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPut {
+		uploaded, _ := ioutil.ReadAll(r.Body)
+		suite.Uploads.Store(r.URL.Path, uploaded)
+		return
+	}
+	upgrader := websocket.Upgrader{}
+	socket, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer socket.Close()
+	var writeMutex sync.Mutex
+	respondWithCode := func(eventType, class string, code int, body interface{}) {
+		payload, _ := json.Marshal(body)
+		writeMutex.Lock()
+		defer writeMutex.Unlock()
+		_ = socket.WriteJSON(map[string]interface{}{"type": eventType, "class": class, "code": code, "body": json.RawMessage(payload)})
+	}
+	respond := func(eventType, class string, body interface{}) {
+		respondWithCode(eventType, class, 200, body)
+	}
+	attachments := 0
+	for {
+		request := struct {
+			Action   string          `json:"action"`
+			FileName string          `json:"fileName"`
+			Message  json.RawMessage `json:"message"`
+		}{}
+		if err := socket.ReadJSON(&request); err != nil {
+			return
+		}
+		switch request.Action {
+		case "configureSession":
+			respond("response", "SessionResponse", map[string]interface{}{"connected": true, "newSession": true})
+		case "echo":
+			atomic.AddInt32(&suite.Pings, 1)
+			message := map[string]interface{}{}
+			_ = json.Unmarshal(request.Message, &message)
+			message["id"] = uuid.New().String()
+			message["direction"] = "Inbound"
+			respond("response", "StructuredMessage", message)
+		case "onAttachment":
+			if request.FileName == "fail.txt" {
+				respondWithCode("response", "string", 400, "File type not supported")
+				continue
+			}
+			attachments++
+			attachmentID := fmt.Sprintf("attachment-%d", attachments)
+			respond("response", "PresignedUrlResponse", map[string]interface{}{
+				"attachmentId": attachmentID,
+				"url":          suite.Server.URL + "/uploads/" + attachmentID,
+				"fileName":     request.FileName,
+				"headers":      map[string]string{"x-amz-tagging": "abc"},
+			})
+			go func() {
+				time.Sleep(50 * time.Millisecond)
+				respond("message", "UploadSuccessEvent", map[string]interface{}{"attachmentId": attachmentID, "downloadUrl": "https://example.com/" + attachmentID})
+			}()
+		case "onMessage":
+			message := map[string]interface{}{}
+			_ = json.Unmarshal(request.Message, &message)
+			message["id"] = uuid.New().String()
+			message["direction"] = "Inbound"
+			respond("message", "StructuredMessage", message)
+			if message["text"] == "disconnect" {
+				return
+			} else if message["text"] == "flood" {
+				for i := 0; i < 150; i++ {
+					respond("message", "StructuredMessage", map[string]interface{}{"id": uuid.New().String(), "type": "Text", "text": "flood", "direction": "Outbound"})
+				}
+			} else if message["text"] == "expire" {
+				respond("message", "SessionExpiredEvent", map[string]interface{}{})
+			} else if message["type"] == "Text" && message["content"] == nil {
+				respond("message", "StructuredMessage", map[string]interface{}{
+					"id":                uuid.New().String(),
+					"type":              "Text",
+					"text":              fmt.Sprintf("You said: %s", message["text"]),
+					"direction":         "Outbound",
+					"originatingEntity": "Bot",
+				})
+			}
+		}
+	}
+}
+
+// Suite Tools
+
+func (suite *WebMessagingSuite) SetupSuite() {
+	suite.Name = strings.TrimSuffix(reflect.TypeOf(*suite).Name(), "Suite")
+	suite.Logger = logger.Create("test",
+		&logger.FileStream{
+			Path:        fmt.Sprintf("./log/test-%s.log", strings.ToLower(suite.Name)),
+			Unbuffered:  true,
+			FilterLevel: logger.TRACE,
+		},
+	).Child("test", "test")
+	suite.Logger.Infof("Suite Start: %s %s", suite.Name, strings.Repeat("=", 80-14-len(suite.Name)))
+	suite.Server = httptest.NewServer(http.HandlerFunc(suite.standIn))
+}
+
+func (suite *WebMessagingSuite) TearDownSuite() {
+	suite.Server.Close()
+	if suite.T().Failed() {
+		suite.Logger.Warnf("At least one test failed, we are not cleaning")
+		suite.T().Log("At least one test failed, we are not cleaning")
+	} else {
+		suite.Logger.Infof("All tests succeeded, we are cleaning")
+	}
+	suite.Logger.Infof("Suite End: %s %s", suite.Name, strings.Repeat("=", 80-12-len(suite.Name)))
+	suite.Logger.Close()
+}
+
+func (suite *WebMessagingSuite) BeforeTest(suiteName, testName string) {
+	suite.Logger.Infof("Test Start: %s %s", testName, strings.Repeat("-", 80-13-len(testName)))
+	suite.Start = time.Now()
+	suite.Uploads = &sync.Map{}
+	atomic.StoreInt32(&suite.Pings, 0)
+}
+
+func (suite *WebMessagingSuite) AfterTest(suiteName, testName string) {
+	duration := time.Since(suite.Start)
+	suite.Logger.Record("duration", duration.String()).Infof("Test End: %s %s", testName, strings.Repeat("-", 80-11-len(testName)))
+}
@@ -0,0 +1,165 @@
+package gcloudcx
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gildas/go-core"
+	"github.com/gildas/go-errors"
+)
+
+// WebMessagingEvent describes an event received on the Web Messaging websocket
+//
+// See https://developer.genesys.cloud/api/digital/webmessaging/websocketapi
+type WebMessagingEvent struct {
+	Type    string               `json:"type"`  // response, message
+	Class   string               `json:"class"` // SessionResponse, StructuredMessage, PresignedUrlResponse, UploadSuccessEvent, SessionExpiredEvent, SessionClearedEvent, ...
+	Code    int                  `json:"code"`
+	Body    json.RawMessage      `json:"body"`
+	Message *WebMessagingMessage `json:"-"` // set when Class is StructuredMessage and Body is a valid message
+}
+
+// WebMessagingMessage describes a message exchanged with Web Messaging
+//
+// The content and events are the same as OpenMessaging's
+type WebMessagingMessage struct {
+	ID                string                `json:"id,omitempty"`
+	Channel           *WebMessagingChannel  `json:"channel,omitempty"`
+	Type              string                `json:"type"` // Text, Structured, Event
+	Text              string                `json:"text,omitempty"`
+	Content           []*OpenMessageContent `json:"content,omitempty"`
+	Events            []*OpenMessageEvent   `json:"events,omitempty"`
+	Direction         string                `json:"direction,omitempty"`         // Inbound, Outbound
+	OriginatingEntity string                `json:"originatingEntity,omitempty"` // Human, Bot
+}
+
+// WebMessagingChannel describes the channel of a WebMessagingMessage
+type WebMessagingChannel struct {
+	MessageID        string            `json:"messageId,omitempty"`
+	Time             time.Time         `json:"-"`
+	From             *OpenMessageFrom  `json:"from,omitempty"`
+	CustomAttributes map[string]string `json:"-"`
+}
+
+// WebMessagingPresignedURL describes where to upload an attachment
+type WebMessagingPresignedURL struct {
+	AttachmentID string            `json:"attachmentId"`
+	URL          string            `json:"url"`
+	FileName     string            `json:"fileName"`
+	Headers      map[string]string `json:"headers"`
+}
+
+// WebMessagingUploadSuccess describes an attachment that was uploaded successfully
+type WebMessagingUploadSuccess struct {
+	AttachmentID string    `json:"attachmentId"`
+	DownloadURL  string    `json:"downloadUrl"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// IsFromAgent tells if the message was sent by an agent or a bot
+func (message WebMessagingMessage) IsFromAgent() bool {
+	return message.Direction == "Outbound"
+}
+
+// IsTyping tells if the message is a typing indicator
+func (message WebMessagingMessage) IsTyping() bool {
+	for _, event := range message.Events {
+		if event.Type == "Typing" {
+			return true
+		}
+	}
+	return false
+}
+
+// UnmarshalJSON unmarshals JSON into this
+func (event *WebMessagingEvent) UnmarshalJSON(payload []byte) (err error) {
+	type surrogate WebMessagingEvent
+	var inner surrogate
+
+	if err = json.Unmarshal(payload, &inner); err != nil {
+		return errors.JSONUnmarshalError.Wrap(err)
+	}
+	*event = WebMessagingEvent(inner)
+	if event.Class == "StructuredMessage" && len(event.Body) > 0 {
+		// The event is kept even if its message cannot be decoded, its Body is still available
+		message := &WebMessagingMessage{}
+		if json.Unmarshal(event.Body, message) == nil {
+			event.Message = message
+		}
+	}
+	return nil
+}
+
+// UnmarshalJSON unmarshals JSON into this
+//
+// The content items and events that cannot be decoded (unknown types, missing properties) are skipped
+func (message *WebMessagingMessage) UnmarshalJSON(payload []byte) (err error) {
+	type surrogate WebMessagingMessage
+	var inner struct {
+		surrogate
+		Content []json.RawMessage `json:"content"`
+		Events  []json.RawMessage `json:"events"`
+	}
+	if err = json.Unmarshal(payload, &inner); err != nil {
+		return errors.JSONUnmarshalError.Wrap(err)
+	}
+	*message = WebMessagingMessage(inner.surrogate)
+	for _, item := range inner.Content {
+		content := &OpenMessageContent{}
+		if json.Unmarshal(item, content) == nil {
+			message.Content = append(message.Content, content)
+		}
+	}
+	for _, item := range inner.Events {
+		event := &OpenMessageEvent{}
+		if json.Unmarshal(item, event) == nil {
+			message.Events = append(message.Events, event)
+		}
+	}
+	return
+}
+
+// webMessagingMetadata describes the metadata of a WebMessagingChannel
+type webMessagingMetadata struct {
+	CustomAttributes map[string]string `json:"customAttributes"`
+}
+
+// MarshalJSON marshals this into JSON
+func (channel WebMessagingChannel) MarshalJSON() ([]byte, error) {
+	type surrogate WebMessagingChannel
+	var metadata *webMessagingMetadata
+	if len(channel.CustomAttributes) > 0 {
+		metadata = &webMessagingMetadata{CustomAttributes: channel.CustomAttributes}
+	}
+	var timestamp *core.Time
+	if !channel.Time.IsZero() {
+		timestamp = (*core.Time)(&channel.Time)
+	}
+	data, err := json.Marshal(struct {
+		surrogate
+		T *core.Time            `json:"time,omitempty"`
+		M *webMessagingMetadata `json:"metadata,omitempty"`
+	}{
+		surrogate: surrogate(channel),
+		T:         timestamp,
+		M:         metadata,
+	})
+	return data, errors.JSONMarshalError.Wrap(err)
+}
+
+// UnmarshalJSON unmarshals JSON into this
+func (channel *WebMessagingChannel) UnmarshalJSON(payload []byte) (err error) {
+	type surrogate WebMessagingChannel
+	var inner struct {
+		surrogate
+		T        core.Time            `json:"time"`
+		Metadata webMessagingMetadata `json:"metadata"`
+	}
+	if err = json.Unmarshal(payload, &inner); err != nil {
+		return errors.JSONUnmarshalError.Wrap(err)
+	}
+	*channel = WebMessagingChannel(inner.surrogate)
+	channel.Time = inner.T.AsTime()
+	channel.CustomAttributes = inner.Metadata.CustomAttributes
+	return
+}
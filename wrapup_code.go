@@ -0,0 +1,282 @@
+package gcloudcx
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gildas/go-errors"
+	"github.com/google/uuid"
+)
+
+// WrapupCode describes a Wrap-up Code of the organization
+type WrapupCode struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	DateCreated time.Time `json:"dateCreated,omitempty"`
+	CreatedBy   string    `json:"createdBy,omitempty"`
+	SelfURI     string    `json:"selfUri,omitempty"`
+}
+
+// FetchWrapupCodes fetches all the Wrap-up Codes of the organization
+//
+// See https://developer.genesys.cloud/api/rest/v2/routing/#get-api-v2-routing-wrapupcodes
+func (client *Client) FetchWrapupCodes() ([]*WrapupCode, error) {
+	codes := []*WrapupCode{}
+	page := 1
+	for {
+		response := struct {
+			Entities  []*WrapupCode `json:"entities"`
+			PageCount int           `json:"pageCount"`
+		}{}
+		if err := client.Get(NewURI("/routing/wrapupcodes?pageSize=100&pageNumber=%d", page), &response); err != nil {
+			return nil, err
+		}
+		codes = append(codes, response.Entities...)
+		if page >= response.PageCount {
+			break
+		}
+		page++
+	}
+	return codes, nil
+}
+
+// FindWrapupCodeByName finds a Wrap-up Code of the organization by its name (case insensitive)
+func (client *Client) FindWrapupCodeByName(name string) (*WrapupCode, error) {
+	if len(name) == 0 {
+		return nil, errors.ArgumentMissing.With("name").WithStack()
+	}
+	codes, err := client.FetchWrapupCodes()
+	if err != nil {
+		return nil, err
+	}
+	if code := findWrapupCode(codes, name); code != nil {
+		return code, nil
+	}
+	return nil, errors.NotFound.With("wrapupcode", name).WithStack()
+}
+
+// CreateWrapupCode creates a new Wrap-up Code in the organization
+//
+// See https://developer.genesys.cloud/api/rest/v2/routing/#post-api-v2-routing-wrapupcodes
+func (client *Client) CreateWrapupCode(name string) (*WrapupCode, error) {
+	if len(name) == 0 {
+		return nil, errors.ArgumentMissing.With("name").WithStack()
+	}
+	code := &WrapupCode{}
+	if err := client.Post("/routing/wrapupcodes", struct {
+		Name string `json:"name"`
+	}{Name: name}, &code); err != nil {
+		return nil, err
+	}
+	return code, nil
+}
+
+// DeleteWrapupCode deletes a Wrap-up Code from the organization
+func (client *Client) DeleteWrapupCode(code Identifiable) error {
+	if code == nil || code.GetID() == uuid.Nil {
+		return errors.ArgumentMissing.With("code").WithStack()
+	}
+	return client.Delete(NewURI("/routing/wrapupcodes/%s", code.GetID()), nil)
+}
+
+// FindWrapupCode finds a Wrap-up Code assigned to this Queue by its identifier or its name (case insensitive)
+func (queue *Queue) FindWrapupCode(key string) (*WrapupCode, error) {
+	if len(key) == 0 {
+		return nil, errors.ArgumentMissing.With("code").WithStack()
+	}
+	codes, err := queue.FetchWrapupCodes()
+	if err != nil {
+		return nil, err
+	}
+	if code := findWrapupCode(codes, key); code != nil {
+		return code, nil
+	}
+	return nil, errors.NotFound.With("wrapupcode", key).WithStack()
+}
+
+// FetchWrapupCodes fetches the Wrap-up Codes assigned to this Queue
+//
+// See https://developer.genesys.cloud/api/rest/v2/routing/#get-api-v2-routing-queues--queueId--wrapupcodes
+func (queue *Queue) FetchWrapupCodes() ([]*WrapupCode, error) {
+	codes := []*WrapupCode{}
+	page := 1
+	for {
+		response := struct {
+			Entities  []*WrapupCode `json:"entities"`
+			PageCount int           `json:"pageCount"`
+		}{}
+		if err := queue.Client.Get(NewURI("/routing/queues/%s/wrapupcodes?pageSize=100&pageNumber=%d", queue.ID, page), &response); err != nil {
+			return nil, err
+		}
+		codes = append(codes, response.Entities...)
+		if page >= response.PageCount {
+			break
+		}
+		page++
+	}
+	return codes, nil
+}
+
+// AddWrapupCodes assigns the given Wrap-up Codes to this Queue
+//
+// See https://developer.genesys.cloud/api/rest/v2/routing/#post-api-v2-routing-queues--queueId--wrapupcodes
+func (queue *Queue) AddWrapupCodes(codes ...Identifiable) error {
+	if len(codes) == 0 {
+		return nil
+	}
+	payload := make([]queueEntity, 0, len(codes))
+	for _, code := range codes {
+		payload = append(payload, queueEntity{ID: code.GetID()})
+	}
+	defer queue.Client.wrapupCodes.invalidate(queue.ID)
+	return queue.Client.Post(NewURI("/routing/queues/%s/wrapupcodes", queue.ID), payload, nil)
+}
+
+// RemoveWrapupCode unassigns the given Wrap-up Code from this Queue
+func (queue *Queue) RemoveWrapupCode(code Identifiable) error {
+	if code == nil || code.GetID() == uuid.Nil {
+		return errors.ArgumentMissing.With("code").WithStack()
+	}
+	defer queue.Client.wrapupCodes.invalidate(queue.ID)
+	return queue.Client.Delete(NewURI("/routing/queues/%s/wrapupcodes/%s", queue.ID, code.GetID()), nil)
+}
+
+// GetID gets the identifier of this
+//
+//   implements Identifiable
+func (code WrapupCode) GetID() uuid.UUID {
+	return code.ID
+}
+
+// String gets a string version
+//
+//   implements the fmt.Stringer interface
+func (code WrapupCode) String() string {
+	if len(code.Name) > 0 {
+		return code.Name
+	}
+	return code.ID.String()
+}
+
+// findWrapupCode finds a Wrap-up Code by its identifier or its name (case insensitive)
+func findWrapupCode(codes []*WrapupCode, key string) *WrapupCode {
+	for _, code := range codes {
+		if strings.EqualFold(code.ID.String(), key) || strings.EqualFold(code.Name, key) {
+			return code
+		}
+	}
+	return nil
+}
+
+// resolveWrapup validates the given Wrapup against the Wrap-up Codes of the queue of the participant
+//
+// The Wrapup Code (or its Name if the Code is empty) can be the identifier or the name of a Wrap-up Code.
+// The returned Wrapup is a copy with the Code and Name of the matching Wrap-up Code.
+//
+// The Wrapup is sent as is, without validation, if the queue of the participant cannot be found in the participants
+// or if the Wrap-up Codes of that queue cannot be fetched (e.g.: missing routing:wrapupCode:view permission).
+func resolveWrapup(client *Client, participants []*Participant, identifiable Identifiable, wrapup *Wrapup) (*Wrapup, error) {
+	if wrapup == nil {
+		return nil, errors.ArgumentMissing.With("wrapup").WithStack()
+	}
+	key := wrapup.Code
+	if len(key) == 0 {
+		key = wrapup.Name
+	}
+	if len(key) == 0 {
+		return nil, errors.ArgumentMissing.With("code").WithStack()
+	}
+	queueID := participantQueueID(participants, identifiable)
+	if queueID == uuid.Nil {
+		return wrapup, nil
+	}
+	codes, err := client.wrapupCodes.get(client, queueID)
+	if err != nil {
+		client.Logger.Warnf("Failed to fetch the Wrap-up Codes of queue %s, the wrap-up code %s will not be validated: %s", queueID, key, err)
+		return wrapup, nil
+	}
+	code := findWrapupCode(codes, key)
+	if code == nil {
+		return nil, errors.ArgumentInvalid.With("wrapup", key).WithStack()
+	}
+	resolved := *wrapup
+	resolved.Code = code.ID.String()
+	resolved.Name = code.Name
+	return &resolved, nil
+}
+
+// wrapupCodeCache keeps the Wrap-up Codes of queues for wrap-up validation
+type wrapupCodeCache struct {
+	entries map[uuid.UUID]*wrapupCodeCacheEntry
+	mutex   sync.RWMutex
+}
+
+type wrapupCodeCacheEntry struct {
+	Codes     []*WrapupCode
+	ExpiresOn time.Time
+}
+
+// wrapupCodeCacheTTL tells how long the Wrap-up Codes of a queue are kept
+const wrapupCodeCacheTTL = 5 * time.Minute
+
+// get gets the Wrap-up Codes of the given queue, fetching them if they are not cached or have expired
+func (cache *wrapupCodeCache) get(client *Client, queueID uuid.UUID) ([]*WrapupCode, error) {
+	cache.mutex.RLock()
+	entry, found := cache.entries[queueID]
+	cache.mutex.RUnlock()
+	if found && time.Now().Before(entry.ExpiresOn) {
+		return entry.Codes, nil
+	}
+	codes, err := (&Queue{ID: queueID, Client: client}).FetchWrapupCodes()
+	if err != nil {
+		return nil, err
+	}
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if cache.entries == nil {
+		cache.entries = map[uuid.UUID]*wrapupCodeCacheEntry{}
+	}
+	cache.entries[queueID] = &wrapupCodeCacheEntry{Codes: codes, ExpiresOn: time.Now().Add(wrapupCodeCacheTTL)}
+	return codes, nil
+}
+
+// invalidate removes the Wrap-up Codes of the given queue from the cache
+func (cache *wrapupCodeCache) invalidate(queueID uuid.UUID) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	delete(cache.entries, queueID)
+}
+
+// participantQueueID finds the identifier of the queue of the given participant
+//
+// If the given participant does not carry its queue, it is looked up in the participants.
+func participantQueueID(participants []*Participant, identifiable Identifiable) uuid.UUID {
+	switch participant := identifiable.(type) {
+	case *Participant:
+		if id := participant.queueID(); id != uuid.Nil {
+			return id
+		}
+	case Participant:
+		if id := participant.queueID(); id != uuid.Nil {
+			return id
+		}
+	}
+	for _, participant := range participants {
+		if participant != nil && participant.ID == identifiable.GetID() {
+			return participant.queueID()
+		}
+	}
+	return uuid.Nil
+}
+
+// queueID gets the identifier of the queue of this participant, if any
+func (participant Participant) queueID() uuid.UUID {
+	if participant.Queue != nil && participant.Queue.ID != uuid.Nil {
+		return participant.Queue.ID
+	}
+	if id, err := uuid.Parse(participant.QueueID); err == nil {
+		return id
+	}
+	return uuid.Nil
+}
@@ -0,0 +1,198 @@
+package gcloudcx_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gildas/go-errors"
+	"github.com/gildas/go-gcloudcx"
+	"github.com/gildas/go-logger"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+type WrapupCodeSuite struct {
+	suite.Suite
+	Name   string
+	Logger *logger.Logger
+	Start  time.Time
+
+	QueueID          uuid.UUID
+	ForbiddenQueueID uuid.UUID
+	CodeID           uuid.UUID
+	Recorder         *RequestRecorder
+	Server           *httptest.Server
+	Client           *gcloudcx.Client
+}
+
+func TestWrapupCodeSuite(t *testing.T) {
+	suite.Run(t, new(WrapupCodeSuite))
+}
+
+func (suite *WrapupCodeSuite) TestCanFetchWrapupCodes() {
+	codes, err := suite.Client.FetchWrapupCodes()
+	suite.Require().Nilf(err, "Failed to fetch wrap-up codes. %s", err)
+	suite.Assert().Len(codes, 3)
+	suite.Assert().Equal([]string{
+		"GET /api/v2/routing/wrapupcodes?pageSize=100&pageNumber=1",
+		"GET /api/v2/routing/wrapupcodes?pageSize=100&pageNumber=2",
+	}, suite.Recorder.Requests())
+}
+
+func (suite *WrapupCodeSuite) TestCanFindWrapupCodeByName() {
+	code, err := suite.Client.FindWrapupCodeByName("sale completed")
+	suite.Require().Nilf(err, "Failed to find wrap-up code. %s", err)
+	suite.Assert().Equal(suite.CodeID, code.ID)
+
+	_, err = suite.Client.FindWrapupCodeByName("nothing")
+	suite.Require().NotNil(err, "Wrap-up code should not have been found")
+	suite.Assert().True(errors.Is(err, errors.NotFound), "Error should be a NotFound")
+}
+
+func (suite *WrapupCodeSuite) TestCanCreateAndDeleteWrapupCode() {
+	code, err := suite.Client.CreateWrapupCode("Sale Completed")
+	suite.Require().Nilf(err, "Failed to create wrap-up code. %s", err)
+	suite.Assert().Equal(suite.CodeID, code.ID)
+	suite.Assert().Equal(`{"name":"Sale Completed"}`, suite.Recorder.Bodies()[0])
+	err = suite.Client.DeleteWrapupCode(code)
+	suite.Require().Nilf(err, "Failed to delete wrap-up code. %s", err)
+	suite.Assert().Equal("DELETE /api/v2/routing/wrapupcodes/"+suite.CodeID.String(), suite.Recorder.Requests()[1])
+}
+
+func (suite *WrapupCodeSuite) TestCanWrapupWithCodeName() {
+	participant := &gcloudcx.Participant{ID: uuid.New(), QueueID: suite.QueueID.String()}
+	conversation := gcloudcx.ConversationChat{ID: uuid.New(), Participants: []*gcloudcx.Participant{participant}, Client: suite.Client}
+	err := conversation.Wrapup(participant, &gcloudcx.Wrapup{Code: "Sale Completed", Notes: "Happy customer"})
+	suite.Require().Nilf(err, "Failed to wrap up. %s", err)
+	suite.Assert().Equal(fmt.Sprintf("GET /api/v2/routing/queues/%s/wrapupcodes?pageSize=100&pageNumber=1", suite.QueueID), suite.Recorder.Requests()[0])
+	suite.Assert().Equal(fmt.Sprintf("PATCH /api/v2/conversations/chats/%s/participants/%s", conversation.ID, participant.ID), suite.Recorder.Requests()[1])
+	suite.Assert().Contains(suite.Recorder.Bodies()[1], fmt.Sprintf(`"code":"%s"`, suite.CodeID))
+	suite.Assert().Contains(suite.Recorder.Bodies()[1], `"notes":"Happy customer"`)
+}
+
+func (suite *WrapupCodeSuite) TestShouldNotWrapupWithUnassignedCode() {
+	participant := &gcloudcx.Participant{ID: uuid.New(), Queue: &gcloudcx.Queue{ID: suite.QueueID}}
+	conversation := gcloudcx.ConversationCall{ID: uuid.New(), Participants: []*gcloudcx.Participant{participant}, Client: suite.Client}
+	err := conversation.Wrapup(gcloudcx.Participant{ID: participant.ID}, &gcloudcx.Wrapup{Code: "Default Wrap-up Code"})
+	suite.Require().NotNil(err, "Wrap-up should have failed")
+	suite.Assert().True(errors.Is(err, errors.ArgumentInvalid), "Error should be an ArgumentInvalid")
+	suite.Assert().Len(suite.Recorder.Requests(), 1, "The participant should not have been patched")
+}
+
+func (suite *WrapupCodeSuite) TestShouldCacheQueueWrapupCodes() {
+	participant := &gcloudcx.Participant{ID: uuid.New(), QueueID: suite.QueueID.String()}
+	conversation := gcloudcx.ConversationChat{ID: uuid.New(), Participants: []*gcloudcx.Participant{participant}, Client: suite.Client}
+	for i := 0; i < 3; i++ {
+		err := conversation.Wrapup(participant, &gcloudcx.Wrapup{Code: "Sale Completed"})
+		suite.Require().Nilf(err, "Failed to wrap up. %s", err)
+	}
+	suite.Assert().Len(suite.Recorder.Requests(), 4, "The Wrap-up Codes of the queue should have been fetched only once")
+
+	suite.Recorder.Reset()
+	queue := &gcloudcx.Queue{ID: suite.QueueID, Client: suite.Client}
+	err := queue.AddWrapupCodes(gcloudcx.WrapupCode{ID: suite.CodeID})
+	suite.Require().Nilf(err, "Failed to add wrap-up codes. %s", err)
+	err = conversation.Wrapup(participant, &gcloudcx.Wrapup{Code: "Sale Completed"})
+	suite.Require().Nilf(err, "Failed to wrap up. %s", err)
+	suite.Assert().Equal(fmt.Sprintf("GET /api/v2/routing/queues/%s/wrapupcodes?pageSize=100&pageNumber=1", suite.QueueID), suite.Recorder.Requests()[1], "Changing the queue should invalidate its cached Wrap-up Codes")
+}
+
+func (suite *WrapupCodeSuite) TestCanWrapupWhenQueueWrapupCodesAreForbidden() {
+	participant := &gcloudcx.Participant{ID: uuid.New(), QueueID: suite.ForbiddenQueueID.String()}
+	conversation := gcloudcx.ConversationCall{ID: uuid.New(), Participants: []*gcloudcx.Participant{participant}, Client: suite.Client}
+	err := conversation.Wrapup(participant, &gcloudcx.Wrapup{Code: suite.CodeID.String()})
+	suite.Require().Nilf(err, "Failed to wrap up. %s", err)
+	suite.Require().Len(suite.Recorder.Requests(), 2)
+	suite.Assert().Equal(fmt.Sprintf("PATCH /api/v2/conversations/calls/%s/participants/%s", conversation.ID, participant.ID), suite.Recorder.Requests()[1])
+	suite.Assert().Contains(suite.Recorder.Bodies()[1], fmt.Sprintf(`"code":"%s"`, suite.CodeID))
+}
+
+func (suite *WrapupCodeSuite) TestCanWrapupWithoutQueue() {
+	participant := &gcloudcx.Participant{ID: uuid.New()}
+	conversation := gcloudcx.ConversationEmail{ID: uuid.New(), Participants: []*gcloudcx.Participant{participant}, Client: suite.Client}
+	err := conversation.Wrapup(participant, &gcloudcx.Wrapup{Code: "Anything"})
+	suite.Require().Nilf(err, "Failed to wrap up. %s", err)
+	suite.Require().Len(suite.Recorder.Requests(), 1)
+	suite.Assert().Equal(fmt.Sprintf("PATCH /api/v2/conversations/emails/%s/participants/%s", conversation.ID, participant.ID), suite.Recorder.Requests()[0])
+	suite.Assert().Contains(suite.Recorder.Bodies()[0], `"code":"Anything"`)
+}
+
+func (suite *WrapupCodeSuite) TestShouldNotWrapupWithoutWrapup() {
+	participant := &gcloudcx.Participant{ID: uuid.New()}
+	conversation := gcloudcx.ConversationChat{ID: uuid.New(), Client: suite.Client}
+	err := conversation.Wrapup(participant, nil)
+	suite.Require().NotNil(err, "Wrap-up should have failed")
+	suite.Assert().True(errors.Is(err, errors.ArgumentMissing), "Error should be an ArgumentMissing")
+	suite.Assert().Empty(suite.Recorder.Requests())
+}
+
+// Suite Tools
+
+func (suite *WrapupCodeSuite) SetupSuite() {
+	suite.Name = strings.TrimSuffix(reflect.TypeOf(*suite).Name(), "Suite")
+	suite.Logger = logger.Create("test",
+		&logger.FileStream{
+			Path:        fmt.Sprintf("./log/test-%s.log", strings.ToLower(suite.Name)),
+			Unbuffered:  true,
+			FilterLevel: logger.TRACE,
+		},
+	).Child("test", "test")
+	suite.Logger.Infof("Suite Start: %s %s", suite.Name, strings.Repeat("=", 80-14-len(suite.Name)))
+	suite.QueueID = uuid.MustParse("4c4e3e2a-9d1b-4a8e-b1f3-0f2a6d7c5e11")
+	suite.ForbiddenQueueID = uuid.MustParse("5d5f4f3b-ae2c-4b9f-82a4-1a3b7e8d6f22")
+	suite.CodeID = uuid.MustParse("b2a3c4d5-6e7f-4a1b-9c2d-3e4f5a6b7c8d")
+	suite.Recorder = NewRequestRecorder(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		code := fmt.Sprintf(`{"id": "%s", "name": "Sale Completed"}`, suite.CodeID)
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v2/routing/wrapupcodes":
+			if r.URL.Query().Get("pageNumber") == "1" {
+				_, _ = w.Write([]byte(fmt.Sprintf(`{"entities": [%s, {"id": "%s", "name": "Default Wrap-up Code"}], "pageCount": 2}`, code, uuid.New())))
+			} else {
+				_, _ = w.Write([]byte(fmt.Sprintf(`{"entities": [{"id": "%s", "name": "No Sale"}], "pageCount": 2}`, uuid.New())))
+			}
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v2/routing/queues/"+suite.QueueID.String()+"/wrapupcodes":
+			_, _ = w.Write([]byte(`{"entities": [` + code + `], "pageCount": 1}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v2/routing/queues/"+suite.ForbiddenQueueID.String()+"/wrapupcodes":
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"status": 403, "code": "missing.permissions", "message": "Missing routing:wrapupCode:view"}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v2/routing/wrapupcodes":
+			_, _ = w.Write([]byte(code))
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			_, _ = w.Write([]byte(`{}`))
+		}
+	})
+	suite.Server = httptest.NewServer(suite.Recorder)
+}
+
+func (suite *WrapupCodeSuite) TearDownSuite() {
+	suite.Server.Close()
+	if suite.T().Failed() {
+		suite.Logger.Warnf("At least one test failed, we are not cleaning")
+		suite.T().Log("At least one test failed, we are not cleaning")
+	} else {
+		suite.Logger.Infof("All tests succeeded, we are cleaning")
+	}
+	suite.Logger.Infof("Suite End: %s %s", suite.Name, strings.Repeat("=", 80-12-len(suite.Name)))
+	suite.Logger.Close()
+}
+
+func (suite *WrapupCodeSuite) BeforeTest(suiteName, testName string) {
+	suite.Logger.Infof("Test Start: %s %s", testName, strings.Repeat("-", 80-13-len(testName)))
+	suite.Start = time.Now()
+	suite.Recorder.Reset()
+	// Each test gets its own client so the cached Wrap-up Codes do not leak between tests
+	suite.Client = CreateTestClient(suite.Server.URL, suite.Logger)
+}
+
+func (suite *WrapupCodeSuite) AfterTest(suiteName, testName string) {
+	duration := time.Since(suite.Start)
+	suite.Logger.Record("duration", duration.String()).Infof("Test End: %s %s", testName, strings.Repeat("-", 80-11-len(testName)))
+}